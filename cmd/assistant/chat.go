@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/poy/assistant/pkg/chat"
+)
+
+const chatUsage = "chat --post-url <url> [--addr <host:port>] [--dir <dir>]"
+
+// runChat serves the chat platform's webhook until it is interrupted. The
+// token for posting replies and the secret for the webhook's signatures are
+// read from ASSISTANT_CHAT_TOKEN and ASSISTANT_CHAT_SECRET. The secret is
+// required.
+func runChat(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8081", "The address to listen on for the webhook")
+	postURL := fs.String("post-url", "", "The URL the replies are posted to")
+	dir := fs.String("dir", assistantDir()+"/chat", "Where each chat user's sessions are saved")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *postURL == "" {
+		return usageError(chatUsage)
+	}
+
+	client := &chat.WebhookClient{
+		URL:   *postURL,
+		Token: os.Getenv("ASSISTANT_CHAT_TOKEN"),
+	}
+	adapter, err := chat.New(
+		ctx,
+		client,
+		os.Getenv("ASSISTANT_CHAT_SECRET"),
+		chat.WithDir(*dir),
+		chat.WithTenants(newTenants()),
+		chat.WithTimeout(settings.Agent.Timeout),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start the chat adapter (is ASSISTANT_CHAT_SECRET set?): %w", err)
+	}
+	defer adapter.Close()
+
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: adapter,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening for chat messages on http://%s", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
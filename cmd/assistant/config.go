@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/poy/assistant/pkg/config"
+)
+
+func loadSettings() (config.Settings, error) {
+	dir := assistantDir()
+
+	p := *configPath
+	if p == "" {
+		p = os.Getenv("ASSISTANT_CONFIG")
+	}
+	if p == "" {
+		p = filepath.Join(dir, "config.yaml")
+	}
+
+	f, err := config.Load(p)
+	if err != nil {
+		return config.Settings{}, err
+	}
+
+	// Only the flags the user set override the other layers.
+	flags := map[string]string{}
+	flag.Visit(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+
+	return config.Resolve(config.Defaults(dir), f, os.Getenv, flags)
+}
+
+func runConfig(ctx context.Context, args []string) error {
+	if len(args) != 1 || args[0] != "show" {
+		return usageError("config show")
+	}
+
+	profile := settings.Profile
+	if profile == "" {
+		profile = "(none)"
+	}
+	fmt.Printf("profile: %s\n\n", profile)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, e := range settings.Entries() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Key, e.Value, e.Source)
+	}
+	return w.Flush()
+}
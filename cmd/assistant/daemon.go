@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/poy/assistant/pkg/reminders"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+const daemonUsage = "daemon [--notify bell,notify-send,webhook,email] [--interval <duration>] [--sent <file>]"
+
+// runDaemon sends reminders for the upcoming and overdue tasks until it is
+// interrupted.
+func runDaemon(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	notify := fs.String("notify", "bell", "How to send the reminders (bell, notify-send, webhook and/or email)")
+	interval := fs.Duration("interval", time.Minute, "How often to check the tasks")
+	sent := fs.String("sent", assistantDir()+"/reminders-sent.json", "Where to keep which reminders were sent")
+	webhookURL := fs.String("webhook-url", "", "Where to post the reminders for the webhook notifier")
+	smtpAddr := fs.String("smtp-addr", "localhost:25", "The SMTP server for the email notifier")
+	emailFrom := fs.String("email-from", "assistant@localhost", "Who the emails are from")
+	emailTo := fs.String("email-to", "", "Who to email the reminders to (comma separated)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return usageError(daemonUsage)
+	}
+
+	opts := []reminders.Option{
+		reminders.WithInterval(*interval),
+		reminders.WithSentPath(*sent),
+	}
+	for _, name := range strings.Split(*notify, ",") {
+		switch strings.TrimSpace(name) {
+		case "bell":
+			opts = append(opts, reminders.WithNotifier(reminders.Bell{W: os.Stdout}))
+		case "notify-send":
+			opts = append(opts, reminders.WithNotifier(reminders.NotifySend()))
+		case "webhook":
+			if *webhookURL == "" {
+				return fmt.Errorf("the webhook notifier needs --webhook-url")
+			}
+			opts = append(opts, reminders.WithNotifier(reminders.Webhook{URL: *webhookURL}))
+		case "email":
+			if *emailTo == "" {
+				return fmt.Errorf("the email notifier needs --email-to")
+			}
+			opts = append(opts, reminders.WithNotifier(reminders.Email{
+				Addr: *smtpAddr,
+				From: *emailFrom,
+				To:   strings.Split(*emailTo, ","),
+			}))
+		default:
+			return fmt.Errorf("unknown notifier %q", name)
+		}
+	}
+
+	s := injection.Resolve[tasks.Store](injection.WithInjection(ctx))
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	log.Printf("checking the tasks every %s for reminders", *interval)
+	return reminders.New(s, opts...).Run(ctx)
+}
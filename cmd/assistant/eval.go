@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/eval"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+const evalUsage = "eval [--model <model>] [--json] <suite.yaml>"
+
+// runEval runs the scenarios in the suite and reports how the agents did.
+// Each scenario has its own tasks, so the user's tasks aren't touched.
+func runEval(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	model := fs.String("model", settings.LLM.Model, "The model to evaluate")
+	asJSON := fs.Bool("json", false, "Write the report as JSON")
+	timeout := fs.Duration("timeout", settings.Agent.Timeout, "How long each thing the user says can run for (0 for no limit)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return usageError(evalUsage)
+	}
+
+	suite, err := eval.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// The params are resolved for each scenario, so they use the model too.
+	settings.LLM.Model = *model
+	llm := injection.Resolve[llms.LLM[vertex.Params]](injection.WithInjection(ctx))
+	r := eval.Run(ctx, suite, llm, eval.Options{
+		Model:   *model,
+		Timeout: *timeout,
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to write the report: %w", err)
+		}
+		return nil
+	}
+	return r.Print(os.Stdout)
+}
@@ -2,51 +2,219 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/google/go-react/pkg/llms"
 	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/config"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/logging"
+	"github.com/poy/assistant/pkg/sessions"
+	assistanttools "github.com/poy/assistant/pkg/tools"
 	"github.com/poy/assistant/pkg/tools/tasks"
-	"github.com/poy/assistant/pkg/tools/userinput"
+	"github.com/poy/assistant/pkg/tracing"
 	"github.com/poy/go-dependency-injection/pkg/injection"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-var model = flag.String("model", "text-bison@001", "The model to use for the prompt")
-var apiEndpoint = flag.String("api-endpoint", "us-central1-aiplatform.googleapis.com", "The API endpoint to use")
-var projectID = flag.String("project-id", os.Getenv("GCP_PROJECT_ID"), "The project ID to use")
-var maxTokens = flag.Int("max-tokens", 1024, "The maximum number of tokens to generate")
-var temperature = flag.Float64("temperature", 0.2, "The temperature to use for the prompt")
-var topK = flag.Int("top-k", 40, "The top-k value to use for the prompt")
-var topP = flag.Float64("top-p", 0.9, "The top-p value to use for the prompt")
+var (
+	configPath = flag.String("config", "", "The config file to use (defaults to ~/.assistant/config.yaml)")
+	debug      = flag.Bool("debug", false, "Show the agents' thoughts (the same as --log-level debug)")
+)
+
+// settings are the effective settings after layering the flags, environment
+// variables, config file profile and defaults.
+var settings config.Settings
+
+func init() {
+	// These flags are only used if they are explicitly set. Otherwise the
+	// environment variables and config file are used.
+	defaults := config.Defaults("~/.assistant")
+	flag.String("profile", "", "The profile from the config file to use")
+	flag.String("llm-provider", defaults.LLM.Provider, "The LLM provider to use")
+	flag.String("model", defaults.LLM.Model, "The model to use for the prompt")
+	flag.String("api-endpoint", defaults.LLM.APIEndpoint, "The API endpoint to use")
+	flag.String("project-id", "", "The project ID to use (defaults to $GCP_PROJECT_ID)")
+	flag.Int("max-tokens", defaults.LLM.MaxTokens, "The maximum number of tokens to generate")
+	flag.Float64("temperature", defaults.LLM.Temperature, "The temperature to use for the prompt")
+	flag.Int("top-k", defaults.LLM.TopK, "The top-k value to use for the prompt")
+	flag.Float64("top-p", defaults.LLM.TopP, "The top-p value to use for the prompt")
+	flag.String("store-backend", defaults.Store.Backend, "Where to keep the tasks (file or memory)")
+	flag.String("store-path", defaults.Store.Path, "The file to save the tasks to")
+	flag.String("tenants-dir", defaults.Store.TenantsDir, "Where each user's tasks are saved when serving several users")
+	flag.Duration("timeout", defaults.Agent.Timeout, "How long a single goal can run for (0 for no limit)")
+	flag.Int("max-iterations", defaults.Agent.MaxIterations, "The maximum number of reasoning iterations per agent (0 for no limit)")
+	flag.Int("token-budget", defaults.Agent.TokenBudget, "Roughly how many tokens a goal can use before it is stopped (0 for no limit)")
+	flag.String("output", defaults.Display.Format, "How to show output to the user (terminal or json)")
+	flag.Int("summary-chars", defaults.Display.SummaryChars, "How much of what was shown to the user is given back to the agent")
+	flag.String("trace-exporter", defaults.Tracing.Exporter, "Where to export the OpenTelemetry spans (none, stdout or otlp)")
+	flag.String("trace-endpoint", "", "The OTLP collector to send the spans to (defaults to $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	flag.String("log-level", defaults.Log.Level, "What to log to stderr (prompt, debug, info, warn or error)")
+	flag.String("log-file", "", "A file to also write the logs to as JSON")
+}
+
+// command is a subcommand of the assistant.
+type command struct {
+	usage       string
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+func commands() map[string]command {
+	return map[string]command{
+		"repl": {
+			usage:       "repl",
+			description: "Start an interactive session (default)",
+			run:         runREPLCommand,
+		},
+		"ask": {
+			usage:       `ask "<goal>"`,
+			description: "Run the agent once for the given goal and exit",
+			run:         runAsk,
+		},
+		"today": {
+			usage:       "today [--offline]",
+			description: "Show what needs attention today and a plan for the day",
+			run:         runToday,
+		},
+		"review": {
+			usage:       "review [--days <n>] [--stale <n>] [--offline]",
+			description: "Review what was completed, what is stale and the trends",
+			run:         runReview,
+		},
+		"eval": {
+			usage:       evalUsage,
+			description: "Score the agents on a suite of scenarios",
+			run:         runEval,
+		},
+		"tasks": {
+			usage:       "tasks list|show <name>|add --title <title> [--description <description>]|export|import",
+			description: "Manage the tasks directly without the LLM",
+			run:         runTasks,
+		},
+		"config": {
+			usage:       "config show",
+			description: "Show the effective settings and where they came from",
+			run:         runConfig,
+		},
+		"sessions": {
+			usage:       "sessions list|show <id>|resume <id>",
+			description: "Look at or resume previous sessions",
+			run:         runSessions,
+		},
+		"usage": {
+			usage:       "usage",
+			description: "Show how much of the LLM the goals have used",
+			run:         runUsage,
+		},
+		"serve": {
+			usage:       serveUsage,
+			description: "Serve the tasks and agents over a local HTTP API",
+			run:         runServe,
+		},
+		"mcp": {
+			usage:       "mcp",
+			description: "Serve the task tools with the Model Context Protocol over stdio",
+			run:         runMCP,
+		},
+		"chat": {
+			usage:       chatUsage,
+			description: "Answer messages from a chat platform's webhook",
+			run:         runChat,
+		},
+		"daemon": {
+			usage:       daemonUsage,
+			description: "Send reminders for the upcoming and overdue tasks",
+			run:         runDaemon,
+		},
+		"sync": {
+			usage:       syncUsage,
+			description: "Sync the tasks with a CalDAV calendar",
+			run:         runSync,
+		},
+	}
+}
+
+// usageError is returned when a command is used incorrectly.
+type usageError string
+
+func (e usageError) Error() string {
+	return "usage: assistant " + string(e)
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: assistant [flags] <command> [args]\n\nCommands:\n")
+
+	cmds := commands()
+	var names []string
+	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-10s %s\n", name, cmds[name].description)
+	}
+
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
 
 func main() {
 	log.SetFlags(0)
+	flag.Usage = usage
 	flag.Parse()
 
+	var err error
+	settings, err = loadSettings()
+	if err != nil {
+		log.Fatalf("failed to load settings: %v", err)
+	}
+
+	closeLogs := setupLogging()
+
 	ctx := context.Background()
 	registerLLM(ctx)
-	setupStoreFilePath()
-
-	ctx = injection.WithInjection(ctx)
+	setupStore()
+	setupDisplay()
+	assistanttools.ProvideMaxIterations(settings.Agent.MaxIterations)
+	shutdownTracing := setupTracing(ctx)
 
-	taskAgent := injection.Resolve[tasks.TaskAgent](ctx).Agent
+	name, args := "repl", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
 
-	for {
-		fmt.Println("AI: What is the goal?")
-		fmt.Print("You: ")
-		goal, err := userinput.ReadLine()
-		if err != nil {
-			log.Fatalf("failed to read line: %v", err)
-		}
+	cmd, ok := commands()[name]
+	if !ok {
+		log.Printf("unknown command %q", name)
+		flag.Usage()
+		os.Exit(2)
+	}
 
-		finalAnswer, err := taskAgent.Run(ctx, goal)
-		if err != nil {
-			log.Fatalf("agent.Run failed: %v", err)
+	err = cmd.run(ctx, args)
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("failed to export the spans: %v", err)
+	}
+	closeLogs()
+	if err != nil {
+		var uerr usageError
+		if errors.As(err, &uerr) {
+			log.Print(err)
+			os.Exit(2)
 		}
-		fmt.Println(finalAnswer)
+		log.Printf("%s: %v", name, err)
+		os.Exit(1)
 	}
 }
 
@@ -54,34 +222,42 @@ func registerLLM(ctx context.Context) {
 	injection.Register[vertex.Params](
 		func(ctx context.Context) vertex.Params {
 			return vertex.Params{
-				Model:       *model,
-				MaxTokens:   *maxTokens,
-				Temperature: *temperature,
-				TopK:        *topK,
-				TopP:        *topP,
+				Model:       settings.LLM.Model,
+				MaxTokens:   settings.LLM.MaxTokens,
+				Temperature: settings.LLM.Temperature,
+				TopK:        settings.LLM.TopK,
+				TopP:        settings.LLM.TopP,
 			}
 		},
 	)
+	// The client is shared by every container (e.g., one per chat user).
+	var (
+		once sync.Once
+		llm  llms.LLM[vertex.Params]
+	)
 	injection.Register[llms.LLM[vertex.Params]](
 		func(ctx context.Context) llms.LLM[vertex.Params] {
-			return getLLM(ctx)
+			once.Do(func() {
+				llm = assistanttools.NewContextLLM(getLLM(ctx))
+			})
+			return assistanttools.LLMFor(ctx, llm)
 		},
 	)
 }
 
 func getLLM(ctx context.Context) llms.LLM[vertex.Params] {
-	if *projectID == "" {
-		log.Fatalf("you must set the project-id flag or GCP_PROJECT_ID environment variable")
+	if settings.LLM.ProjectID == "" {
+		log.Fatalf("you must set the project-id flag, GCP_PROJECT_ID environment variable or llm.project_id in the config file")
 	}
 
-	llm, err := vertex.New(ctx, *apiEndpoint, *projectID)
+	llm, err := vertex.New(ctx, settings.LLM.APIEndpoint, settings.LLM.ProjectID)
 	if err != nil {
 		log.Fatalf("failed to create LLM: %v", err)
 	}
 	return llm
 }
 
-func setupStoreFilePath() {
+func assistantDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		log.Fatalf("failed to get user home dir: %v", err)
@@ -91,6 +267,95 @@ func setupStoreFilePath() {
 	if err := os.MkdirAll(home+"/.assistant", 0755); err != nil {
 		log.Fatalf("failed to create directory: %v", err)
 	}
+	return home + "/.assistant"
+}
+
+func setupStore() {
+	// The memory backend is the store without a path.
+	if settings.Store.Backend == config.BackendFile {
+		tasks.ProvideStorePath(settings.Store.Path)
+	}
+}
+
+// newTenants returns the stores for each user and team when serving several
+// users.
+func newTenants() *tasks.Tenants {
+	dir := ""
+	if settings.Store.Backend == config.BackendFile {
+		dir = settings.Store.TenantsDir
+	}
+	return tasks.NewTenants(dir, settings.Store.Teams)
+}
+
+func setupDisplay() {
+	if settings.Display.Format == config.FormatJSON {
+		display.ProvideSink(display.NewJSON(os.Stdout))
+	}
+	display.ProvideSummaryLimit(settings.Display.SummaryChars)
+}
+
+// setupLogging logs at the configured level to stderr and, if there is a log
+// file, as JSON to the file too. The returned function closes the file.
+func setupLogging() func() {
+	level, err := logging.ParseLevel(settings.Log.Level)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *debug && level > slog.LevelDebug {
+		level = slog.LevelDebug
+	}
+
+	handler := logging.NewTextHandler(os.Stderr, level)
+	closeFile := func() {}
+	if settings.Log.File != "" {
+		f, err := os.OpenFile(settings.Log.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatalf("failed to open the log file: %v", err)
+		}
+		handler = logging.Tee(handler, logging.NewJSONHandler(f, level))
+		closeFile = func() { f.Close() }
+	}
+
+	logger := slog.New(handler)
+	logging.ProvideLogger(logger)
+	slog.SetDefault(logger)
+	// SetDefault sends the log package's output to the logger at info,
+	// which would hide the errors that the commands exit with.
+	log.SetOutput(os.Stderr)
+	return closeFile
+}
+
+// setupTracing exports the spans with the configured exporter. The returned
+// function flushes the spans that haven't been exported yet.
+func setupTracing(ctx context.Context) func(context.Context) error {
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch settings.Tracing.Exporter {
+	case config.ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	case config.ExporterOTLP:
+		var opts []otlptracegrpc.Option
+		if settings.Tracing.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(settings.Tracing.Endpoint))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return func(context.Context) error { return nil }
+	}
+	if err != nil {
+		log.Fatalf("failed to create the %s trace exporter: %v", settings.Tracing.Exporter, err)
+	}
+
+	shutdown := tracing.Setup(exporter)
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return shutdown(ctx)
+	}
+}
 
-	tasks.ProvideStorePath(home + "/.assistant/tasks.json")
+func sessionsDir() sessions.Dir {
+	return sessions.Dir(assistantDir() + "/sessions")
 }
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/poy/assistant/pkg/mcp"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// runMCP serves the task tools with the Model Context Protocol over stdin and
+// stdout. Everything else (e.g., the agent logs) goes to stderr.
+func runMCP(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usageError("mcp")
+	}
+
+	ctx = injection.WithInjection(ctx)
+	return mcp.New(ctx, "dev").Serve(ctx, os.Stdin, os.Stdout)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/poy/assistant/pkg/sessions"
+	assistanttools "github.com/poy/assistant/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/assistant/pkg/tools/userinput"
+	llmusage "github.com/poy/assistant/pkg/usage"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// maxHistoryTurns is how many of the previous turns are given to the agent.
+const maxHistoryTurns = 5
+
+func runREPLCommand(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usageError("repl")
+	}
+
+	session, err := sessions.New(sessionsDir())
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return runREPL(ctx, session, nil)
+}
+
+func runREPL(ctx context.Context, session *sessions.Session, history []sessions.Turn) error {
+	defer session.Close()
+	sessions.ProvideRecorder(session)
+
+	ctx = injection.WithInjection(ctx)
+
+	taskAgent := injection.Resolve[tasks.TaskAgent](ctx).Agent
+	s := injection.Resolve[tasks.Store](ctx)
+
+	userinput.SetDefaultEditor(userinput.NewEditor(
+		os.Stdin,
+		os.Stdout,
+		userinput.WithHistoryFile(assistantDir()+"/history"),
+		userinput.WithCompleter(s.TaskNames),
+	))
+
+	for {
+		fmt.Println("AI: What is the goal?")
+		goal, err := userinput.ReadLine("You: ")
+		if errors.Is(err, userinput.ErrInterrupted) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read line: %w", err)
+		}
+		if strings.TrimSpace(goal) == "" {
+			continue
+		}
+		session.Record(sessions.Event{Type: sessions.EventUserInput, Text: goal})
+
+		tracker := llmusage.NewTracker(settings.Agent.TokenBudget)
+		finalAnswer, err := runGoal(ctx, taskAgent, sessions.HistoryPrompt(history, goal, maxHistoryTurns), tracker)
+		if errors.Is(err, errCancelled) || errors.Is(err, errTimedOut) || errors.Is(err, llmusage.ErrBudgetExceeded) {
+			session.Record(sessions.Event{Type: sessions.EventError, Text: err.Error()})
+			fmt.Printf("AI: Stopped the goal (%v).\n", err)
+			printUsage(tracker)
+			continue
+		}
+		if err != nil {
+			session.Record(sessions.Event{Type: sessions.EventError, Text: err.Error()})
+			return fmt.Errorf("agent.Run failed: %w", err)
+		}
+		fmt.Println(finalAnswer)
+		printUsage(tracker)
+		history = append(history, sessions.Turn{UserInput: goal, Answer: finalAnswer})
+	}
+}
+
+// runAsk runs the agent for a single goal. This allows the assistant to be
+// scripted.
+func runAsk(ctx context.Context, args []string) error {
+	goal := strings.TrimSpace(strings.Join(args, " "))
+	if goal == "" {
+		return usageError(`ask "<goal>"`)
+	}
+
+	session, err := sessions.New(sessionsDir())
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+	sessions.ProvideRecorder(session)
+
+	ctx = injection.WithInjection(ctx)
+
+	taskAgent := injection.Resolve[tasks.TaskAgent](ctx).Agent
+
+	session.Record(sessions.Event{Type: sessions.EventUserInput, Text: goal})
+	finalAnswer, err := runGoal(ctx, taskAgent, goal, llmusage.NewTracker(settings.Agent.TokenBudget))
+	if err != nil {
+		session.Record(sessions.Event{Type: sessions.EventError, Text: err.Error()})
+		return fmt.Errorf("agent.Run failed: %w", err)
+	}
+	fmt.Println(finalAnswer)
+	return nil
+}
+
+var (
+	// errCancelled is returned when the user cancels a goal with Ctrl-C.
+	errCancelled = errors.New("cancelled")
+	// errTimedOut is returned when a goal runs past its deadline.
+	errTimedOut = errors.New("timed out")
+)
+
+// runGoal runs the agent with a context that is cancelled by Ctrl-C or the
+// configured timeout. Only the current goal is stopped, not the process. The
+// LLM usage is recorded with the tracker and added to the usage stats.
+func runGoal(ctx context.Context, agent assistanttools.Agent[string], goal string, tracker *llmusage.Tracker) (string, error) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	ctx, cancel := userinput.WithInterrupt(ctx)
+	defer cancel()
+
+	ctx = llmusage.WithTracker(ctx, tracker)
+	defer func() {
+		if err := llmusage.RecordGoal(usageStatsPath(), tracker); err != nil {
+			log.Printf("failed to save the usage: %v", err)
+		}
+	}()
+
+	if settings.Agent.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.Agent.Timeout)
+		defer cancel()
+	}
+
+	finalAnswer, err := agent.Run(ctx, goal)
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("%w after %s", errTimedOut, settings.Agent.Timeout)
+	case ctx.Err() != nil:
+		return "", errCancelled
+	}
+	return finalAnswer, err
+}
+
+// printUsage shows how much of the LLM the goal used.
+func printUsage(tracker *llmusage.Tracker) {
+	entries := tracker.Entries()
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Println()
+	if err := llmusage.WriteSummary(os.Stdout, entries); err != nil {
+		log.Printf("failed to show the usage: %v", err)
+	}
+}
+
+func usageStatsPath() string {
+	return assistantDir() + "/usage.json"
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// runReview shows what was completed, what is stale and the trends. The
+// retrospective is written without the LLM if there isn't one configured or
+// --offline is given.
+func runReview(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("review", flag.ContinueOnError)
+	days := fs.Int("days", tasks.DefaultReviewDays, "How many days to review")
+	stale := fs.Int("stale", tasks.DefaultStaleDays, "How many days without a note before an open task is stale")
+	offline := fs.Bool("offline", false, "Write the retrospective without the LLM")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *days <= 0 || *stale <= 0 {
+		return usageError(commands()["review"].usage)
+	}
+
+	ctx = injection.WithInjection(ctx)
+	if *offline || settings.LLM.ProjectID == "" {
+		now := time.Now()
+		r := tasks.ReviewFor(injection.Resolve[tasks.Store](ctx), now.AddDate(0, 0, -*days), now, time.Duration(*stale)*24*time.Hour)
+		r.Retrospective = tasks.FallbackRetrospective(r)
+		return injection.Resolve[display.Sink](ctx).Show(ctx, tasks.ReviewOutput(r))
+	}
+
+	_, err := tasks.Review(ctx).Run(ctx, fmt.Sprintf("%d %d", *days, *stale))
+	return err
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/poy/assistant/pkg/auth"
+	"github.com/poy/assistant/pkg/grpcserver"
+	"github.com/poy/assistant/pkg/server"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	"google.golang.org/grpc"
+)
+
+const serveUsage = "serve [--addr <host:port>] [--grpc-addr <host:port>] [--multi-user]"
+
+// runServe exposes the tasks and agents over a local HTTP API (and optionally
+// gRPC) until it is interrupted. The clients' bearer tokens are read from
+// ASSISTANT_API_TOKENS (user:token pairs separated by commas). They are
+// required with --multi-user.
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "The address to listen on")
+	grpcAddr := fs.String("grpc-addr", "", "The address to serve gRPC on (disabled if empty)")
+	multiUser := fs.Bool("multi-user", false, "Give each user (named by their token) their own tasks")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return usageError(serveUsage)
+	}
+
+	tokens, err := auth.ParseTokens(os.Getenv("ASSISTANT_API_TOKENS"))
+	if err != nil {
+		return fmt.Errorf("failed to parse ASSISTANT_API_TOKENS: %w", err)
+	}
+	if *multiUser && len(tokens) == 0 {
+		return errors.New("--multi-user requires the users' tokens in ASSISTANT_API_TOKENS")
+	}
+
+	serverOpts := []server.Option{server.WithTimeout(settings.Agent.Timeout)}
+	grpcOpts := []grpcserver.Option{grpcserver.WithTimeout(settings.Agent.Timeout)}
+	if len(tokens) > 0 {
+		serverOpts = append(serverOpts, server.WithTokens(tokens))
+		grpcOpts = append(grpcOpts, grpcserver.WithTokens(tokens))
+	}
+	if *multiUser {
+		tenants := newTenants()
+		serverOpts = append(serverOpts, server.WithTenants(tenants))
+		grpcOpts = append(grpcOpts, grpcserver.WithTenants(tenants))
+	}
+
+	ctx = injection.WithInjection(ctx)
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: server.New(ctx, serverOpts...),
+	}
+
+	var g *grpc.Server
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", *grpcAddr, err)
+		}
+		g = grpc.NewServer()
+		grpcserver.New(ctx, grpcOpts...).Register(g)
+		go func() {
+			log.Printf("serving gRPC on %s", *grpcAddr)
+			if err := g.Serve(lis); err != nil {
+				log.Printf("failed to serve gRPC: %v", err)
+			}
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		if g != nil {
+			g.GracefulStop()
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening on http://%s", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
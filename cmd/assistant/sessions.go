@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/poy/assistant/pkg/sessions"
+)
+
+func runSessions(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("sessions list|show <id>|resume <id>")
+	}
+
+	switch args[0] {
+	case "list":
+		return listSessions()
+	case "show":
+		if len(args) != 2 {
+			return usageError("sessions show <id>")
+		}
+		return showSession(args[1])
+	case "resume":
+		if len(args) != 2 {
+			return usageError("sessions resume <id>")
+		}
+		return resumeSession(ctx, args[1])
+	default:
+		return usageError("sessions list|show <id>|resume <id>")
+	}
+}
+
+func listSessions() error {
+	infos, err := sessions.List(sessionsDir())
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(infos) == 0 {
+		fmt.Println("You don't have any sessions yet...")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTARTED\tEVENTS\tFIRST GOAL")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", info.ID, info.Started.Format("2006-01-02 15:04"), info.Events, info.FirstGoal)
+	}
+	return w.Flush()
+}
+
+func showSession(id string) error {
+	events, err := sessions.Load(sessionsDir(), id)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	for _, e := range events {
+		ts := e.Time.Format("15:04:05")
+		switch e.Type {
+		case sessions.EventUserInput:
+			fmt.Printf("[%s] You: %s\n", ts, e.Text)
+		case sessions.EventThought:
+			fmt.Printf("[%s] %s thought: %s\n", ts, e.Agent, e.Text)
+		case sessions.EventToolCall:
+			fmt.Printf("[%s] %s used %s: %s\n", ts, e.Agent, e.Action, e.Input)
+		case sessions.EventObservation:
+			fmt.Printf("[%s] %s observed from %s: %s\n", ts, e.Agent, e.Action, e.Text)
+		case sessions.EventFinalAnswer:
+			fmt.Printf("[%s] %s answered: %s\n", ts, e.Agent, e.Text)
+		case sessions.EventError:
+			fmt.Printf("[%s] %s error: %s\n", ts, e.Agent, e.Text)
+		}
+	}
+	return nil
+}
+
+func resumeSession(ctx context.Context, id string) error {
+	events, err := sessions.Load(sessionsDir(), id)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	history := sessions.Conversation(events)
+	for _, t := range history {
+		fmt.Printf("You: %s\n", t.UserInput)
+		fmt.Printf("AI: %s\n", t.Answer)
+	}
+
+	session, err := sessions.Open(sessionsDir(), id)
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	return runREPL(ctx, session, history)
+}
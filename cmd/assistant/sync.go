@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/poy/assistant/pkg/caldav"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+const syncUsage = "sync --url <calendar-url> [--state <file>]"
+
+// runSync does a two-way sync of the tasks with a CalDAV calendar. The
+// credentials are read from ASSISTANT_CALDAV_USER and
+// ASSISTANT_CALDAV_PASSWORD.
+func runSync(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	u := fs.String("url", "", "The CalDAV calendar collection to sync with")
+	state := fs.String("state", assistantDir()+"/caldav-state.json", "Where to keep what was synced last time")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *u == "" {
+		return usageError(syncUsage)
+	}
+
+	client := &caldav.Client{
+		URL:      *u,
+		Username: os.Getenv("ASSISTANT_CALDAV_USER"),
+		Password: os.Getenv("ASSISTANT_CALDAV_PASSWORD"),
+	}
+	s := injection.Resolve[tasks.Store](injection.WithInjection(ctx))
+
+	result, err := caldav.Sync(ctx, client, s, *state)
+	for _, l := range []struct {
+		label string
+		names []string
+	}{
+		{"pushed", result.Pushed},
+		{"pulled", result.Pulled},
+		{"removed locally", result.DeletedLocal},
+		{"removed from the calendar", result.DeletedRemote},
+		{"changed in both places (notes merged)", result.Conflicts},
+	} {
+		if len(l.names) > 0 {
+			fmt.Printf("%s: %s\n", l.label, strings.Join(l.names, ", "))
+		}
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/taskfile"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// runTasks works with the store directly. None of these commands use the LLM,
+// so they are deterministic and cheap to script.
+func runTasks(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError(commands()["tasks"].usage)
+	}
+
+	ctx = injection.WithInjection(ctx)
+	s := injection.Resolve[tasks.Store](ctx)
+	sink := injection.Resolve[display.Sink](ctx)
+
+	switch args[0] {
+	case "list":
+		return sink.Show(ctx, tasks.ListOutput(s.TaskNames()))
+	case "show":
+		if len(args) < 2 {
+			return usageError("tasks show <name>")
+		}
+		return showTask(ctx, s, sink, strings.Join(args[1:], " "))
+	case "add":
+		return addTask(s, args[1:])
+	case "export":
+		return exportTasks(s, args[1:])
+	case "import":
+		return importTasks(s, args[1:])
+	default:
+		return usageError(commands()["tasks"].usage)
+	}
+}
+
+func showTask(ctx context.Context, s tasks.Store, sink display.Sink, name string) error {
+	t := s.GetTask(name)
+	if t == nil {
+		return fmt.Errorf("task %q not found", name)
+	}
+	return sink.Show(ctx, tasks.DetailsOutput(t))
+}
+
+func addTask(s tasks.Store, args []string) error {
+	fs := flag.NewFlagSet("tasks add", flag.ContinueOnError)
+	title := fs.String("title", "", "The title of the task")
+	description := fs.String("description", "", "The description of the task")
+	if err := fs.Parse(args); err != nil {
+		return usageError("tasks add --title <title> [--description <description>]")
+	}
+	if *title == "" || fs.NArg() != 0 {
+		return usageError("tasks add --title <title> [--description <description>]")
+	}
+
+	if s.GetTask(*title) != nil {
+		return fmt.Errorf("task %q already exists", *title)
+	}
+	s.Add(*title, *description)
+	fmt.Printf("Added task %q\n", *title)
+	return nil
+}
+
+const (
+	exportUsage = "tasks export [--format todotxt|ics|md|csv] [--output <file>]"
+	importUsage = "tasks import [--format todotxt|ics|md|csv] [--dry-run] <file>"
+)
+
+// exportTasks writes the tasks to the file, or stdout. The format defaults to
+// the file's extension.
+func exportTasks(s tasks.Store, args []string) error {
+	fs := flag.NewFlagSet("tasks export", flag.ContinueOnError)
+	format := fs.String("format", "", "The format to write (defaults to the output's extension)")
+	output := fs.String("output", "", "The file to write to (defaults to stdout)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return usageError(exportUsage)
+	}
+	if *format == "" && *output == "" {
+		return usageError(exportUsage)
+	}
+
+	f, err := fileFormat(*format, *output)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *output != "" {
+		if w, err = os.Create(*output); err != nil {
+			return fmt.Errorf("failed to export tasks: %w", err)
+		}
+	}
+	if err := taskfile.Encode(w, f, taskfile.Items(s)); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to export tasks: %w", err)
+	}
+	if w == os.Stdout {
+		return nil
+	}
+	return w.Close()
+}
+
+// importTasks adds the tasks in the file. Tasks that already exist are
+// skipped.
+func importTasks(s tasks.Store, args []string) error {
+	fs := flag.NewFlagSet("tasks import", flag.ContinueOnError)
+	format := fs.String("format", "", "The format to read (defaults to the file's extension)")
+	dryRun := fs.Bool("dry-run", false, "Show what would be imported without changing the tasks")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return usageError(importUsage)
+	}
+
+	f, err := fileFormat(*format, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	r, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to import tasks: %w", err)
+	}
+	defer r.Close()
+	items, err := taskfile.Decode(r, f)
+	if err != nil {
+		return fmt.Errorf("failed to import tasks from %s: %w", fs.Arg(0), err)
+	}
+
+	result, err := taskfile.Import(s, items, *dryRun)
+	verb := "Imported"
+	if *dryRun {
+		verb = "Would import"
+	}
+	for _, name := range result.Added {
+		fmt.Printf("%s %q\n", verb, name)
+	}
+	for _, name := range result.Skipped {
+		fmt.Printf("Skipped %q, it already exists\n", name)
+	}
+	return err
+}
+
+func fileFormat(format, path string) (taskfile.Format, error) {
+	if format != "" {
+		return taskfile.ParseFormat(format)
+	}
+	return taskfile.FormatFor(path)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// runToday shows the daily briefing. The plan is made without the LLM if
+// there isn't one configured or --offline is given.
+func runToday(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("today", flag.ContinueOnError)
+	offline := fs.Bool("offline", false, "Plan the day without the LLM")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return usageError(commands()["today"].usage)
+	}
+
+	ctx = injection.WithInjection(ctx)
+	if *offline || settings.LLM.ProjectID == "" {
+		a := tasks.AgendaFor(injection.Resolve[tasks.Store](ctx), time.Now())
+		a.Plan = tasks.FallbackPlan(a)
+		return injection.Resolve[display.Sink](ctx).Show(ctx, tasks.BriefingOutput(a))
+	}
+
+	_, err := tasks.Briefing(ctx).Run(ctx, "")
+	return err
+}
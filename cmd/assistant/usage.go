@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	llmusage "github.com/poy/assistant/pkg/usage"
+)
+
+// runUsage shows how much of the LLM the goals have used so far.
+func runUsage(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usageError("usage")
+	}
+
+	s, err := llmusage.LoadStats(usageStatsPath())
+	if err != nil {
+		return err
+	}
+	if s.Goals == 0 {
+		fmt.Println("No goals have been run yet.")
+		return nil
+	}
+	fmt.Printf("%d goals since %s\n\n", s.Goals, s.Since.Format("02 Jan 2006"))
+	return llmusage.WriteSummary(os.Stdout, s.Entries)
+}
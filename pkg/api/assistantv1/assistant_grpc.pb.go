@@ -0,0 +1,436 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: assistant/v1/assistant.proto
+
+package assistantv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Tasks_AddTask_FullMethodName       = "/assistant.v1.Tasks/AddTask"
+	Tasks_RemoveTask_FullMethodName    = "/assistant.v1.Tasks/RemoveTask"
+	Tasks_ListTaskNames_FullMethodName = "/assistant.v1.Tasks/ListTaskNames"
+	Tasks_GetTask_FullMethodName       = "/assistant.v1.Tasks/GetTask"
+	Tasks_AddNotes_FullMethodName      = "/assistant.v1.Tasks/AddNotes"
+	Tasks_CompleteTask_FullMethodName  = "/assistant.v1.Tasks/CompleteTask"
+)
+
+// TasksClient is the client API for Tasks service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TasksClient interface {
+	// AddTask adds a new task.
+	AddTask(ctx context.Context, in *AddTaskRequest, opts ...grpc.CallOption) (*Task, error)
+	// RemoveTask removes a task.
+	RemoveTask(ctx context.Context, in *RemoveTaskRequest, opts ...grpc.CallOption) (*RemoveTaskResponse, error)
+	// ListTaskNames returns the names of the tasks.
+	ListTaskNames(ctx context.Context, in *ListTaskNamesRequest, opts ...grpc.CallOption) (*ListTaskNamesResponse, error)
+	// GetTask returns a task by its name.
+	GetTask(ctx context.Context, in *GetTaskRequest, opts ...grpc.CallOption) (*Task, error)
+	// AddNotes adds notes to a task.
+	AddNotes(ctx context.Context, in *AddNotesRequest, opts ...grpc.CallOption) (*Task, error)
+	// CompleteTask marks a task as completed.
+	CompleteTask(ctx context.Context, in *CompleteTaskRequest, opts ...grpc.CallOption) (*Task, error)
+}
+
+type tasksClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTasksClient(cc grpc.ClientConnInterface) TasksClient {
+	return &tasksClient{cc}
+}
+
+func (c *tasksClient) AddTask(ctx context.Context, in *AddTaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, Tasks_AddTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tasksClient) RemoveTask(ctx context.Context, in *RemoveTaskRequest, opts ...grpc.CallOption) (*RemoveTaskResponse, error) {
+	out := new(RemoveTaskResponse)
+	err := c.cc.Invoke(ctx, Tasks_RemoveTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tasksClient) ListTaskNames(ctx context.Context, in *ListTaskNamesRequest, opts ...grpc.CallOption) (*ListTaskNamesResponse, error) {
+	out := new(ListTaskNamesResponse)
+	err := c.cc.Invoke(ctx, Tasks_ListTaskNames_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tasksClient) GetTask(ctx context.Context, in *GetTaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, Tasks_GetTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tasksClient) AddNotes(ctx context.Context, in *AddNotesRequest, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, Tasks_AddNotes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tasksClient) CompleteTask(ctx context.Context, in *CompleteTaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, Tasks_CompleteTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TasksServer is the server API for Tasks service.
+// All implementations must embed UnimplementedTasksServer
+// for forward compatibility
+type TasksServer interface {
+	// AddTask adds a new task.
+	AddTask(context.Context, *AddTaskRequest) (*Task, error)
+	// RemoveTask removes a task.
+	RemoveTask(context.Context, *RemoveTaskRequest) (*RemoveTaskResponse, error)
+	// ListTaskNames returns the names of the tasks.
+	ListTaskNames(context.Context, *ListTaskNamesRequest) (*ListTaskNamesResponse, error)
+	// GetTask returns a task by its name.
+	GetTask(context.Context, *GetTaskRequest) (*Task, error)
+	// AddNotes adds notes to a task.
+	AddNotes(context.Context, *AddNotesRequest) (*Task, error)
+	// CompleteTask marks a task as completed.
+	CompleteTask(context.Context, *CompleteTaskRequest) (*Task, error)
+	mustEmbedUnimplementedTasksServer()
+}
+
+// UnimplementedTasksServer must be embedded to have forward compatible implementations.
+type UnimplementedTasksServer struct {
+}
+
+func (UnimplementedTasksServer) AddTask(context.Context, *AddTaskRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTask not implemented")
+}
+func (UnimplementedTasksServer) RemoveTask(context.Context, *RemoveTaskRequest) (*RemoveTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveTask not implemented")
+}
+func (UnimplementedTasksServer) ListTaskNames(context.Context, *ListTaskNamesRequest) (*ListTaskNamesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTaskNames not implemented")
+}
+func (UnimplementedTasksServer) GetTask(context.Context, *GetTaskRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTask not implemented")
+}
+func (UnimplementedTasksServer) AddNotes(context.Context, *AddNotesRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddNotes not implemented")
+}
+func (UnimplementedTasksServer) CompleteTask(context.Context, *CompleteTaskRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteTask not implemented")
+}
+func (UnimplementedTasksServer) mustEmbedUnimplementedTasksServer() {}
+
+// UnsafeTasksServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TasksServer will
+// result in compilation errors.
+type UnsafeTasksServer interface {
+	mustEmbedUnimplementedTasksServer()
+}
+
+func RegisterTasksServer(s grpc.ServiceRegistrar, srv TasksServer) {
+	s.RegisterService(&Tasks_ServiceDesc, srv)
+}
+
+func _Tasks_AddTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TasksServer).AddTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tasks_AddTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TasksServer).AddTask(ctx, req.(*AddTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tasks_RemoveTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TasksServer).RemoveTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tasks_RemoveTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TasksServer).RemoveTask(ctx, req.(*RemoveTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tasks_ListTaskNames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTaskNamesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TasksServer).ListTaskNames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tasks_ListTaskNames_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TasksServer).ListTaskNames(ctx, req.(*ListTaskNamesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tasks_GetTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TasksServer).GetTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tasks_GetTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TasksServer).GetTask(ctx, req.(*GetTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tasks_AddNotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddNotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TasksServer).AddNotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tasks_AddNotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TasksServer).AddNotes(ctx, req.(*AddNotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tasks_CompleteTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TasksServer).CompleteTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tasks_CompleteTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TasksServer).CompleteTask(ctx, req.(*CompleteTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Tasks_ServiceDesc is the grpc.ServiceDesc for Tasks service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Tasks_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "assistant.v1.Tasks",
+	HandlerType: (*TasksServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddTask",
+			Handler:    _Tasks_AddTask_Handler,
+		},
+		{
+			MethodName: "RemoveTask",
+			Handler:    _Tasks_RemoveTask_Handler,
+		},
+		{
+			MethodName: "ListTaskNames",
+			Handler:    _Tasks_ListTaskNames_Handler,
+		},
+		{
+			MethodName: "GetTask",
+			Handler:    _Tasks_GetTask_Handler,
+		},
+		{
+			MethodName: "AddNotes",
+			Handler:    _Tasks_AddNotes_Handler,
+		},
+		{
+			MethodName: "CompleteTask",
+			Handler:    _Tasks_CompleteTask_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "assistant/v1/assistant.proto",
+}
+
+const (
+	Assistant_Run_FullMethodName = "/assistant.v1.Assistant/Run"
+)
+
+// AssistantClient is the client API for Assistant service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AssistantClient interface {
+	// Run runs a goal. The first request must have the goal. The responses are
+	// the events of the agents, what was shown to the user and any questions
+	// for the user, which are answered with an Answer request. The last
+	// response has the final answer.
+	Run(ctx context.Context, opts ...grpc.CallOption) (Assistant_RunClient, error)
+}
+
+type assistantClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAssistantClient(cc grpc.ClientConnInterface) AssistantClient {
+	return &assistantClient{cc}
+}
+
+func (c *assistantClient) Run(ctx context.Context, opts ...grpc.CallOption) (Assistant_RunClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Assistant_ServiceDesc.Streams[0], Assistant_Run_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &assistantRunClient{stream}
+	return x, nil
+}
+
+type Assistant_RunClient interface {
+	Send(*RunRequest) error
+	Recv() (*RunResponse, error)
+	grpc.ClientStream
+}
+
+type assistantRunClient struct {
+	grpc.ClientStream
+}
+
+func (x *assistantRunClient) Send(m *RunRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *assistantRunClient) Recv() (*RunResponse, error) {
+	m := new(RunResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AssistantServer is the server API for Assistant service.
+// All implementations must embed UnimplementedAssistantServer
+// for forward compatibility
+type AssistantServer interface {
+	// Run runs a goal. The first request must have the goal. The responses are
+	// the events of the agents, what was shown to the user and any questions
+	// for the user, which are answered with an Answer request. The last
+	// response has the final answer.
+	Run(Assistant_RunServer) error
+	mustEmbedUnimplementedAssistantServer()
+}
+
+// UnimplementedAssistantServer must be embedded to have forward compatible implementations.
+type UnimplementedAssistantServer struct {
+}
+
+func (UnimplementedAssistantServer) Run(Assistant_RunServer) error {
+	return status.Errorf(codes.Unimplemented, "method Run not implemented")
+}
+func (UnimplementedAssistantServer) mustEmbedUnimplementedAssistantServer() {}
+
+// UnsafeAssistantServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AssistantServer will
+// result in compilation errors.
+type UnsafeAssistantServer interface {
+	mustEmbedUnimplementedAssistantServer()
+}
+
+func RegisterAssistantServer(s grpc.ServiceRegistrar, srv AssistantServer) {
+	s.RegisterService(&Assistant_ServiceDesc, srv)
+}
+
+func _Assistant_Run_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AssistantServer).Run(&assistantRunServer{stream})
+}
+
+type Assistant_RunServer interface {
+	Send(*RunResponse) error
+	Recv() (*RunRequest, error)
+	grpc.ServerStream
+}
+
+type assistantRunServer struct {
+	grpc.ServerStream
+}
+
+func (x *assistantRunServer) Send(m *RunResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *assistantRunServer) Recv() (*RunRequest, error) {
+	m := new(RunRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Assistant_ServiceDesc is the grpc.ServiceDesc for Assistant service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Assistant_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "assistant.v1.Assistant",
+	HandlerType: (*AssistantServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Run",
+			Handler:       _Assistant_Run_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "assistant/v1/assistant.proto",
+}
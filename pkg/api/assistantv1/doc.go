@@ -0,0 +1,5 @@
+// Package assistantv1 is the generated code for the gRPC API defined in
+// api/assistant/v1/assistant.proto.
+package assistantv1
+
+//go:generate sh -c "cd ../../.. && buf generate api"
@@ -0,0 +1,62 @@
+// Package auth authenticates the clients of the HTTP and gRPC APIs with
+// bearer tokens. Each token is given to a single user, so a client can only
+// act as the user its token belongs to.
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnauthenticated is returned when a request doesn't have a known bearer
+// token.
+var ErrUnauthenticated = errors.New("a valid bearer token is required")
+
+// Tokens maps each bearer token to the user it was given to.
+type Tokens map[string]string
+
+// ParseTokens parses a comma separated list of user:token pairs (e.g.,
+// "alice:s3cret,bob:hunter2").
+func ParseTokens(s string) (Tokens, error) {
+	tokens := Tokens{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		user, token, ok := strings.Cut(pair, ":")
+		if !ok || user == "" || token == "" {
+			// The pair isn't in the error, as it might be a secret.
+			return nil, errors.New("each token must be user:token")
+		}
+		if _, ok := tokens[token]; ok {
+			return nil, fmt.Errorf("the token for %s is used more than once", user)
+		}
+		tokens[token] = user
+	}
+	return tokens, nil
+}
+
+// User returns the user for the value of an Authorization header
+// ("Bearer <token>").
+func (t Tokens) User(authorization string) (string, error) {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", ErrUnauthenticated
+	}
+
+	// Compare against every token so the time doesn't say how much of the
+	// token matched.
+	user := ""
+	for known, u := range t {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			user = u
+		}
+	}
+	if user == "" {
+		return "", ErrUnauthenticated
+	}
+	return user, nil
+}
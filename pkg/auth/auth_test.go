@@ -0,0 +1,73 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/poy/assistant/pkg/auth"
+)
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	tokens, err := auth.ParseTokens("alice:alice-token, bob:bob-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		authorization string
+		expected      string
+		err           error
+	}{
+		{name: "known token", authorization: "Bearer bob-token", expected: "bob"},
+		{name: "scheme is case insensitive", authorization: "bearer alice-token", expected: "alice"},
+		{name: "unknown token", authorization: "Bearer mallory-token", err: auth.ErrUnauthenticated},
+		{name: "another scheme", authorization: "Basic alice-token", err: auth.ErrUnauthenticated},
+		{name: "missing", err: auth.ErrUnauthenticated},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := tokens.User(tc.authorization)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		input string
+		err   bool
+	}{
+		{name: "empty", input: ""},
+		{name: "pairs", input: "alice:a,bob:b"},
+		{name: "missing token", input: "alice", err: true},
+		{name: "missing user", input: ":a", err: true},
+		{name: "shared token", input: "alice:a,bob:a", err: true},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := auth.ParseTokens(tc.input)
+			if actual, expected := err != nil, tc.err; actual != expected {
+				t.Fatalf("expected an error to be %v, got %v", expected, err)
+			}
+		})
+	}
+}
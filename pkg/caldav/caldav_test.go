@@ -0,0 +1,515 @@
+package caldav_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poy/assistant/pkg/caldav"
+	"github.com/poy/assistant/pkg/ical"
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+// fakeCalDAV is an in-process CalDAV calendar collection at /calendar/.
+type fakeCalDAV struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string]*object
+	etags   int
+}
+
+type object struct {
+	data []byte
+	etag string
+}
+
+func newFakeCalDAV(t *testing.T) (*fakeCalDAV, *caldav.Client) {
+	f := &fakeCalDAV{t: t, objects: map[string]*object{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, &caldav.Client{
+		URL:      srv.URL + "/calendar/",
+		Username: "some-user",
+		Password: "some-password",
+	}
+}
+
+func (f *fakeCalDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if u, p, _ := r.BasicAuth(); u != "some-user" || p != "some-password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	o := f.objects[r.URL.Path]
+	switch r.Method {
+	case "PROPFIND":
+		if r.URL.Path != "/calendar/" || r.Header.Get("Depth") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:">`)
+		fmt.Fprint(w, `<d:response><d:href>/calendar/</d:href><d:propstat><d:prop/><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+		for href, o := range f.objects {
+			fmt.Fprintf(w, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>%s</d:getetag></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, href, o.etag)
+		}
+		fmt.Fprint(w, `</d:multistatus>`)
+	case http.MethodGet:
+		if o == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", o.etag)
+		w.Write(o.data)
+	case http.MethodPut:
+		if r.Header.Get("If-None-Match") == "*" && o != nil ||
+			r.Header.Get("If-Match") != "" && (o == nil || r.Header.Get("If-Match") != o.etag) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		var buf bytes.Buffer
+		buf.ReadFrom(r.Body)
+		if _, err := ical.Decode(bytes.NewReader(buf.Bytes())); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("ETag", f.set(r.URL.Path, buf.Bytes()))
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if o == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("If-Match") != o.etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// set saves the object and returns its new ETag. The lock must be held.
+func (f *fakeCalDAV) set(href string, data []byte) string {
+	f.etags++
+	etag := fmt.Sprintf(`"%d"`, f.etags)
+	f.objects[href] = &object{data: data, etag: etag}
+	return etag
+}
+
+// Put changes the to-do as if someone else did.
+func (f *fakeCalDAV) Put(href string, todo ical.Todo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, todo); err != nil {
+		f.t.Fatal(err)
+	}
+	f.set(href, buf.Bytes())
+}
+
+// Edit changes the to-do as if someone else did.
+func (f *fakeCalDAV) Edit(href string, edit func(todo *ical.Todo)) {
+	todo := f.Todo(href)
+	edit(&todo)
+	f.Put(href, todo)
+}
+
+// Delete removes the to-do as if someone else did.
+func (f *fakeCalDAV) Delete(href string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, href)
+}
+
+func (f *fakeCalDAV) Todo(href string) ical.Todo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := f.objects[href]
+	if o == nil {
+		f.t.Fatalf("%s isn't in the calendar", href)
+	}
+	todos, err := ical.Decode(bytes.NewReader(o.data))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return todos[0]
+}
+
+func (f *fakeCalDAV) Hrefs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var hrefs []string
+	for href := range f.objects {
+		hrefs = append(hrefs, href)
+	}
+	sort.Strings(hrefs)
+	return hrefs
+}
+
+// Only returns the only object's href.
+func (f *fakeCalDAV) Only() string {
+	hrefs := f.Hrefs()
+	if len(hrefs) != 1 {
+		f.t.Fatalf("expected 1 object, got %v", hrefs)
+	}
+	return hrefs[0]
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	due := time.Date(2023, 6, 1, 17, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name string
+		// run makes the changes and syncs with sync.
+		run func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result)
+	}{
+		{
+			name: "pushes new tasks",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "2%")
+				s.GetTask("buy milk").SetDue(due)
+				s.GetTask("buy milk").AddNotes("the store closes at 9")
+
+				result := sync()
+				if actual, expected := strings.Join(result.Pushed, ","), "buy milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				todo := f.Todo(f.Only())
+				if todo.Summary != "buy milk" || todo.Description != "2%" || !todo.Due.Equal(due) {
+					t.Fatalf("unexpected to-do %+v", todo)
+				}
+				if len(todo.Comments) != 1 || todo.Comments[0].Text != "the store closes at 9" {
+					t.Fatalf("expected the note as a comment, got %+v", todo.Comments)
+				}
+			},
+		},
+		{
+			name: "pulls new to-dos",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				f.Put("/calendar/some-uid.ics", ical.Todo{
+					UID:         "some-uid",
+					Summary:     "call the dentist",
+					Description: "about the cleaning",
+					Due:         due,
+					Completed:   due,
+				})
+
+				result := sync()
+				if actual, expected := strings.Join(result.Pulled, ","), "call the dentist"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				task := s.GetTask("call the dentist")
+				if task == nil {
+					t.Fatal("expected the task")
+				}
+				if task.Description() != "about the cleaning" || !task.Due().Equal(due) || !task.Completed() {
+					t.Fatalf("unexpected task %+v", task.Data())
+				}
+			},
+		},
+		{
+			name: "doesn't change anything if nothing changed",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				f.Put("/calendar/some-uid.ics", ical.Todo{UID: "some-uid", Summary: "call the dentist"})
+				sync()
+
+				result := sync()
+				if fmt.Sprint(result) != fmt.Sprint(caldav.Result{}) {
+					t.Fatalf("expected nothing to be synced, got %+v", result)
+				}
+				if actual, expected := len(f.Hrefs()), 2; actual != expected {
+					t.Fatalf("expected %d objects, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "pulls edits",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				s.GetTask("buy milk").Assign("", "alex")
+				sync()
+
+				f.Edit(f.Only(), func(todo *ical.Todo) {
+					todo.Description = "oat milk"
+				})
+				sync()
+
+				task := s.GetTask("buy milk")
+				if actual, expected := task.Description(), "oat milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := task.Assignee(), "alex"; actual != expected {
+					t.Fatalf("expected the assignee to be kept, got %q", actual)
+				}
+			},
+		},
+		{
+			name: "pulls renames",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				s.GetTask("buy milk").SetReminder(time.Hour)
+				created := s.GetTask("buy milk").Data().Created
+				sync()
+
+				f.Edit(f.Only(), func(todo *ical.Todo) {
+					todo.Summary = "buy oat milk"
+				})
+				sync()
+
+				if actual, expected := strings.Join(s.TaskNames(), ","), "buy oat milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				task := s.GetTask("buy oat milk")
+				if actual := task.Data().Created; !actual.Equal(created) {
+					t.Fatalf("expected the creation time %v, got %v", created, actual)
+				}
+				if _, ok := task.Reminder(); !ok {
+					t.Fatal("expected the reminder to be kept")
+				}
+			},
+		},
+		{
+			name: "pushes edits",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				sync()
+
+				s.GetTask("buy milk").AddNotes("get two")
+				result := sync()
+				if actual, expected := strings.Join(result.Pushed, ","), "buy milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual := f.Todo(f.Only()).Comments; len(actual) != 1 || actual[0].Text != "get two" {
+					t.Fatalf("expected the note, got %+v", actual)
+				}
+			},
+		},
+		{
+			name: "merges conflicting edits",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				sync()
+
+				s.GetTask("buy milk").AddNotes("from the assistant")
+				f.Edit(f.Only(), func(todo *ical.Todo) {
+					todo.Description = "from the phone"
+					todo.Comments = append(todo.Comments, ical.Comment{Time: time.Now(), Text: "from the phone"})
+				})
+				result := sync()
+				if actual, expected := strings.Join(result.Conflicts, ","), "buy milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+
+				task := s.GetTask("buy milk")
+				if actual, expected := task.Description(), "from the phone"; actual != expected {
+					t.Fatalf("expected the calendar's description %q, got %q", expected, actual)
+				}
+				if actual, expected := len(task.Data().Notes), 2; actual != expected {
+					t.Fatalf("expected %d notes, got %d", expected, actual)
+				}
+				if actual, expected := len(f.Todo(f.Only()).Comments), 2; actual != expected {
+					t.Fatalf("expected %d comments, got %d", expected, actual)
+				}
+
+				// Both sides agree now.
+				if result := sync(); len(result.Pushed)+len(result.Pulled)+len(result.Conflicts) != 0 {
+					t.Fatalf("expected nothing to be synced, got %+v", result)
+				}
+			},
+		},
+		{
+			name: "removes to-dos that were removed locally",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				sync()
+
+				s.Remove("buy milk")
+				result := sync()
+				if actual, expected := strings.Join(result.DeletedRemote, ","), "buy milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual := f.Hrefs(); len(actual) != 0 {
+					t.Fatalf("expected the to-do to be removed, got %v", actual)
+				}
+			},
+		},
+		{
+			name: "removes tasks that were removed from the calendar",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				sync()
+
+				f.Delete(f.Only())
+				result := sync()
+				if actual, expected := strings.Join(result.DeletedLocal, ","), "buy milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if s.GetTask("buy milk") != nil {
+					t.Fatal("expected the task to be removed")
+				}
+			},
+		},
+		{
+			name: "keeps a task that was changed and removed from the calendar",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				sync()
+
+				f.Delete(f.Only())
+				s.GetTask("buy milk").AddNotes("still needed")
+				sync()
+
+				if s.GetTask("buy milk") == nil {
+					t.Fatal("expected the task to be kept")
+				}
+				if actual, expected := f.Todo(f.Only()).Summary, "buy milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "links a task that was added in both places",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "")
+				f.Put("/calendar/some-uid.ics", ical.Todo{UID: "some-uid", Summary: "buy milk"})
+				sync()
+
+				if actual, expected := strings.Join(f.Hrefs(), ","), "/calendar/some-uid.ics"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := len(s.TaskNames()), 1; actual != expected {
+					t.Fatalf("expected %d task, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "adds a to-do with the name of a synced task under another name",
+			run: func(t *testing.T, s tasks.Store, f *fakeCalDAV, sync func() caldav.Result) {
+				s.Add("buy milk", "from the assistant")
+				sync()
+				synced := f.Only()
+
+				f.Put("/calendar/some-uid.ics", ical.Todo{UID: "some-uid", Summary: "buy milk", Description: "from the phone"})
+				sync()
+
+				if actual, expected := s.GetTask("buy milk").Description(), "from the assistant"; actual != expected {
+					t.Fatalf("expected the synced task to be kept, got %q", actual)
+				}
+				task := s.GetTask("buy milk (2)")
+				if task == nil {
+					t.Fatalf("expected the to-do under another name, got %v", s.TaskNames())
+				}
+				if actual, expected := task.Description(), "from the phone"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := f.Todo("/calendar/some-uid.ics").Summary, "buy milk (2)"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := f.Todo(synced).Summary, "buy milk"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+
+				// Both sides agree now.
+				if result := sync(); fmt.Sprint(result) != fmt.Sprint(caldav.Result{}) {
+					t.Fatalf("expected nothing to be synced, got %+v", result)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f, client := newFakeCalDAV(t)
+			s := tasks.NewStore("")
+			statePath := filepath.Join(t.TempDir(), "state.json")
+
+			tc.run(t, s, f, func() caldav.Result {
+				result, err := caldav.Sync(context.Background(), client, s, statePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result
+			})
+		})
+	}
+}
+
+func TestSyncRenameConflict(t *testing.T) {
+	t.Parallel()
+	f, client := newFakeCalDAV(t)
+	s := tasks.NewStore("")
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	ctx := context.Background()
+
+	s.Add("buy milk", "")
+	if _, err := caldav.Sync(ctx, client, s, statePath); err != nil {
+		t.Fatal(err)
+	}
+	href := f.Only()
+
+	// An unrelated task that isn't synced (yet) has the new name.
+	s.Add("buy oat milk", "from the assistant")
+	f.Edit(href, func(todo *ical.Todo) {
+		todo.Summary = "buy oat milk"
+	})
+	if _, err := caldav.Sync(ctx, client, s, statePath); !errors.Is(err, tasks.ErrExists) {
+		t.Fatalf("expected %v, got %v", tasks.ErrExists, err)
+	}
+
+	if s.GetTask("buy milk") == nil {
+		t.Fatal("expected the task to keep its name")
+	}
+	if actual, expected := s.GetTask("buy oat milk").Description(), "from the assistant"; actual != expected {
+		t.Fatalf("expected the unrelated task to be kept, got %q", actual)
+	}
+}
+
+func TestClientConflicts(t *testing.T) {
+	t.Parallel()
+	f, client := newFakeCalDAV(t)
+	ctx := context.Background()
+
+	href := client.Href("some-uid.ics")
+	etag, err := client.Put(ctx, href, ical.Todo{UID: "some-uid", Summary: "buy milk"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// It was already created.
+	if _, err := client.Put(ctx, href, ical.Todo{UID: "some-uid", Summary: "buy milk"}, ""); !errors.Is(err, caldav.ErrPreconditionFailed) {
+		t.Fatalf("expected %v, got %v", caldav.ErrPreconditionFailed, err)
+	}
+
+	// Someone else changed it.
+	f.Edit(href, func(todo *ical.Todo) { todo.Description = "oat" })
+	if _, err := client.Put(ctx, href, ical.Todo{UID: "some-uid", Summary: "buy milk"}, etag); !errors.Is(err, caldav.ErrPreconditionFailed) {
+		t.Fatalf("expected %v, got %v", caldav.ErrPreconditionFailed, err)
+	}
+	if err := client.Delete(ctx, href, etag); !errors.Is(err, caldav.ErrPreconditionFailed) {
+		t.Fatalf("expected %v, got %v", caldav.ErrPreconditionFailed, err)
+	}
+	if actual, expected := f.Todo(href).Description, "oat"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
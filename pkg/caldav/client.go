@@ -0,0 +1,216 @@
+// Package caldav syncs the tasks with a CalDAV calendar (e.g., so they show
+// up in calendar and phone to-do apps). Each task is a VTODO object in the
+// calendar collection.
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/poy/assistant/pkg/ical"
+)
+
+// ErrPreconditionFailed is returned when the object was changed (or created)
+// on the server since its ETag was read.
+var ErrPreconditionFailed = errors.New("the object was changed on the server")
+
+// ErrNotFound is returned when the object isn't on the server.
+var ErrNotFound = errors.New("the object isn't on the server")
+
+// Client talks to a CalDAV calendar collection.
+type Client struct {
+	// URL is the calendar collection.
+	URL      string
+	Username string
+	Password string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// List returns the ETag of each object in the collection by its href.
+func (c *Client) List(ctx context.Context) (map[string]string, error) {
+	const body = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:getetag/></d:prop></d:propfind>`
+
+	resp, err := c.do(ctx, "PROPFIND", c.URL, strings.NewReader(body), map[string]string{
+		"Depth":        "1",
+		"Content-Type": "application/xml; charset=utf-8",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("failed to list the calendar: unexpected status %s", resp.Status)
+	}
+
+	var ms struct {
+		Responses []struct {
+			Href     string `xml:"href"`
+			Propstat []struct {
+				Status string `xml:"status"`
+				ETag   string `xml:"prop>getetag"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to decode the calendar listing: %w", err)
+	}
+
+	result := map[string]string{}
+	for _, r := range ms.Responses {
+		// The collection itself is in the listing too.
+		if !strings.HasSuffix(r.Href, ".ics") {
+			continue
+		}
+		for _, ps := range r.Propstat {
+			if ps.ETag != "" && strings.Contains(ps.Status, " 200 ") {
+				result[c.href(r.Href)] = ps.ETag
+			}
+		}
+	}
+	return result, nil
+}
+
+// Get returns the to-do in the object and its ETag.
+func (c *Client) Get(ctx context.Context, href string) (ical.Todo, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.resolve(href), nil, nil)
+	if err != nil {
+		return ical.Todo{}, "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ical.Todo{}, "", fmt.Errorf("%w: %s", ErrNotFound, href)
+	case resp.StatusCode != http.StatusOK:
+		return ical.Todo{}, "", fmt.Errorf("failed to get %s: unexpected status %s", href, resp.Status)
+	}
+
+	todos, err := ical.Decode(resp.Body)
+	if err != nil {
+		return ical.Todo{}, "", fmt.Errorf("failed to decode %s: %w", href, err)
+	}
+	if len(todos) == 0 {
+		return ical.Todo{}, "", fmt.Errorf("%s doesn't have a VTODO", href)
+	}
+	return todos[0], resp.Header.Get("ETag"), nil
+}
+
+// Put saves the to-do to the object and returns its new ETag. The ETag is
+// what the object is expected to be on the server. If it is empty, the object
+// must not exist yet.
+func (c *Client) Put(ctx context.Context, href string, todo ical.Todo, etag string) (string, error) {
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, todo); err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", href, err)
+	}
+
+	headers := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	if etag == "" {
+		headers["If-None-Match"] = "*"
+	} else {
+		headers["If-Match"] = etag
+	}
+	resp, err := c.do(ctx, http.MethodPut, c.resolve(href), &buf, headers)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return "", fmt.Errorf("%w: %s", ErrPreconditionFailed, href)
+	case resp.StatusCode/100 != 2:
+		return "", fmt.Errorf("failed to save %s: unexpected status %s", href, resp.Status)
+	}
+
+	if newETag := resp.Header.Get("ETag"); newETag != "" {
+		return newETag, nil
+	}
+	// Some servers don't return the ETag when they change the object.
+	_, newETag, err := c.Get(ctx, href)
+	return newETag, err
+}
+
+// Delete removes the object if it still has the ETag.
+func (c *Client) Delete(ctx context.Context, href, etag string) error {
+	resp, err := c.do(ctx, http.MethodDelete, c.resolve(href), nil, map[string]string{"If-Match": etag})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: %s", ErrPreconditionFailed, href)
+	case resp.StatusCode == http.StatusNotFound:
+		return nil
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("failed to delete %s: unexpected status %s", href, resp.Status)
+	}
+	return nil
+}
+
+// Href returns the href for a new object in the collection.
+func (c *Client) Href(name string) string {
+	return c.href(strings.TrimSuffix(c.collectionPath(), "/") + "/" + url.PathEscape(name))
+}
+
+func (c *Client) collectionPath() string {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return c.URL
+	}
+	return u.Path
+}
+
+// href normalizes the href to its path so the same object always has the
+// same href.
+func (c *Client) href(h string) string {
+	u, err := url.Parse(h)
+	if err != nil {
+		return h
+	}
+	return u.EscapedPath()
+}
+
+// resolve returns the URL for the href.
+func (c *Client) resolve(href string) string {
+	base, err := url.Parse(c.URL)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func (c *Client) do(ctx context.Context, method, u string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s %s: %w", method, u, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,443 @@
+package caldav
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/poy/assistant/pkg/ical"
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+// State is what was synced last time. It is how a change is told apart from
+// a deletion on either side.
+type State struct {
+	// Objects are keyed by the VTODO's UID.
+	Objects map[string]*Object `json:"objects"`
+}
+
+// Object is a synced task.
+type Object struct {
+	Href string `json:"href"`
+	ETag string `json:"etag"`
+	// Name is the task's name in the store.
+	Name string `json:"name"`
+	// Hash is of the task when it was synced, so local changes can be
+	// detected.
+	Hash string `json:"hash"`
+}
+
+// LoadState reads the state. A missing file is an empty state.
+func LoadState(p string) (*State, error) {
+	s := &State{Objects: map[string]*Object{}}
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to decode sync state %s: %w", p, err)
+	}
+	if s.Objects == nil {
+		s.Objects = map[string]*Object{}
+	}
+	return s, nil
+}
+
+// Save writes the state.
+func (s *State) Save(p string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), filepath.Base(p)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Result is what a sync did.
+type Result struct {
+	Pushed        []string
+	Pulled        []string
+	DeletedLocal  []string
+	DeletedRemote []string
+	// Conflicts are the tasks that changed in both places. The server's
+	// fields were kept and the notes were merged.
+	Conflicts []string
+}
+
+// Sync does a two-way sync between the store and the calendar. The state is
+// saved to statePath, even if the sync fails part way.
+func Sync(ctx context.Context, c *Client, s tasks.Store, statePath string) (Result, error) {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return Result{}, err
+	}
+
+	sy := &syncer{c: c, s: s, state: state}
+	err = sy.sync(ctx)
+	if saveErr := state.Save(statePath); saveErr != nil {
+		err = errors.Join(err, saveErr)
+	}
+	return sy.result, err
+}
+
+type syncer struct {
+	c      *Client
+	s      tasks.Store
+	state  *State
+	result Result
+}
+
+func (sy *syncer) sync(ctx context.Context) error {
+	remote, err := sy.c.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The objects that were synced before.
+	known := map[string]bool{}
+	for _, uid := range sortedUIDs(sy.state) {
+		o := sy.state.Objects[uid]
+		known[o.Href] = true
+		if err := sy.syncKnown(ctx, uid, o, remote); err != nil {
+			return err
+		}
+	}
+
+	// New objects on the server. These are done before the new tasks so that
+	// a task that was added in both places is linked instead of duplicated.
+	var hrefs []string
+	for href := range remote {
+		if !known[href] {
+			hrefs = append(hrefs, href)
+		}
+	}
+	sort.Strings(hrefs)
+	for _, href := range hrefs {
+		if err := sy.pullNew(ctx, href); err != nil {
+			return err
+		}
+	}
+
+	// New tasks.
+	linked := map[string]bool{}
+	for _, o := range sy.state.Objects {
+		linked[strings.ToLower(o.Name)] = true
+	}
+	for _, name := range sy.s.TaskNames() {
+		if linked[strings.ToLower(name)] {
+			continue
+		}
+		t := sy.s.GetTask(name)
+		if t == nil {
+			continue
+		}
+		uid := t.Data().ID()
+		o := &Object{Href: sy.c.Href(uid + ".ics"), Name: t.Name()}
+		if err := sy.push(ctx, uid, o, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncKnown syncs an object that was synced before.
+func (sy *syncer) syncKnown(ctx context.Context, uid string, o *Object, remote map[string]string) error {
+	t := sy.s.GetTask(o.Name)
+	etag, onServer := remote[o.Href]
+	localChanged := t != nil && hash(uid, t.Data()) != o.Hash
+	remoteChanged := onServer && etag != o.ETag
+
+	switch {
+	case t == nil && !onServer:
+		delete(sy.state.Objects, uid)
+	case t == nil && remoteChanged:
+		// The change on the server wins over the deletion.
+		return sy.pull(ctx, uid, o)
+	case t == nil:
+		err := sy.c.Delete(ctx, o.Href, o.ETag)
+		if errors.Is(err, ErrPreconditionFailed) {
+			return sy.pull(ctx, uid, o)
+		}
+		if err != nil {
+			return err
+		}
+		delete(sy.state.Objects, uid)
+		sy.result.DeletedRemote = append(sy.result.DeletedRemote, o.Name)
+	case !onServer && localChanged:
+		// The local change wins over the deletion.
+		o.ETag = ""
+		return sy.push(ctx, uid, o, t)
+	case !onServer:
+		sy.s.Remove(o.Name)
+		delete(sy.state.Objects, uid)
+		sy.result.DeletedLocal = append(sy.result.DeletedLocal, o.Name)
+	case localChanged && remoteChanged:
+		return sy.merge(ctx, uid, o, t)
+	case localChanged:
+		o.ETag = etag
+		err := sy.push(ctx, uid, o, t)
+		if errors.Is(err, ErrPreconditionFailed) {
+			return sy.merge(ctx, uid, o, t)
+		}
+		return err
+	case remoteChanged:
+		return sy.pull(ctx, uid, o)
+	}
+	return nil
+}
+
+// push saves the task to the server. The object's ETag is what is expected to
+// be on the server.
+func (sy *syncer) push(ctx context.Context, uid string, o *Object, t *tasks.Task) error {
+	d := t.Data()
+	etag, err := sy.c.Put(ctx, o.Href, toTodo(uid, d), o.ETag)
+	if err != nil {
+		return err
+	}
+	o.ETag = etag
+	o.Name = d.Name
+	o.Hash = hash(uid, d)
+	sy.state.Objects[uid] = o
+	sy.result.Pushed = append(sy.result.Pushed, d.Name)
+	return nil
+}
+
+// pull saves the object from the server to the store.
+func (sy *syncer) pull(ctx context.Context, uid string, o *Object) error {
+	todo, etag, err := sy.c.Get(ctx, o.Href)
+	if errors.Is(err, ErrNotFound) {
+		delete(sy.state.Objects, uid)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	t, err := sy.apply(o.Name, todo)
+	if err != nil {
+		return err
+	}
+	o.ETag = etag
+	o.Name = t.Name()
+	o.Hash = hash(uid, t.Data())
+	sy.state.Objects[uid] = o
+	sy.result.Pulled = append(sy.result.Pulled, t.Name())
+	return nil
+}
+
+// pullNew saves a new object from the server to the store.
+func (sy *syncer) pullNew(ctx context.Context, href string) error {
+	todo, etag, err := sy.c.Get(ctx, href)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	uid := todo.UID
+	if uid == "" {
+		uid = href
+	}
+	o := &Object{Href: href, ETag: etag, Name: todo.Summary}
+
+	if t := sy.s.GetTask(todo.Summary); t != nil {
+		if !sy.linked(uid, t.Name()) {
+			// The same task was added in both places.
+			return sy.mergeTodo(ctx, uid, o, t, todo)
+		}
+
+		// The name belongs to a task that is synced with a different to-do,
+		// so the to-do is added under another name. The new name is pushed
+		// so that both places agree on it.
+		todo.Summary = sy.freeName(todo.Summary)
+		t, err := sy.apply("", todo)
+		if err != nil {
+			return err
+		}
+		sy.result.Pulled = append(sy.result.Pulled, t.Name())
+		return sy.push(ctx, uid, o, t)
+	}
+
+	t, err := sy.apply("", todo)
+	if err != nil {
+		return err
+	}
+	o.Name = t.Name()
+	o.Hash = hash(uid, t.Data())
+	sy.state.Objects[uid] = o
+	sy.result.Pulled = append(sy.result.Pulled, t.Name())
+	return nil
+}
+
+// linked returns true if the task is synced with a to-do other than uid.
+func (sy *syncer) linked(uid, name string) bool {
+	for other, o := range sy.state.Objects {
+		if other != uid && strings.EqualFold(o.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// freeName returns the name with a number that no task has (e.g., "buy milk
+// (2)").
+func (sy *syncer) freeName(name string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if sy.s.GetTask(candidate) == nil {
+			return candidate
+		}
+	}
+}
+
+// merge handles a task that changed in both places.
+func (sy *syncer) merge(ctx context.Context, uid string, o *Object, t *tasks.Task) error {
+	todo, etag, err := sy.c.Get(ctx, o.Href)
+	if errors.Is(err, ErrNotFound) {
+		o.ETag = ""
+		return sy.push(ctx, uid, o, t)
+	}
+	if err != nil {
+		return err
+	}
+	o.ETag = etag
+	return sy.mergeTodo(ctx, uid, o, t, todo)
+}
+
+// mergeTodo keeps the server's fields, merges the notes and then saves the
+// result to both places.
+func (sy *syncer) mergeTodo(ctx context.Context, uid string, o *Object, t *tasks.Task, todo ical.Todo) error {
+	local := t.Data()
+	todo.Comments = mergeComments(todo.Comments, toTodo(uid, local).Comments)
+
+	merged, err := sy.apply(t.Name(), todo)
+	if err != nil {
+		return err
+	}
+	sy.result.Conflicts = append(sy.result.Conflicts, merged.Name())
+
+	// Don't count the conflict as a push too.
+	pushed := len(sy.result.Pushed)
+	defer func() { sy.result.Pushed = sy.result.Pushed[:pushed] }()
+	return sy.push(ctx, uid, o, merged)
+}
+
+// apply saves the to-do to the task with the name, adding it if needed. If
+// the to-do was renamed, the task is renamed too. It fails with
+// tasks.ErrExists if a different task already has the to-do's summary.
+func (sy *syncer) apply(name string, todo ical.Todo) (*tasks.Task, error) {
+	if todo.Summary == "" {
+		return nil, fmt.Errorf("the to-do %s doesn't have a summary", todo.UID)
+	}
+
+	t := sy.s.GetTask(name)
+	if t == nil {
+		var err error
+		if t, err = sy.s.Create(todo.Summary, todo.Description); err != nil {
+			return nil, err
+		}
+	} else if t.Name() != todo.Summary {
+		if err := t.Rename(todo.Summary); err != nil {
+			return nil, fmt.Errorf("failed to rename %q: %w", name, err)
+		}
+	}
+
+	// The calendar doesn't have the assignee or watchers.
+	d := t.Data()
+	d.Description = todo.Description
+	d.Due = todo.Due
+	d.CompletedAt = todo.Completed
+	d.Notes = nil
+	for _, c := range todo.Comments {
+		d.Notes = append(d.Notes, tasks.NoteData{Time: c.Time, Note: c.Text})
+	}
+	if err := t.SetData(d); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// toTodo maps the task to a to-do. Times are truncated to seconds because
+// that is all iCalendar keeps.
+func toTodo(uid string, d tasks.Data) ical.Todo {
+	todo := ical.Todo{
+		UID:         uid,
+		Summary:     d.Name,
+		Description: d.Description,
+		Created:     truncate(d.Created),
+		Due:         truncate(d.Due),
+		Completed:   truncate(d.CompletedAt),
+	}
+	for _, n := range d.Notes {
+		todo.Comments = append(todo.Comments, ical.Comment{Time: truncate(n.Time), Text: n.Note})
+	}
+	return todo
+}
+
+func truncate(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC().Truncate(time.Second)
+}
+
+// hash is used to tell if the task changed since it was synced. Only the
+// fields that are synced are included.
+func hash(uid string, d tasks.Data) string {
+	todo := toTodo(uid, d)
+	// The creation time is only kept locally.
+	todo.Created = time.Time{}
+	data, _ := json.Marshal(todo)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// mergeComments returns the comments from both, without duplicates, ordered
+// by time.
+func mergeComments(a, b []ical.Comment) []ical.Comment {
+	seen := map[string]bool{}
+	var result []ical.Comment
+	for _, c := range append(append([]ical.Comment(nil), a...), b...) {
+		key := fmt.Sprintf("%d/%s", c.Time.Unix(), c.Text)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, c)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+func sortedUIDs(s *State) []string {
+	var uids []string
+	for uid := range s.Objects {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids
+}
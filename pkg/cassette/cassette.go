@@ -0,0 +1,201 @@
+// Package cassette records the prompts sent to an LLM and its responses, and
+// replays them later. This allows tests to run a whole agent (including the
+// predictors and the tools it calls) without the LLM, while still failing
+// when the prompts change.
+//
+// A cassette is recorded once against the real LLM with a Recorder and saved
+// as a golden file. Tests then serve it back with a Replayer, which looks the
+// responses up by the hash of the prompt.
+package cassette
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// ErrUnrecorded is returned by the Replayer for a prompt that isn't in the
+// cassette. It usually means the prompt changed and the cassette needs to be
+// recorded again.
+var ErrUnrecorded = errors.New("the prompt isn't in the cassette")
+
+// Interaction is a prompt and what the LLM returned for it.
+type Interaction struct {
+	// Hash is the Hash of the prompt.
+	Hash     string `json:"hash"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+	// Error is set if the LLM failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Cassette is the interactions with the LLM, in the order they happened.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Hash returns the key for the prompt.
+func Hash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load reads the cassette from the file.
+func Load(p string) (*Cassette, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette %s: %w", p, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to the file. It is indented so changes to the
+// cassette are easy to review.
+func (c *Cassette) Save(p string) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for cassette: %w", err)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.WriteFile(p, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Recorder wraps an LLM and records each prompt and response to the
+// cassette. It is safe to use from multiple goroutines.
+type Recorder[TParams any] struct {
+	llm llms.LLM[TParams]
+
+	mu sync.Mutex
+	c  *Cassette
+}
+
+var _ llms.LLM[int] = (*Recorder[int])(nil)
+
+// NewRecorder returns a Recorder that records the LLM's interactions.
+func NewRecorder[TParams any](llm llms.LLM[TParams]) *Recorder[TParams] {
+	return &Recorder[TParams]{llm: llm, c: &Cassette{}}
+}
+
+// Generate implements llms.LLM.
+func (r *Recorder[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	resp, err := r.llm.Generate(ctx, prompt, params)
+	if ctx.Err() != nil {
+		// The run was cancelled, so there is nothing to replay.
+		return resp, err
+	}
+
+	i := Interaction{Hash: Hash(prompt), Prompt: prompt, Response: resp}
+	if err != nil {
+		i.Error = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.c.Interactions = append(r.c.Interactions, i)
+	return resp, err
+}
+
+// Cassette returns what has been recorded so far.
+func (r *Recorder[TParams]) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), r.c.Interactions...)}
+}
+
+// Replayer is an LLM that serves the responses from a cassette. If the same
+// prompt was recorded several times, the responses are returned in the order
+// they were recorded. It is safe to use from multiple goroutines.
+type Replayer[TParams any] struct {
+	mu     sync.Mutex
+	c      *Cassette
+	played []bool
+}
+
+var _ llms.LLM[int] = (*Replayer[int])(nil)
+
+// NewReplayer returns a Replayer for the cassette.
+func NewReplayer[TParams any](c *Cassette) *Replayer[TParams] {
+	return &Replayer[TParams]{c: c, played: make([]bool, len(c.Interactions))}
+}
+
+// Generate implements llms.LLM. It returns ErrUnrecorded if the prompt isn't
+// in the cassette (or its responses have all been used).
+func (r *Replayer[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash := Hash(prompt)
+	next := -1
+	for i, in := range r.c.Interactions {
+		if r.played[i] {
+			continue
+		}
+		if next < 0 {
+			next = i
+		}
+		if in.Hash != hash {
+			continue
+		}
+
+		r.played[i] = true
+		if in.Error != "" {
+			return in.Response, errors.New(in.Error)
+		}
+		return in.Response, nil
+	}
+
+	if next < 0 {
+		return "", fmt.Errorf("%w: %s (every interaction was already played)", ErrUnrecorded, hash)
+	}
+	return "", fmt.Errorf("%w: %s (%s)", ErrUnrecorded, hash, Diff(r.c.Interactions[next].Prompt, prompt))
+}
+
+// Unplayed returns the interactions that haven't been replayed. For a test
+// that ran the same as when it was recorded, it is empty.
+func (r *Replayer[TParams]) Unplayed() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []Interaction
+	for i, in := range r.c.Interactions {
+		if !r.played[i] {
+			result = append(result, in)
+		}
+	}
+	return result
+}
+
+// Diff describes the first line that differs between the recorded prompt
+// and the given one.
+func Diff(recorded, prompt string) string {
+	want, got := strings.Split(recorded, "\n"), strings.Split(prompt, "\n")
+	for i := 0; i < len(want) || i < len(got); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d of the prompt was %q, got %q", i+1, w, g)
+		}
+	}
+	return "the prompts are the same"
+}
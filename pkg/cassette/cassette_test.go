@@ -0,0 +1,142 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/poy/assistant/pkg/cassette"
+)
+
+// record runs the prompts through a Recorder and saves the cassette, then
+// loads it back.
+func record(t *testing.T, llm *llmstesting.Fake[int], prompts ...string) *cassette.Cassette {
+	t.Helper()
+	r := cassette.NewRecorder[int](llm)
+	for _, p := range prompts {
+		r.Generate(context.Background(), p, 0)
+	}
+
+	p := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+	if err := r.Cassette().Save(p); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cassette.Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCassette(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{
+			name: "replays the responses",
+			run: func(t *testing.T) {
+				c := record(t, &llmstesting.Fake[int]{Outputs: map[string]string{"a": "1", "b": "2"}}, "a", "b")
+				r := cassette.NewReplayer[int](c)
+
+				// The order of different prompts doesn't matter.
+				for _, tc := range []struct{ prompt, expected string }{{"b", "2"}, {"a", "1"}} {
+					actual, err := r.Generate(context.Background(), tc.prompt, 0)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if actual != tc.expected {
+						t.Fatalf("expected %q, got %q", tc.expected, actual)
+					}
+				}
+				if unplayed := r.Unplayed(); len(unplayed) != 0 {
+					t.Fatalf("expected everything to be replayed, got %+v", unplayed)
+				}
+			},
+		},
+		{
+			name: "replays the same prompt in order",
+			run: func(t *testing.T) {
+				llm := &llmstesting.Fake[int]{Outputs: map[string]string{}}
+				llm.GenerateF = func(ctx context.Context, prompt string) {
+					llm.Outputs[prompt] = strings.Repeat("x", len(llm.Prompts))
+				}
+				r := cassette.NewReplayer[int](record(t, llm, "a", "a", "a"))
+
+				for _, expected := range []string{"x", "xx", "xxx"} {
+					actual, err := r.Generate(context.Background(), "a", 0)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if actual != expected {
+						t.Fatalf("expected %q, got %q", expected, actual)
+					}
+				}
+				if _, err := r.Generate(context.Background(), "a", 0); !errors.Is(err, cassette.ErrUnrecorded) {
+					t.Fatalf("expected ErrUnrecorded once the responses are used up, got %v", err)
+				}
+			},
+		},
+		{
+			name: "replays errors",
+			run: func(t *testing.T) {
+				c := record(t, &llmstesting.Fake[int]{Err: errors.New("some-error")}, "a")
+				if _, err := cassette.NewReplayer[int](c).Generate(context.Background(), "a", 0); err == nil || err.Error() != "some-error" {
+					t.Fatalf("expected some-error, got %v", err)
+				}
+			},
+		},
+		{
+			name: "a changed prompt fails with the difference",
+			run: func(t *testing.T) {
+				c := record(t, &llmstesting.Fake[int]{AlwaysText: "1"}, "Tools:\nlist\nGoal: a")
+				r := cassette.NewReplayer[int](c)
+
+				_, err := r.Generate(context.Background(), "Tools:\nlist\nGoal: b", 0)
+				if !errors.Is(err, cassette.ErrUnrecorded) {
+					t.Fatalf("expected ErrUnrecorded, got %v", err)
+				}
+				if !strings.Contains(err.Error(), `line 3 of the prompt was "Goal: a", got "Goal: b"`) {
+					t.Fatalf("expected the difference in the error, got %v", err)
+				}
+				if unplayed := r.Unplayed(); len(unplayed) != 1 || unplayed[0].Prompt != "Tools:\nlist\nGoal: a" {
+					t.Fatalf("expected the recorded prompt to be unplayed, got %+v", unplayed)
+				}
+			},
+		},
+		{
+			name: "keys by the prompt hash",
+			run: func(t *testing.T) {
+				c := record(t, &llmstesting.Fake[int]{AlwaysText: "1"}, "a")
+				if actual, expected := c.Interactions[0].Hash, cassette.Hash("a"); actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if cassette.Hash("a") == cassette.Hash("b") {
+					t.Fatal("expected different prompts to have different hashes")
+				}
+			},
+		},
+		{
+			name: "loading a missing cassette fails",
+			run: func(t *testing.T) {
+				if _, err := cassette.Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tc.run(t)
+		})
+	}
+}
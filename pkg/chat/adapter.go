@@ -0,0 +1,395 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/sessions"
+	assistanttools "github.com/poy/assistant/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/assistant/pkg/tools/userinput"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// maxHistoryTurns is how many of the previous turns are given to the agent.
+const maxHistoryTurns = 5
+
+// eventTTL is how long an event ID is remembered so the platform's retries of
+// it are ignored.
+const eventTTL = time.Hour
+
+// Adapter is an http.Handler for the chat platform's webhook.
+type Adapter struct {
+	ctx     context.Context
+	client  Client
+	dir     string
+	tenants *tasks.Tenants
+	secret  string
+	timeout time.Duration
+
+	mu    sync.Mutex
+	users map[string]*user
+	wg    sync.WaitGroup
+
+	// seenMu guards the signatures and events that were already accepted.
+	seenMu     sync.Mutex
+	signatures map[string]time.Time
+	events     map[string]time.Time
+}
+
+// Option configures the Adapter.
+type Option func(*Adapter)
+
+// WithDir saves each user's sessions under dir/<user>. Without it, the
+// sessions aren't saved.
+func WithDir(dir string) Option {
+	return func(a *Adapter) {
+		a.dir = dir
+	}
+}
+
+// WithTenants is where each user's tasks are kept. Without it, the tasks are
+// only kept in memory.
+func WithTenants(t *tasks.Tenants) Option {
+	return func(a *Adapter) {
+		a.tenants = t
+	}
+}
+
+// WithTimeout limits how long each message can be worked on. Zero means there
+// isn't a deadline.
+func WithTimeout(d time.Duration) Option {
+	return func(a *Adapter) {
+		a.timeout = d
+	}
+}
+
+// New returns an Adapter that replies with the Client. Each webhook request
+// must be signed with the secret (see Sign). Each user gets their own
+// injection container derived from the context.
+func New(ctx context.Context, c Client, secret string, opts ...Option) (*Adapter, error) {
+	if secret == "" {
+		return nil, errors.New("the webhook secret is required")
+	}
+	a := &Adapter{
+		ctx:        ctx,
+		client:     c,
+		secret:     secret,
+		users:      map[string]*user{},
+		signatures: map[string]time.Time{},
+		events:     map[string]time.Time{},
+	}
+	for _, o := range opts {
+		o(a)
+	}
+	if a.tenants == nil {
+		a.tenants = tasks.NewTenants("", nil)
+	}
+	return a, nil
+}
+
+// Store returns the tasks for the chat user.
+func (a *Adapter) Store(userID string) (tasks.Store, error) {
+	u, err := a.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	return u.store, nil
+}
+
+// Wait waits for the messages that are being worked on.
+func (a *Adapter) Wait() {
+	a.wg.Wait()
+}
+
+// Close waits for the messages that are being worked on and then closes the
+// sessions.
+func (a *Adapter) Close() error {
+	a.Wait()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for _, u := range a.users {
+		if u.session != nil {
+			errs = append(errs, u.session.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ServeHTTP implements http.Handler.
+func (a *Adapter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if err := a.verify(r.Header, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	switch env.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, env.Challenge)
+		return
+	case "event_callback":
+	default:
+		http.Error(w, fmt.Sprintf("unknown type %q", env.Type), http.StatusBadRequest)
+		return
+	}
+
+	// The chat platform expects a quick response, so the message is worked
+	// on in the background. Retries of an event that was already accepted
+	// are acknowledged and ignored.
+	w.WriteHeader(http.StatusOK)
+	if env.EventID != "" && !a.firstSeen(a.events, env.EventID, eventTTL) {
+		return
+	}
+	m := env.Event.Message
+	if env.Event.Type != "message" || m.BotID != "" || m.User == "" || m.Text == "" {
+		return
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		if err := a.handleMessage(m); err != nil {
+			slog.Error("failed to handle the chat message", "user", m.User, "error", err)
+		}
+	}()
+}
+
+// verify checks the request's timestamp and signature, and that the request
+// wasn't already accepted.
+func (a *Adapter) verify(h http.Header, body []byte) error {
+	timestamp := h.Get(TimestampHeader)
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid timestamp")
+	}
+	if skew := time.Since(time.Unix(sec, 0)); skew > MaxSkew || skew < -MaxSkew {
+		return errors.New("stale timestamp")
+	}
+	signature := h.Get(SignatureHeader)
+	if !validSignature(a.secret, timestamp, body, signature) {
+		return errors.New("invalid signature")
+	}
+	// A signature can only be valid for 2*MaxSkew.
+	if !a.firstSeen(a.signatures, signature, 2*MaxSkew) {
+		return errors.New("replayed request")
+	}
+	return nil
+}
+
+// firstSeen records the key and reports whether it wasn't already recorded
+// in the last ttl.
+func (a *Adapter) firstSeen(seen map[string]time.Time, key string, ttl time.Duration) bool {
+	a.seenMu.Lock()
+	defer a.seenMu.Unlock()
+	now := time.Now()
+	for k, t := range seen {
+		if now.Sub(t) > ttl {
+			delete(seen, k)
+		}
+	}
+	if _, ok := seen[key]; ok {
+		return false
+	}
+	seen[key] = now
+	return true
+}
+
+func (a *Adapter) handleMessage(m Message) error {
+	u, err := a.user(m.User)
+	if err != nil {
+		return err
+	}
+
+	thread := m.ThreadTS
+	if thread == "" {
+		thread = m.TS
+	}
+
+	u.mu.Lock()
+	if u.pending != nil && u.thread == thread {
+		q := *u.pending
+		u.pending = nil
+		u.mu.Unlock()
+		q.Answer(m.Text)
+		return nil
+	}
+	if u.running {
+		u.mu.Unlock()
+		return a.reply(m.Channel, thread, "I'm still working on your last request.")
+	}
+	u.running = true
+	u.thread = thread
+	history := u.history
+	u.mu.Unlock()
+
+	answer, err := a.run(u, m.Channel, thread, m.Text, history)
+
+	u.mu.Lock()
+	u.running = false
+	u.pending = nil
+	if err == nil {
+		u.history = append(u.history, sessions.Turn{UserInput: m.Text, Answer: answer})
+	}
+	u.mu.Unlock()
+
+	if err != nil {
+		return a.reply(m.Channel, thread, fmt.Sprintf("Sorry, I couldn't do that: %v", err))
+	}
+	return a.reply(m.Channel, thread, answer)
+}
+
+// run runs the agent for the goal. The agent's questions and output are
+// posted in the thread.
+func (a *Adapter) run(u *user, channel, thread, goal string, history []sessions.Turn) (string, error) {
+	ctx, cancel := context.WithCancel(u.ctx)
+	defer cancel()
+	if a.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+
+	asker := userinput.NewChannel()
+	ctx = sessions.WithRecorder(ctx, u.recorder())
+	ctx = display.WithSink(ctx, threadSink{a: a, channel: channel, thread: thread})
+	ctx = userinput.WithAsker(ctx, asker)
+
+	go func() {
+		for {
+			select {
+			case q := <-asker.Questions():
+				u.mu.Lock()
+				u.pending = &q
+				u.mu.Unlock()
+				if err := a.reply(channel, thread, q.Text); err != nil {
+					slog.Error("failed to ask a question", "user", u.id, "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	u.recorder().Record(sessions.Event{Type: sessions.EventUserInput, Text: goal})
+	answer, err := u.agent.Run(ctx, sessions.HistoryPrompt(history, goal, maxHistoryTurns))
+	if err != nil {
+		u.recorder().Record(sessions.Event{Type: sessions.EventError, Text: err.Error()})
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("stopped the goal: %w", ctx.Err())
+		}
+		return "", err
+	}
+	return answer, nil
+}
+
+func (a *Adapter) reply(channel, thread, text string) error {
+	return a.client.Post(a.ctx, Message{Channel: channel, ThreadTS: thread, Text: text})
+}
+
+// user is the state for a single chat user.
+type user struct {
+	id      string
+	ctx     context.Context
+	store   tasks.Store
+	agent   assistanttools.Agent[string]
+	session *sessions.Session
+
+	mu      sync.Mutex
+	running bool
+	thread  string
+	pending *userinput.Question
+	history []sessions.Turn
+}
+
+func (u *user) recorder() sessions.Recorder {
+	if u.session == nil {
+		return sessions.NopRecorder{}
+	}
+	return u.session
+}
+
+// user returns the state for the user, creating it the first time.
+func (a *Adapter) user(id string) (*user, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if u, ok := a.users[id]; ok {
+		return u, nil
+	}
+
+	store, err := a.tenants.UserStore(id)
+	if err != nil {
+		return nil, err
+	}
+	u := &user{id: id, store: store}
+	if a.dir != "" {
+		dir, err := userDir(a.dir, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", id, err)
+		}
+		u.session, err = sessions.New(sessions.Dir(filepath.Join(dir, "sessions")))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create session for %s: %w", id, err)
+		}
+	}
+
+	// Each user gets their own container so the agents only see their tasks.
+	u.ctx = injection.WithInjection(tasks.WithStore(a.ctx, u.store))
+	u.agent = injection.Resolve[tasks.TaskAgent](u.ctx).Agent
+
+	a.users[id] = u
+	return u, nil
+}
+
+// userDir returns the directory for the user. The ID comes from the chat
+// platform, so make sure it can't escape the directory.
+func userDir(dir, id string) (string, error) {
+	name, err := tasks.SafeName(id)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// threadSink posts the output in the thread.
+type threadSink struct {
+	a       *Adapter
+	channel string
+	thread  string
+}
+
+// Show implements display.Sink.
+func (s threadSink) Show(ctx context.Context, o display.Output) error {
+	return s.a.reply(s.channel, s.thread, o.Text)
+}
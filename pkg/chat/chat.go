@@ -0,0 +1,122 @@
+// Package chat lets the assistant be used from team chat (e.g., Slack or
+// Matrix). The chat platform sends each message to the Adapter's webhook and
+// the Adapter replies in the message's thread with a Client.
+//
+// Each chat user has their own tasks (see tasks.Tenants), session and
+// conversation. When an agent asks the user a question, it is posted in the
+// thread and the user's next reply in that thread answers it.
+package chat
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Message is a message in the chat.
+type Message struct {
+	Channel string `json:"channel"`
+	User    string `json:"user,omitempty"`
+	Text    string `json:"text"`
+	// TS identifies the message.
+	TS string `json:"ts,omitempty"`
+	// ThreadTS is the message that started the thread, if the message is in
+	// a thread.
+	ThreadTS string `json:"thread_ts,omitempty"`
+	// BotID is set for messages from bots, which are ignored.
+	BotID string `json:"bot_id,omitempty"`
+}
+
+// Client posts messages to the chat.
+type Client interface {
+	// Post posts the message.
+	Post(ctx context.Context, m Message) error
+}
+
+// WebhookClient posts the messages as JSON to the chat platform's API.
+type WebhookClient struct {
+	// URL is where the messages are posted (e.g., chat.postMessage).
+	URL string
+	// Token is sent as a bearer token if it is set.
+	Token string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Post implements Client.
+func (c *WebhookClient) Post(ctx context.Context, m Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post message: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to post message: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// envelope is what the chat platform sends to the webhook.
+type envelope struct {
+	// Type is either url_verification or event_callback.
+	Type      string `json:"type"`
+	Challenge string `json:"challenge,omitempty"`
+	// EventID is the same when the platform retries a delivery.
+	EventID string `json:"event_id,omitempty"`
+	Event   struct {
+		Type string `json:"type"`
+		Message
+	} `json:"event"`
+}
+
+// SignatureHeader has the HMAC-SHA256 of the request's timestamp and body. It
+// is formatted as "sha256=<hex>". See Sign.
+const SignatureHeader = "X-Signature"
+
+// TimestampHeader has when the request was sent, in Unix seconds.
+const TimestampHeader = "X-Signature-Timestamp"
+
+// MaxSkew is how far the TimestampHeader can be from the Adapter's clock.
+// Older requests are rejected, so a request can't be replayed later.
+const MaxSkew = 5 * time.Minute
+
+// Sign returns the value of the SignatureHeader for the body sent at the
+// timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	io.WriteString(mac, "v0:"+timestamp+":")
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func validSignature(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(strings.TrimSpace(signature)))
+}
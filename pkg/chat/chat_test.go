@@ -0,0 +1,378 @@
+package chat_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/chat"
+	assistanttesting "github.com/poy/assistant/pkg/testing"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// fakeChat is a chat platform that records the posted messages.
+type fakeChat struct {
+	mu       sync.Mutex
+	messages []chat.Message
+	posted   chan chat.Message
+}
+
+func newFakeChat(t *testing.T) (*fakeChat, *chat.WebhookClient) {
+	t.Helper()
+	f := &fakeChat{posted: make(chan chat.Message, 100)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer some-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var m chat.Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.messages = append(f.messages, m)
+		f.mu.Unlock()
+		f.posted <- m
+	}))
+	t.Cleanup(srv.Close)
+	return f, &chat.WebhookClient{URL: srv.URL, Token: "some-token"}
+}
+
+// next waits for the next posted message.
+func (f *fakeChat) next(t *testing.T) chat.Message {
+	t.Helper()
+	select {
+	case m := <-f.posted:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return chat.Message{}
+	}
+}
+
+const secret = "some-secret"
+
+// signedRequest returns a webhook request signed at the time.
+func signedRequest(body string, at time.Time) *http.Request {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(chat.TimestampHeader, timestamp)
+	req.Header.Set(chat.SignatureHeader, chat.Sign(secret, timestamp, []byte(body)))
+	return req
+}
+
+func send(t *testing.T, h http.Handler, m chat.Message) {
+	t.Helper()
+	sendEvent(t, h, "", time.Now(), m)
+}
+
+// sendEvent sends the message with the event ID at the time.
+func sendEvent(t *testing.T, h http.Handler, eventID string, at time.Time, m chat.Message) {
+	t.Helper()
+	event := map[string]any{
+		"type":      "message",
+		"channel":   m.Channel,
+		"user":      m.User,
+		"text":      m.Text,
+		"ts":        m.TS,
+		"thread_ts": m.ThreadTS,
+		"bot_id":    m.BotID,
+	}
+	body, err := json.Marshal(map[string]any{"type": "event_callback", "event_id": eventID, "event": event})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest(string(body), at))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d (%s)", http.StatusOK, rec.Code, rec.Body)
+	}
+}
+
+func scriptedLLM(script ...string) *llmstesting.Fake[vertex.Params] {
+	llm := &llmstesting.Fake[vertex.Params]{Outputs: map[string]string{}}
+	llm.GenerateF = func(ctx context.Context, prompt string) {
+		if i := len(llm.Prompts) - 1; i < len(script) {
+			llm.Outputs[prompt] = script[i]
+			return
+		}
+		llm.Outputs[prompt] = fmt.Sprintf(`{"thought": "out of script", "final_answer": "unexpected prompt %d"}`, len(llm.Prompts))
+	}
+	return llm
+}
+
+func TestAdapter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		script []string
+		run    func(t *testing.T, a *chat.Adapter, f *fakeChat)
+	}{
+		{
+			name: "replies with the final answer in the thread",
+			script: []string{
+				`{"thought": "I know the answer", "final_answer": "some-answer"}`,
+			},
+			run: func(t *testing.T, a *chat.Adapter, f *fakeChat) {
+				send(t, a, chat.Message{Channel: "C1", User: "U1", Text: "some-goal", TS: "1.0"})
+
+				m := f.next(t)
+				if actual, expected := m, (chat.Message{Channel: "C1", ThreadTS: "1.0", Text: "some-answer"}); actual != expected {
+					t.Fatalf("expected %+v, got %+v", expected, actual)
+				}
+			},
+		},
+		{
+			name: "asks questions in the thread",
+			script: []string{
+				`{"thought": "I should modify the task", "action": "modify", "input": "add a note"}`,
+				`{"thought": "I should ask the user", "action": "user-input", "input": "Which task?"}`,
+				`{"thought": "I know the task", "final_answer": "some-task"}`,
+				`{"thought": "I added the note", "final_answer": "Added the note"}`,
+				`{"thought": "I know the answer", "final_answer": "some-answer"}`,
+			},
+			run: func(t *testing.T, a *chat.Adapter, f *fakeChat) {
+				s, err := a.Store("U1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.Add("some-task", "some-description")
+
+				send(t, a, chat.Message{Channel: "C1", User: "U1", Text: "some-goal", TS: "1.0"})
+
+				q := f.next(t)
+				if actual, expected := q, (chat.Message{Channel: "C1", ThreadTS: "1.0", Text: "Which task?"}); actual != expected {
+					t.Fatalf("expected %+v, got %+v", expected, actual)
+				}
+
+				send(t, a, chat.Message{Channel: "C1", User: "U1", Text: "some-task", TS: "2.0", ThreadTS: "1.0"})
+
+				if actual, expected := f.next(t).Text, "some-answer"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "keeps the tasks for each user separate",
+			script: []string{
+				`{"thought": "I should display the tasks", "action": "display", "input": "all the tasks"}`,
+				`{"thought": "I should list the tasks", "action": "list", "input": ""}`,
+				`{"thought": "I displayed the tasks", "final_answer": "Displayed the tasks"}`,
+				`{"thought": "I know the answer", "final_answer": "some-answer"}`,
+			},
+			run: func(t *testing.T, a *chat.Adapter, f *fakeChat) {
+				s, err := a.Store("U1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.Add("some-task", "some-description")
+
+				send(t, a, chat.Message{Channel: "C1", User: "U2", Text: "show my tasks", TS: "1.0"})
+
+				if actual, expected := f.next(t).Text, "You don't have any tasks yet..."; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := f.next(t).Text, "some-answer"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "ignores retried events",
+			script: []string{
+				`{"thought": "I know the answer", "final_answer": "some-answer"}`,
+			},
+			run: func(t *testing.T, a *chat.Adapter, f *fakeChat) {
+				m := chat.Message{Channel: "C1", User: "U1", Text: "some-goal", TS: "1.0"}
+				sendEvent(t, a, "E1", time.Now().Add(-time.Minute), m)
+				sendEvent(t, a, "E1", time.Now(), m)
+				a.Wait()
+
+				f.mu.Lock()
+				defer f.mu.Unlock()
+				if len(f.messages) != 1 {
+					t.Fatalf("expected 1 message, got %v", f.messages)
+				}
+			},
+		},
+		{
+			name: "ignores bots",
+			run: func(t *testing.T, a *chat.Adapter, f *fakeChat) {
+				send(t, a, chat.Message{Channel: "C1", User: "U1", Text: "some-goal", TS: "1.0", BotID: "B1"})
+				a.Wait()
+
+				f.mu.Lock()
+				defer f.mu.Unlock()
+				if len(f.messages) != 0 {
+					t.Fatalf("expected no messages, got %v", f.messages)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injection.WithInjection(assistanttesting.WithFakeLLM(context.Background(), scriptedLLM(tc.script...)))
+
+			f, client := newFakeChat(t)
+			a, err := chat.New(
+				ctx,
+				client,
+				secret,
+				chat.WithDir(t.TempDir()),
+				chat.WithTenants(tasks.NewTenants(t.TempDir(), nil)),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer a.Close()
+
+			tc.run(t, a, f)
+		})
+	}
+}
+
+func TestAdapterRequests(t *testing.T) {
+	t.Parallel()
+
+	challenge := `{"type": "url_verification", "challenge": "some-challenge"}`
+	testCases := []struct {
+		name           string
+		request        func() *http.Request
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "answers the challenge",
+			request:        func() *http.Request { return signedRequest(challenge, time.Now()) },
+			expectedStatus: http.StatusOK,
+			expectedBody:   "some-challenge",
+		},
+		{
+			name: "invalid signature",
+			request: func() *http.Request {
+				req := signedRequest(challenge, time.Now())
+				req.Header.Set(chat.SignatureHeader, chat.Sign("other-secret", req.Header.Get(chat.TimestampHeader), []byte(challenge)))
+				return req
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "missing signature",
+			request: func() *http.Request {
+				req := signedRequest(challenge, time.Now())
+				req.Header.Del(chat.SignatureHeader)
+				return req
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "missing timestamp",
+			request: func() *http.Request {
+				req := signedRequest(challenge, time.Now())
+				req.Header.Del(chat.TimestampHeader)
+				return req
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "signed with a different timestamp",
+			request: func() *http.Request {
+				req := signedRequest(challenge, time.Now())
+				req.Header.Set(chat.TimestampHeader, strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+				return req
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "stale timestamp",
+			request:        func() *http.Request { return signedRequest(challenge, time.Now().Add(-chat.MaxSkew-time.Minute)) },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "future timestamp",
+			request:        func() *http.Request { return signedRequest(challenge, time.Now().Add(chat.MaxSkew+time.Minute)) },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "unknown type",
+			request:        func() *http.Request { return signedRequest(`{"type": "unknown"}`, time.Now()) },
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid body",
+			request:        func() *http.Request { return signedRequest(`{`, time.Now()) },
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injection.WithInjection(context.Background())
+			_, client := newFakeChat(t)
+			a, err := chat.New(ctx, client, secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			a.ServeHTTP(rec, tc.request())
+
+			if actual, expected := rec.Code, tc.expectedStatus; actual != expected {
+				t.Fatalf("expected %d, got %d (%s)", expected, actual, rec.Body)
+			}
+			if tc.expectedBody != "" && rec.Body.String() != tc.expectedBody {
+				t.Fatalf("expected %q, got %q", tc.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdapterRejectsReplays(t *testing.T) {
+	t.Parallel()
+	ctx := injection.WithInjection(context.Background())
+	_, client := newFakeChat(t)
+	a, err := chat.New(ctx, client, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"type": "url_verification", "challenge": "some-challenge"}`
+	req := signedRequest(body, time.Now())
+	for _, expected := range []int{http.StatusOK, http.StatusUnauthorized} {
+		replay := req.Clone(context.Background())
+		replay.Body = io.NopCloser(strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, replay)
+		if actual := rec.Code; actual != expected {
+			t.Fatalf("expected %d, got %d (%s)", expected, actual, rec.Body)
+		}
+	}
+}
+
+func TestNewRequiresSecret(t *testing.T) {
+	t.Parallel()
+	_, client := newFakeChat(t)
+	if _, err := chat.New(injection.WithInjection(context.Background()), client, ""); err == nil {
+		t.Fatal("expected an error")
+	}
+}
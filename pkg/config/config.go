@@ -0,0 +1,588 @@
+// Package config loads the settings for the assistant. Settings come from (in
+// order of precedence) flags, environment variables, the selected profile in
+// the config file and finally the defaults.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the contents of the config file.
+type File struct {
+	// DefaultProfile is the profile to use when one isn't selected.
+	DefaultProfile string `yaml:"default_profile"`
+	// Profiles are the named sets of settings (e.g., work, personal).
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is a named set of settings. Unset values fall through to the
+// defaults.
+type Profile struct {
+	LLM struct {
+		Provider    *string  `yaml:"provider"`
+		Model       *string  `yaml:"model"`
+		APIEndpoint *string  `yaml:"api_endpoint"`
+		ProjectID   *string  `yaml:"project_id"`
+		MaxTokens   *int     `yaml:"max_tokens"`
+		Temperature *float64 `yaml:"temperature"`
+		TopK        *int     `yaml:"top_k"`
+		TopP        *float64 `yaml:"top_p"`
+	} `yaml:"llm"`
+	Store struct {
+		Backend    *string             `yaml:"backend"`
+		Path       *string             `yaml:"path"`
+		TenantsDir *string             `yaml:"tenants_dir"`
+		Teams      map[string][]string `yaml:"teams"`
+	} `yaml:"store"`
+	Agent struct {
+		Timeout       *string `yaml:"timeout"`
+		MaxIterations *int    `yaml:"max_iterations"`
+		TokenBudget   *int    `yaml:"token_budget"`
+	} `yaml:"agent"`
+	Display struct {
+		Format       *string `yaml:"format"`
+		SummaryChars *int    `yaml:"summary_chars"`
+	} `yaml:"display"`
+	Tracing struct {
+		Exporter *string `yaml:"exporter"`
+		Endpoint *string `yaml:"endpoint"`
+	} `yaml:"tracing"`
+	Log struct {
+		Level *string `yaml:"level"`
+		File  *string `yaml:"file"`
+	} `yaml:"log"`
+}
+
+// Settings are the effective settings for the assistant.
+type Settings struct {
+	// Profile is the name of the selected profile. It is empty if no profile
+	// was used.
+	Profile string
+	LLM     LLM
+	Store   Store
+	Agent   Agent
+	Display Display
+	Tracing Tracing
+	Log     Log
+
+	// Sources records where each setting came from.
+	Sources map[string]Source
+}
+
+// LLM are the settings for the language model.
+type LLM struct {
+	Provider    string
+	Model       string
+	APIEndpoint string
+	ProjectID   string
+	MaxTokens   int
+	Temperature float64
+	TopK        int
+	TopP        float64
+}
+
+// Store are the settings for the task store.
+type Store struct {
+	// Backend is either "file" or "memory".
+	Backend string
+	// Path is where the file backend saves the tasks.
+	Path string
+	// TenantsDir is where the file backend saves each user's and team's
+	// tasks when serving several users.
+	TenantsDir string
+	// Teams are the members of each shared team list.
+	Teams map[string][]string
+}
+
+// Agent are the settings for running the agents.
+type Agent struct {
+	// Timeout is how long a single goal can run for. Zero means there isn't
+	// a deadline.
+	Timeout time.Duration
+	// MaxIterations is the maximum number of reasoning iterations each agent
+	// can go through for a goal.
+	MaxIterations int
+	// TokenBudget is roughly how many tokens a goal can use before it is
+	// stopped. Zero means there isn't a budget.
+	TokenBudget int
+}
+
+// Display are the settings for what is shown to the user.
+type Display struct {
+	// Format is either "terminal" or "json".
+	Format string
+	// SummaryChars is how much of what was shown to the user is given back
+	// to the agent. Zero means the agent is only told that something was
+	// shown.
+	SummaryChars int
+}
+
+// Tracing are the settings for exporting the OpenTelemetry spans.
+type Tracing struct {
+	// Exporter is "none", "stdout" or "otlp".
+	Exporter string
+	// Endpoint is the OTLP collector. If it is empty, the standard
+	// OTEL_EXPORTER_OTLP_* environment variables are used.
+	Endpoint string
+}
+
+// Log are the settings for the assistant's logs.
+type Log struct {
+	// Level is "prompt", "debug", "info", "warn" or "error".
+	Level string
+	// File is where the logs are also written as JSON. If it is empty, the
+	// logs are only written to stderr.
+	File string
+}
+
+// Source is where a setting came from.
+type Source string
+
+const (
+	// SourceDefault means the setting was not configured.
+	SourceDefault Source = "default"
+	// SourceProfile means the setting came from the config file.
+	SourceProfile Source = "profile"
+	// SourceEnv means the setting came from an environment variable.
+	SourceEnv Source = "env"
+	// SourceFlag means the setting came from a flag.
+	SourceFlag Source = "flag"
+)
+
+const (
+	// ProviderVertex uses Vertex AI.
+	ProviderVertex = "vertex"
+
+	// BackendFile saves the tasks to a JSON file.
+	BackendFile = "file"
+	// BackendMemory only keeps the tasks in memory.
+	BackendMemory = "memory"
+
+	// FormatTerminal shows the output as text.
+	FormatTerminal = "terminal"
+	// FormatJSON shows the output as a line of JSON.
+	FormatJSON = "json"
+
+	// ExporterNone doesn't export the spans.
+	ExporterNone = "none"
+	// ExporterStdout writes the spans as JSON.
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends the spans to an OpenTelemetry collector.
+	ExporterOTLP = "otlp"
+
+	// LevelPrompt logs the prompts sent to the LLM and everything below.
+	LevelPrompt = "prompt"
+	// LevelDebug logs the agents' thoughts and everything below.
+	LevelDebug = "debug"
+	// LevelInfo logs what the assistant is doing and everything below.
+	LevelInfo = "info"
+	// LevelWarn only logs the warnings and errors.
+	LevelWarn = "warn"
+	// LevelError only logs the errors.
+	LevelError = "error"
+)
+
+// Defaults returns the settings used when nothing else is configured.
+func Defaults(assistantDir string) Settings {
+	return Settings{
+		LLM: LLM{
+			Provider:    ProviderVertex,
+			Model:       "text-bison@001",
+			APIEndpoint: "us-central1-aiplatform.googleapis.com",
+			MaxTokens:   1024,
+			Temperature: 0.2,
+			TopK:        40,
+			TopP:        0.9,
+		},
+		Store: Store{
+			Backend:    BackendFile,
+			Path:       filepath.Join(assistantDir, "tasks.json"),
+			TenantsDir: filepath.Join(assistantDir, "tenants"),
+		},
+		Agent: Agent{
+			Timeout:       2 * time.Minute,
+			MaxIterations: 10,
+		},
+		Display: Display{
+			Format:       FormatTerminal,
+			SummaryChars: 1000,
+		},
+		Tracing: Tracing{
+			Exporter: ExporterNone,
+		},
+		Log: Log{
+			Level: LevelWarn,
+		},
+		Sources: map[string]Source{},
+	}
+}
+
+// Load reads the config file. A missing file is not an error.
+func Load(path string) (File, error) {
+	var f File
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return f, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Resolve layers the settings. The profile is chosen by the "profile" flag,
+// the ASSISTANT_PROFILE environment variable or the file's default profile.
+// The flags are only the ones the user explicitly set.
+func Resolve(
+	defaults Settings,
+	f File,
+	getenv func(string) string,
+	flags map[string]string,
+) (Settings, error) {
+	s := defaults
+	s.Sources = map[string]Source{}
+	for _, st := range settingsTable {
+		s.Sources[st.key] = SourceDefault
+	}
+
+	s.Profile = f.DefaultProfile
+	if v := getenv("ASSISTANT_PROFILE"); v != "" {
+		s.Profile = v
+	}
+	if v, ok := flags["profile"]; ok {
+		s.Profile = v
+	}
+
+	if s.Profile != "" {
+		p, ok := f.Profiles[s.Profile]
+		if !ok {
+			return Settings{}, fmt.Errorf("unknown profile %q", s.Profile)
+		}
+		for _, st := range settingsTable {
+			v, ok := st.fromProfile(p)
+			if !ok {
+				continue
+			}
+			if err := st.set(&s, v); err != nil {
+				return Settings{}, fmt.Errorf("profile %s: %s: %w", s.Profile, st.key, err)
+			}
+			s.Sources[st.key] = SourceProfile
+		}
+		// The teams can only be set in the config file.
+		if p.Store.Teams != nil {
+			s.Store.Teams = p.Store.Teams
+		}
+	}
+
+	for _, st := range settingsTable {
+		for _, env := range st.envs {
+			v := getenv(env)
+			if v == "" {
+				continue
+			}
+			if err := st.set(&s, v); err != nil {
+				return Settings{}, fmt.Errorf("%s: %w", env, err)
+			}
+			s.Sources[st.key] = SourceEnv
+			break
+		}
+	}
+
+	for _, st := range settingsTable {
+		v, ok := flags[st.flag]
+		if !ok {
+			continue
+		}
+		if err := st.set(&s, v); err != nil {
+			return Settings{}, fmt.Errorf("-%s: %w", st.flag, err)
+		}
+		s.Sources[st.key] = SourceFlag
+	}
+
+	if err := s.validate(); err != nil {
+		return Settings{}, err
+	}
+	return s, nil
+}
+
+func (s Settings) validate() error {
+	if s.LLM.Provider != ProviderVertex {
+		return fmt.Errorf("unsupported LLM provider %q", s.LLM.Provider)
+	}
+	switch s.Store.Backend {
+	case BackendFile:
+		if s.Store.Path == "" {
+			return fmt.Errorf("the %s store backend requires a path", BackendFile)
+		}
+	case BackendMemory:
+	default:
+		return fmt.Errorf("unsupported store backend %q", s.Store.Backend)
+	}
+	if s.Agent.Timeout < 0 {
+		return fmt.Errorf("agent.timeout can't be negative")
+	}
+	if s.Agent.MaxIterations < 0 {
+		return fmt.Errorf("agent.max_iterations can't be negative")
+	}
+	if s.Agent.TokenBudget < 0 {
+		return fmt.Errorf("agent.token_budget can't be negative")
+	}
+	switch s.Display.Format {
+	case FormatTerminal, FormatJSON:
+	default:
+		return fmt.Errorf("unsupported display format %q", s.Display.Format)
+	}
+	if s.Display.SummaryChars < 0 {
+		return fmt.Errorf("display.summary_chars can't be negative")
+	}
+	switch s.Tracing.Exporter {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+	default:
+		return fmt.Errorf("unsupported trace exporter %q", s.Tracing.Exporter)
+	}
+	switch s.Log.Level {
+	case LevelPrompt, LevelDebug, LevelInfo, LevelWarn, LevelError:
+	default:
+		return fmt.Errorf("unsupported log level %q", s.Log.Level)
+	}
+	return nil
+}
+
+// Entry is a single setting, used to show the effective settings.
+type Entry struct {
+	Key    string
+	Value  string
+	Source Source
+}
+
+// Entries returns the settings sorted by key.
+func (s Settings) Entries() []Entry {
+	var entries []Entry
+	for _, st := range settingsTable {
+		entries = append(entries, Entry{
+			Key:    st.key,
+			Value:  st.get(s),
+			Source: s.Sources[st.key],
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// setting describes how a single setting is configured in each layer.
+type setting struct {
+	key         string
+	flag        string
+	envs        []string
+	fromProfile func(Profile) (string, bool)
+	get         func(Settings) string
+	set         func(*Settings, string) error
+}
+
+var settingsTable = []setting{
+	stringSetting("llm.provider", "llm-provider", []string{"ASSISTANT_LLM_PROVIDER"},
+		func(p Profile) *string { return p.LLM.Provider },
+		func(s *Settings) *string { return &s.LLM.Provider }),
+	stringSetting("llm.model", "model", []string{"ASSISTANT_MODEL"},
+		func(p Profile) *string { return p.LLM.Model },
+		func(s *Settings) *string { return &s.LLM.Model }),
+	stringSetting("llm.api_endpoint", "api-endpoint", []string{"ASSISTANT_API_ENDPOINT"},
+		func(p Profile) *string { return p.LLM.APIEndpoint },
+		func(s *Settings) *string { return &s.LLM.APIEndpoint }),
+	stringSetting("llm.project_id", "project-id", []string{"ASSISTANT_PROJECT_ID", "GCP_PROJECT_ID"},
+		func(p Profile) *string { return p.LLM.ProjectID },
+		func(s *Settings) *string { return &s.LLM.ProjectID }),
+	intSetting("llm.max_tokens", "max-tokens", []string{"ASSISTANT_MAX_TOKENS"},
+		func(p Profile) *int { return p.LLM.MaxTokens },
+		func(s *Settings) *int { return &s.LLM.MaxTokens }),
+	floatSetting("llm.temperature", "temperature", []string{"ASSISTANT_TEMPERATURE"},
+		func(p Profile) *float64 { return p.LLM.Temperature },
+		func(s *Settings) *float64 { return &s.LLM.Temperature }),
+	intSetting("llm.top_k", "top-k", []string{"ASSISTANT_TOP_K"},
+		func(p Profile) *int { return p.LLM.TopK },
+		func(s *Settings) *int { return &s.LLM.TopK }),
+	floatSetting("llm.top_p", "top-p", []string{"ASSISTANT_TOP_P"},
+		func(p Profile) *float64 { return p.LLM.TopP },
+		func(s *Settings) *float64 { return &s.LLM.TopP }),
+	stringSetting("store.backend", "store-backend", []string{"ASSISTANT_STORE_BACKEND"},
+		func(p Profile) *string { return p.Store.Backend },
+		func(s *Settings) *string { return &s.Store.Backend }),
+	stringSetting("store.path", "store-path", []string{"ASSISTANT_STORE_PATH"},
+		func(p Profile) *string { return p.Store.Path },
+		func(s *Settings) *string { return &s.Store.Path }),
+	stringSetting("store.tenants_dir", "tenants-dir", []string{"ASSISTANT_TENANTS_DIR"},
+		func(p Profile) *string { return p.Store.TenantsDir },
+		func(s *Settings) *string { return &s.Store.TenantsDir }),
+	durationSetting("agent.timeout", "timeout", []string{"ASSISTANT_TIMEOUT"},
+		func(p Profile) *string { return p.Agent.Timeout },
+		func(s *Settings) *time.Duration { return &s.Agent.Timeout }),
+	intSetting("agent.max_iterations", "max-iterations", []string{"ASSISTANT_MAX_ITERATIONS"},
+		func(p Profile) *int { return p.Agent.MaxIterations },
+		func(s *Settings) *int { return &s.Agent.MaxIterations }),
+	intSetting("agent.token_budget", "token-budget", []string{"ASSISTANT_TOKEN_BUDGET"},
+		func(p Profile) *int { return p.Agent.TokenBudget },
+		func(s *Settings) *int { return &s.Agent.TokenBudget }),
+	stringSetting("display.format", "output", []string{"ASSISTANT_OUTPUT"},
+		func(p Profile) *string { return p.Display.Format },
+		func(s *Settings) *string { return &s.Display.Format }),
+	intSetting("display.summary_chars", "summary-chars", []string{"ASSISTANT_SUMMARY_CHARS"},
+		func(p Profile) *int { return p.Display.SummaryChars },
+		func(s *Settings) *int { return &s.Display.SummaryChars }),
+	stringSetting("tracing.exporter", "trace-exporter", []string{"ASSISTANT_TRACE_EXPORTER"},
+		func(p Profile) *string { return p.Tracing.Exporter },
+		func(s *Settings) *string { return &s.Tracing.Exporter }),
+	stringSetting("tracing.endpoint", "trace-endpoint", []string{"ASSISTANT_TRACE_ENDPOINT"},
+		func(p Profile) *string { return p.Tracing.Endpoint },
+		func(s *Settings) *string { return &s.Tracing.Endpoint }),
+	stringSetting("log.level", "log-level", []string{"ASSISTANT_LOG_LEVEL"},
+		func(p Profile) *string { return p.Log.Level },
+		func(s *Settings) *string { return &s.Log.Level }),
+	stringSetting("log.file", "log-file", []string{"ASSISTANT_LOG_FILE"},
+		func(p Profile) *string { return p.Log.File },
+		func(s *Settings) *string { return &s.Log.File }),
+}
+
+func stringSetting(
+	key, flag string,
+	envs []string,
+	fromProfile func(Profile) *string,
+	field func(*Settings) *string,
+) setting {
+	return setting{
+		key:  key,
+		flag: flag,
+		envs: envs,
+		fromProfile: func(p Profile) (string, bool) {
+			v := fromProfile(p)
+			if v == nil {
+				return "", false
+			}
+			return *v, true
+		},
+		get: func(s Settings) string {
+			return *field(&s)
+		},
+		set: func(s *Settings, v string) error {
+			*field(s) = expandHome(v)
+			return nil
+		},
+	}
+}
+
+func intSetting(
+	key, flag string,
+	envs []string,
+	fromProfile func(Profile) *int,
+	field func(*Settings) *int,
+) setting {
+	return setting{
+		key:  key,
+		flag: flag,
+		envs: envs,
+		fromProfile: func(p Profile) (string, bool) {
+			v := fromProfile(p)
+			if v == nil {
+				return "", false
+			}
+			return strconv.Itoa(*v), true
+		},
+		get: func(s Settings) string {
+			return strconv.Itoa(*field(&s))
+		},
+		set: func(s *Settings, v string) error {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid integer %q", v)
+			}
+			*field(s) = i
+			return nil
+		},
+	}
+}
+
+func floatSetting(
+	key, flag string,
+	envs []string,
+	fromProfile func(Profile) *float64,
+	field func(*Settings) *float64,
+) setting {
+	return setting{
+		key:  key,
+		flag: flag,
+		envs: envs,
+		fromProfile: func(p Profile) (string, bool) {
+			v := fromProfile(p)
+			if v == nil {
+				return "", false
+			}
+			return strconv.FormatFloat(*v, 'g', -1, 64), true
+		},
+		get: func(s Settings) string {
+			return strconv.FormatFloat(*field(&s), 'g', -1, 64)
+		},
+		set: func(s *Settings, v string) error {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", v)
+			}
+			*field(s) = f
+			return nil
+		},
+	}
+}
+
+func durationSetting(
+	key, flag string,
+	envs []string,
+	fromProfile func(Profile) *string,
+	field func(*Settings) *time.Duration,
+) setting {
+	return setting{
+		key:  key,
+		flag: flag,
+		envs: envs,
+		fromProfile: func(p Profile) (string, bool) {
+			v := fromProfile(p)
+			if v == nil {
+				return "", false
+			}
+			return *v, true
+		},
+		get: func(s Settings) string {
+			return field(&s).String()
+		},
+		set: func(s *Settings, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", v)
+			}
+			*field(s) = d
+			return nil
+		},
+	}
+}
+
+// expandHome replaces a leading ~/ with the user's home directory.
+func expandHome(p string) string {
+	if !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[2:])
+}
@@ -0,0 +1,214 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/poy/assistant/pkg/config"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	const file = `
+default_profile: personal
+profiles:
+  personal:
+    llm:
+      model: personal-model
+      temperature: 0
+  work:
+    llm:
+      model: work-model
+      top_k: 7
+    store:
+      backend: memory
+      teams:
+        platform: [alice, bob]
+    agent:
+      timeout: 30s
+`
+
+	testCases := []struct {
+		name   string
+		env    map[string]string
+		flags  map[string]string
+		assert func(*testing.T, config.Settings, error)
+	}{
+		{
+			name: "uses the default profile",
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := s.Profile, "personal"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.LLM.Model, "personal-model"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.LLM.Temperature, 0.0; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+				if actual, expected := s.Sources["llm.temperature"], config.SourceProfile; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.LLM.TopK, 40; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := s.Sources["llm.top_k"], config.SourceDefault; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "env selects the profile",
+			env:  map[string]string{"ASSISTANT_PROFILE": "work"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := s.LLM.Model, "work-model"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.Store.Backend, config.BackendMemory; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.Agent.Timeout, 30*time.Second; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+				if actual, expected := len(s.Store.Teams["platform"]), 2; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "flag beats env beats profile",
+			env:   map[string]string{"ASSISTANT_PROFILE": "work", "ASSISTANT_MODEL": "env-model", "ASSISTANT_TOP_K": "3"},
+			flags: map[string]string{"profile": "personal", "model": "flag-model"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := s.Profile, "personal"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.LLM.Model, "flag-model"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.Sources["llm.model"], config.SourceFlag; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.LLM.TopK, 3; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := s.Sources["llm.top_k"], config.SourceEnv; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "falls back to GCP_PROJECT_ID",
+			env:  map[string]string{"GCP_PROJECT_ID": "some-project"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := s.LLM.ProjectID, "some-project"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "unknown profile",
+			flags: map[string]string{"profile": "unknown"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+		{
+			name: "invalid value",
+			env:  map[string]string{"ASSISTANT_MAX_TOKENS": "lots"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+		{
+			name:  "invalid duration",
+			flags: map[string]string{"timeout": "soon"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+		{
+			name: "negative token budget",
+			env:  map[string]string{"ASSISTANT_TOKEN_BUDGET": "-1"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+		{
+			name:  "unsupported trace exporter",
+			flags: map[string]string{"trace-exporter": "carrier-pigeon"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+		{
+			name: "unsupported log level",
+			env:  map[string]string{"ASSISTANT_LOG_LEVEL": "chatty"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+		{
+			name:  "unsupported store backend",
+			flags: map[string]string{"store-backend": "carrier-pigeon"},
+			assert: func(t *testing.T, s config.Settings, err error) {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			p := filepath.Join(dir, "config.yaml")
+			if err := os.WriteFile(p, []byte(file), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := config.Load(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := config.Resolve(
+				config.Defaults(dir),
+				f,
+				func(k string) string { return tc.env[k] },
+				tc.flags,
+			)
+			tc.assert(t, s, err)
+		})
+	}
+}
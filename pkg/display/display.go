@@ -0,0 +1,170 @@
+// Package display shows output to the user. Tools use it instead of printing
+// so that the output can go to a terminal, be encoded as JSON or be captured,
+// and so the agents get a summary of what the user saw.
+package display
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// Output is something shown to the user.
+type Output struct {
+	// Kind says what the output is (e.g., task-list).
+	Kind string `json:"kind"`
+	// Text is the human readable version of the output.
+	Text string `json:"-"`
+	// Data is the structured version of the output.
+	Data any `json:"data,omitempty"`
+}
+
+// Sink shows the Output to the user.
+type Sink interface {
+	// Show shows the output to the user.
+	Show(ctx context.Context, o Output) error
+}
+
+// SummaryLimit is the maximum number of characters of the output that are
+// given back to the agent. Zero means the agent is only told that something
+// was shown.
+type SummaryLimit int
+
+// DefaultSummaryLimit is used when the SummaryLimit is not provided.
+const DefaultSummaryLimit = 1000
+
+// ProvideSink shows the output with the given Sink.
+func ProvideSink(s Sink) {
+	injection.Register[Sink](
+		func(ctx context.Context) Sink {
+			return s
+		},
+	)
+}
+
+// ProvideSummaryLimit sets how much of the output is given to the agent.
+func ProvideSummaryLimit(n int) {
+	injection.Register[SummaryLimit](
+		func(ctx context.Context) SummaryLimit {
+			return SummaryLimit(n)
+		},
+	)
+}
+
+func init() {
+	ProvideSink(NewTerminal(os.Stdout))
+	ProvideSummaryLimit(DefaultSummaryLimit)
+
+	injection.Register[*Displayer](
+		func(ctx context.Context) *Displayer {
+			return &Displayer{
+				sink:  injection.Resolve[Sink](ctx),
+				limit: int(injection.Resolve[SummaryLimit](ctx)),
+			}
+		},
+	)
+}
+
+type sinkKey struct{}
+
+// WithSink returns a context that shows the output with the given Sink.
+func WithSink(ctx context.Context, s Sink) context.Context {
+	return context.WithValue(ctx, sinkKey{}, s)
+}
+
+// Displayer shows output to the user and summarizes it for the agent.
+type Displayer struct {
+	sink  Sink
+	limit int
+}
+
+// Display shows the output to the user and returns the observation for the
+// agent.
+func (d *Displayer) Display(ctx context.Context, o Output) (string, error) {
+	sink := d.sink
+	if s, ok := ctx.Value(sinkKey{}).(Sink); ok {
+		sink = s
+	}
+	if err := sink.Show(ctx, o); err != nil {
+		return "", fmt.Errorf("failed to display %s: %w", o.Kind, err)
+	}
+	return Summarize(o, d.limit), nil
+}
+
+// Summarize returns what the agent is told about the output. The text is
+// truncated to the limit.
+func Summarize(o Output, limit int) string {
+	if limit <= 0 {
+		return fmt.Sprintf("Displayed the %s to the user", strings.ReplaceAll(o.Kind, "-", " "))
+	}
+
+	text := strings.TrimSpace(o.Text)
+	if r := []rune(text); len(r) > limit {
+		text = string(r[:limit]) + "... (truncated)"
+	}
+	return fmt.Sprintf("Displayed the following to the user:\n%s", text)
+}
+
+// Terminal writes the text of the output.
+type Terminal struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTerminal returns a Sink that writes the text of the output to w.
+func NewTerminal(w io.Writer) *Terminal {
+	return &Terminal{w: w}
+}
+
+// Show implements Sink.
+func (t *Terminal) Show(ctx context.Context, o Output) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := fmt.Fprintln(t.w, o.Text)
+	return err
+}
+
+// JSON writes each output as a line of JSON.
+type JSON struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSON returns a Sink that encodes the output to w.
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{enc: json.NewEncoder(w)}
+}
+
+// Show implements Sink.
+func (j *JSON) Show(ctx context.Context, o Output) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.enc.Encode(o)
+}
+
+// Buffer captures the output. It is useful for tests.
+type Buffer struct {
+	mu      sync.Mutex
+	outputs []Output
+}
+
+// Show implements Sink.
+func (b *Buffer) Show(ctx context.Context, o Output) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.outputs = append(b.outputs, o)
+	return nil
+}
+
+// Outputs returns everything that was shown.
+func (b *Buffer) Outputs() []Output {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Output(nil), b.outputs...)
+}
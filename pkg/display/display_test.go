@@ -0,0 +1,92 @@
+package display_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/poy/assistant/pkg/display"
+)
+
+var output = display.Output{
+	Kind: "task-list",
+	Text: "* buy groceries\n* clean the garage",
+	Data: []string{"buy groceries", "clean the garage"},
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		limit    int
+		expected string
+	}{
+		{
+			name:     "returns the text",
+			limit:    100,
+			expected: "Displayed the following to the user:\n* buy groceries\n* clean the garage",
+		},
+		{
+			name:     "truncates the text",
+			limit:    15,
+			expected: "Displayed the following to the user:\n* buy groceries... (truncated)",
+		},
+		{
+			name:     "only says what was shown",
+			limit:    0,
+			expected: "Displayed the task list to the user",
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if actual := display.Summarize(output, tc.limit); actual != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSinks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		sink     func(*bytes.Buffer) display.Sink
+		expected string
+	}{
+		{
+			name: "terminal writes the text",
+			sink: func(b *bytes.Buffer) display.Sink {
+				return display.NewTerminal(b)
+			},
+			expected: "* buy groceries\n* clean the garage\n",
+		},
+		{
+			name: "json writes the data",
+			sink: func(b *bytes.Buffer) display.Sink {
+				return display.NewJSON(b)
+			},
+			expected: `{"kind":"task-list","data":["buy groceries","clean the garage"]}` + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var b bytes.Buffer
+			if err := tc.sink(&b).Show(context.Background(), output); err != nil {
+				t.Fatal(err)
+			}
+			if actual := b.String(); actual != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
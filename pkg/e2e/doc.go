@@ -0,0 +1,4 @@
+// Package e2e has end-to-end tests of the agents. The LLM is replayed from
+// the cassettes in testdata (see the cassette package), so the tests run
+// offline and fail when the prompts change.
+package e2e
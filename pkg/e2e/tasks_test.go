@@ -0,0 +1,83 @@
+package e2e_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/poy/assistant/pkg/display"
+	assistanttesting "github.com/poy/assistant/pkg/testing"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func TestTaskAgent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		goal   string
+		setup  func(s tasks.Store)
+		assert func(t *testing.T, answer string, s tasks.Store)
+	}{
+		{
+			name: "add-note",
+			goal: "add a note to the grocery task to buy eggs",
+			setup: func(s tasks.Store) {
+				s.Add("hire nanny", "")
+				s.Add("buy groceries", "milk and bread")
+			},
+			assert: func(t *testing.T, answer string, s tasks.Store) {
+				notes := s.GetTask("buy groceries").Notes()
+				if len(notes) != 1 {
+					t.Fatalf("expected a note, got %d", len(notes))
+				}
+				if actual, expected := notes[0].Note(), "buy eggs"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if len(s.GetTask("hire nanny").Notes()) != 0 {
+					t.Fatal("expected the other task to be left alone")
+				}
+				if !strings.Contains(answer, "buy eggs") {
+					t.Fatalf("unexpected answer %q", answer)
+				}
+			},
+		},
+		{
+			name: "complete",
+			goal: "I hired the nanny",
+			setup: func(s tasks.Store) {
+				s.Add("hire nanny", "")
+				s.Add("buy groceries", "milk and bread")
+			},
+			assert: func(t *testing.T, answer string, s tasks.Store) {
+				if !s.GetTask("hire nanny").Completed() {
+					t.Fatal("expected the task to be completed")
+				}
+				if s.GetTask("buy groceries").Completed() {
+					t.Fatal("expected the other task to be left alone")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := assistanttesting.WithCassette(context.Background(), t, filepath.Join("testdata", tc.name+".json"))
+			ctx = injection.WithInjection(ctx)
+
+			s := injection.Resolve[tasks.Store](ctx)
+			tc.setup(s)
+
+			answer, err := injection.Resolve[tasks.TaskAgent](ctx).Run(display.WithSink(ctx, &display.Buffer{}), tc.goal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.assert(t, answer, s)
+		})
+	}
+}
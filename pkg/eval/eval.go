@@ -0,0 +1,160 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/display"
+	assistanttools "github.com/poy/assistant/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/assistant/pkg/tools/userinput"
+	"github.com/poy/assistant/pkg/usage"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// Options configure Run.
+type Options struct {
+	// Model is the name of the model in the Report.
+	Model string
+	// Timeout is how long each thing the user says can run for. Zero means
+	// there isn't a limit.
+	Timeout time.Duration
+}
+
+// Report is how the suite went.
+type Report struct {
+	Suite     string           `json:"suite"`
+	Model     string           `json:"model"`
+	Scenarios []ScenarioResult `json:"scenarios"`
+	// Usage is the usage per agent and tool, over every scenario.
+	Usage []usage.Entry `json:"usage"`
+}
+
+// ScenarioResult is how a scenario went.
+type ScenarioResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	// Failures are the differences from what was expected, and the errors.
+	Failures []string `json:"failures,omitempty"`
+	// Steps is how many times the LLM was called.
+	Steps   int           `json:"steps"`
+	Latency time.Duration `json:"latency"`
+	// Usage is the usage per agent and tool.
+	Usage []usage.Entry `json:"usage"`
+}
+
+// Accuracy is the fraction of the scenarios that passed.
+func (r Report) Accuracy() float64 {
+	if len(r.Scenarios) == 0 {
+		return 0
+	}
+	var passed int
+	for _, s := range r.Scenarios {
+		if s.Passed {
+			passed++
+		}
+	}
+	return float64(passed) / float64(len(r.Scenarios))
+}
+
+// Run runs each scenario in the suite with the LLM. Each scenario gets its
+// own in-memory store, and whatever is displayed is thrown away.
+func Run(ctx context.Context, suite *Suite, llm llms.LLM[vertex.Params], opts Options) Report {
+	r := Report{
+		Suite: suite.Name,
+		Model: opts.Model,
+	}
+	total := usage.NewTracker(0)
+	for _, sc := range suite.Scenarios {
+		result := runScenario(ctx, sc, llm, opts)
+		for _, e := range result.Usage {
+			total.Add(e.Agent, e.Tool, e.Usage)
+		}
+		r.Scenarios = append(r.Scenarios, result)
+	}
+	r.Usage = total.Entries()
+	return r
+}
+
+func runScenario(ctx context.Context, sc Scenario, llm llms.LLM[vertex.Params], opts Options) ScenarioResult {
+	result := ScenarioResult{Name: sc.Name}
+	s := tasks.NewStore("")
+	tracker := usage.NewTracker(0)
+
+	ctx = tasks.WithStore(ctx, s)
+	ctx = assistanttools.WithLLM(ctx, llm)
+	ctx = usage.WithTracker(ctx, tracker)
+	ctx = injection.WithInjection(ctx)
+	ctx = display.WithSink(ctx, &display.Buffer{})
+	ctx = userinput.WithAsker(ctx, userinput.NewScripted(sc.Answers...))
+
+	if err := seed(s, sc.Tasks); err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("failed to set up the tasks: %v", err))
+		return result
+	}
+
+	agent := injection.Resolve[tasks.TaskAgent](ctx)
+	start := time.Now()
+	for _, goal := range sc.Say {
+		if err := runGoal(ctx, agent, goal, opts.Timeout); err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("%q failed: %v", goal, err))
+		}
+	}
+	result.Latency = time.Since(start)
+
+	result.Failures = append(result.Failures, check(s, sc.Expect)...)
+	result.Passed = len(result.Failures) == 0
+	result.Usage = tracker.Entries()
+	result.Steps = tracker.Total().Calls
+	return result
+}
+
+func runGoal(ctx context.Context, agent tasks.TaskAgent, goal string, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	_, err := agent.Run(ctx, goal)
+	return err
+}
+
+// Print writes the report for a person to read.
+func (r Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "SCENARIO\tRESULT\tSTEPS\tLATENCY\n")
+	for _, s := range r.Scenarios {
+		result := "pass"
+		if !s.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", s.Name, result, s.Steps, s.Latency.Round(time.Millisecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, s := range r.Scenarios {
+		for _, f := range s.Failures {
+			fmt.Fprintf(w, "  %s: %s\n", s.Name, f)
+		}
+	}
+
+	model := r.Model
+	if model == "" {
+		model = "the default model"
+	}
+	var passed int
+	for _, s := range r.Scenarios {
+		if s.Passed {
+			passed++
+		}
+	}
+	fmt.Fprintf(w, "\n%s with %s: %d/%d passed (%.0f%%)\n\n", r.Suite, model, passed, len(r.Scenarios), 100*r.Accuracy())
+
+	return usage.WriteSummary(w, r.Usage)
+}
@@ -0,0 +1,202 @@
+package eval_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/poy/assistant/pkg/eval"
+	assistanttesting "github.com/poy/assistant/pkg/testing"
+)
+
+const suite = `
+name: tasks
+scenarios:
+  - name: complete
+    tasks:
+      - name: hire nanny
+      - name: buy groceries
+        notes: [milk]
+    say:
+      - I hired the nanny
+    expect:
+      tasks:
+        - name: hire nanny
+          completed: true
+        - name: buy groceries
+          completed: false
+          notes: [MILK]
+          due: none
+      absent: [walk the dog]
+      count: 2
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		suite     string
+		wantError string
+	}{
+		{
+			name:  "valid",
+			suite: suite,
+		},
+		{
+			name:      "no scenarios",
+			suite:     "name: empty",
+			wantError: "doesn't have any scenarios",
+		},
+		{
+			name:      "no name",
+			suite:     "scenarios: [{say: [hi]}]",
+			wantError: "scenario 1 doesn't have a name",
+		},
+		{
+			name:      "nothing said",
+			suite:     "scenarios: [{name: quiet}]",
+			wantError: `scenario "quiet" doesn't say anything`,
+		},
+		{
+			name:      "invalid due date",
+			suite:     "scenarios: [{name: due, say: [hi], tasks: [{name: a, due: whenever}]}]",
+			wantError: `task "a"`,
+		},
+		{
+			name:      "invalid expected due date",
+			suite:     "scenarios: [{name: due, say: [hi], expect: {tasks: [{name: a, due: tomorrow}]}}]",
+			wantError: "must be YYYY-MM-DD or none",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // Avoid issues with closure.
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s, err := eval.Parse([]byte(tc.suite))
+			if tc.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+					t.Fatalf("expected an error with %q, got %v", tc.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := len(s.Scenarios), 1; actual != expected {
+				t.Fatalf("expected %d scenarios, got %d", expected, actual)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	completes := func() *assistanttesting.ScriptedLLM {
+		return assistanttesting.NewScriptedLLM(
+			assistanttesting.Action("I should use the modify tool", "modify", "I hired the nanny"),
+			assistanttesting.Action("I should use the complete tool", "complete", "hire nanny"),
+			assistanttesting.Final("Completed hire nanny"),
+			assistanttesting.Final("Done"),
+		).On("TaskFinder", "", assistanttesting.Final("hire nanny"))
+	}
+
+	testCases := []struct {
+		name   string
+		llm    *assistanttesting.ScriptedLLM
+		assert func(t *testing.T, r eval.Report)
+	}{
+		{
+			name: "passes",
+			llm:  completes(),
+			assert: func(t *testing.T, r eval.Report) {
+				if actual, expected := r.Accuracy(), 1.0; actual != expected {
+					t.Fatalf("expected an accuracy of %v, got %v: %+v", expected, actual, r.Scenarios)
+				}
+				if actual, expected := r.Scenarios[0].Steps, 6; actual != expected {
+					t.Fatalf("expected %d steps, got %d", expected, actual)
+				}
+				calls := map[string]int{}
+				for _, e := range r.Usage {
+					calls[e.Agent+"/"+e.Tool] = e.Calls
+					if e.PromptTokens == 0 || e.CompletionTokens == 0 {
+						t.Fatalf("expected the tokens to be estimated, got %+v", e)
+					}
+				}
+				// The finder runs in the modify and complete tools.
+				if calls["Tasks/"] != 2 || calls["TaskFinder/modify"] != 1 || calls["TaskFinder/complete"] != 1 || calls["TaskModifier/modify"] != 2 {
+					t.Fatalf("expected the calls per agent and tool, got %+v", r.Usage)
+				}
+			},
+		},
+		{
+			name: "the store isn't what was expected",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Final("Done"),
+			),
+			assert: func(t *testing.T, r eval.Report) {
+				if actual, expected := r.Accuracy(), 0.0; actual != expected {
+					t.Fatalf("expected an accuracy of %v, got %v", expected, actual)
+				}
+				if actual, expected := strings.Join(r.Scenarios[0].Failures, "\n"), "hire nanny: expected completed to be true"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "the agent fails",
+			llm:  assistanttesting.NewScriptedLLM(),
+			assert: func(t *testing.T, r eval.Report) {
+				failures := r.Scenarios[0].Failures
+				if len(failures) != 2 || !strings.Contains(failures[0], `"I hired the nanny" failed`) {
+					t.Fatalf("expected the agent's error and the store's difference, got %q", failures)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // Avoid issues with closure.
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s, err := eval.Parse([]byte(suite))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := assistanttesting.WithLLM(context.Background(), tc.llm)
+			tc.assert(t, eval.Run(ctx, s, tc.llm, eval.Options{Model: "fake"}))
+		})
+	}
+}
+
+func TestReportPrint(t *testing.T) {
+	t.Parallel()
+
+	s, err := eval.Parse([]byte(suite))
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := assistanttesting.NewScriptedLLM(assistanttesting.Final("Done"))
+	r := eval.Run(assistanttesting.WithLLM(context.Background(), llm), s, llm, eval.Options{Model: "fake"})
+
+	var buf bytes.Buffer
+	if err := r.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"complete  FAIL", "complete: hire nanny: expected completed to be true", "tasks with fake: 0/1 passed (0%)", "Tasks  -     1"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestSampleSuite(t *testing.T) {
+	t.Parallel()
+
+	if _, err := eval.Load("../../evals/tasks.yaml"); err != nil {
+		t.Fatal(err)
+	}
+}
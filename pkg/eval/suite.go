@@ -0,0 +1,221 @@
+// Package eval measures how well the agents do on a suite of scenarios, so
+// changes to the preambles, examples or model can be compared.
+//
+// A scenario starts with some tasks, says something to the assistant (and
+// answers its questions) and then checks the tasks it ended up with. See
+// Suite for the YAML format.
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of scenarios. For example:
+//
+//	name: tasks
+//	scenarios:
+//	  - name: add a note
+//	    tasks:
+//	      - name: buy groceries
+//	    say:
+//	      - add a note to the grocery task to buy eggs
+//	    expect:
+//	      tasks:
+//	        - name: buy groceries
+//	          notes: [eggs]
+type Suite struct {
+	Name      string     `yaml:"name"`
+	Scenarios []Scenario `yaml:"scenarios"`
+}
+
+// Scenario is a conversation with the assistant and what the tasks should
+// look like afterwards.
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Tasks are in the store before the scenario starts.
+	Tasks []TaskState `yaml:"tasks"`
+	// Say is what the user says, in order. Each is a separate goal.
+	Say []string `yaml:"say"`
+	// Answers are given, in order, when the assistant asks the user
+	// something.
+	Answers []string `yaml:"answers"`
+	Expect  Expect   `yaml:"expect"`
+}
+
+// TaskState is a task at the start of a scenario.
+type TaskState struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Notes       []string `yaml:"notes"`
+	Completed   bool     `yaml:"completed"`
+	// Due is parsed with tasks.ParseDue.
+	Due      string `yaml:"due"`
+	Assignee string `yaml:"assignee"`
+}
+
+// Expect is what the store should look like at the end of a scenario.
+type Expect struct {
+	Tasks []TaskExpectation `yaml:"tasks"`
+	// Absent are the tasks that shouldn't be in the store.
+	Absent []string `yaml:"absent"`
+	// Count is how many tasks there should be. It isn't checked if it is nil.
+	Count *int `yaml:"count"`
+}
+
+// TaskExpectation is what a task should look like. Only the fields that are
+// set are checked, and the text is compared without regard to case.
+type TaskExpectation struct {
+	Name string `yaml:"name"`
+	// Description has to be in the description.
+	Description string `yaml:"description"`
+	// Notes each have to be in one of the notes.
+	Notes     []string `yaml:"notes"`
+	Completed *bool    `yaml:"completed"`
+	// Due is the day (YYYY-MM-DD) the task is due, or "none".
+	Due      string  `yaml:"due"`
+	Assignee *string `yaml:"assignee"`
+}
+
+// Load reads the suite from the file.
+func Load(p string) (*Suite, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return s, nil
+}
+
+// Parse decodes and validates the suite.
+func Parse(data []byte) (*Suite, error) {
+	var s Suite
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode suite: %w", err)
+	}
+	if len(s.Scenarios) == 0 {
+		return nil, errors.New("the suite doesn't have any scenarios")
+	}
+	for i, sc := range s.Scenarios {
+		if sc.Name == "" {
+			return nil, fmt.Errorf("scenario %d doesn't have a name", i+1)
+		}
+		if len(sc.Say) == 0 {
+			return nil, fmt.Errorf("scenario %q doesn't say anything", sc.Name)
+		}
+		for _, t := range sc.Tasks {
+			if t.Name == "" {
+				return nil, fmt.Errorf("scenario %q has a task without a name", sc.Name)
+			}
+			if t.Due != "" {
+				if _, err := tasks.ParseDue(t.Due); err != nil {
+					return nil, fmt.Errorf("scenario %q: task %q: %w", sc.Name, t.Name, err)
+				}
+			}
+		}
+		for _, t := range sc.Expect.Tasks {
+			if t.Name == "" {
+				return nil, fmt.Errorf("scenario %q expects a task without a name", sc.Name)
+			}
+			if t.Due != "" && !strings.EqualFold(t.Due, "none") {
+				if _, err := time.Parse("2006-01-02", t.Due); err != nil {
+					return nil, fmt.Errorf("scenario %q: task %q: the due date must be YYYY-MM-DD or none: %w", sc.Name, t.Name, err)
+				}
+			}
+		}
+	}
+	return &s, nil
+}
+
+// seed adds the tasks to the store.
+func seed(s tasks.Store, states []TaskState) error {
+	for _, st := range states {
+		s.Add(st.Name, st.Description)
+		t := s.GetTask(st.Name)
+		if len(st.Notes) > 0 {
+			t.AddNotes(st.Notes...)
+		}
+		if st.Due != "" {
+			due, err := tasks.ParseDue(st.Due)
+			if err != nil {
+				return err
+			}
+			if err := t.SetDue(due); err != nil {
+				return err
+			}
+		}
+		if st.Assignee != "" {
+			if err := t.Assign("", st.Assignee); err != nil {
+				return err
+			}
+		}
+		if st.Completed {
+			t.Complete()
+		}
+	}
+	return nil
+}
+
+// check returns how the store differs from what is expected.
+func check(s tasks.Store, e Expect) []string {
+	var failures []string
+	for _, te := range e.Tasks {
+		t := s.GetTask(te.Name)
+		if t == nil {
+			failures = append(failures, fmt.Sprintf("expected task %q", te.Name))
+			continue
+		}
+		d := t.Data()
+		if te.Description != "" && !containsFold(d.Description, te.Description) {
+			failures = append(failures, fmt.Sprintf("%s: expected the description to have %q, got %q", te.Name, te.Description, d.Description))
+		}
+		for _, want := range te.Notes {
+			found := false
+			for _, n := range d.Notes {
+				found = found || containsFold(n.Note, want)
+			}
+			if !found {
+				failures = append(failures, fmt.Sprintf("%s: expected a note with %q", te.Name, want))
+			}
+		}
+		if te.Completed != nil && *te.Completed != !d.CompletedAt.IsZero() {
+			failures = append(failures, fmt.Sprintf("%s: expected completed to be %v", te.Name, *te.Completed))
+		}
+		if te.Due != "" {
+			got := "none"
+			if !d.Due.IsZero() {
+				got = d.Due.Format("2006-01-02")
+			}
+			if !strings.EqualFold(got, te.Due) {
+				failures = append(failures, fmt.Sprintf("%s: expected to be due %s, got %s", te.Name, te.Due, got))
+			}
+		}
+		if te.Assignee != nil && !strings.EqualFold(d.Assignee, *te.Assignee) {
+			failures = append(failures, fmt.Sprintf("%s: expected to be assigned to %q, got %q", te.Name, *te.Assignee, d.Assignee))
+		}
+	}
+	for _, name := range e.Absent {
+		if s.GetTask(name) != nil {
+			failures = append(failures, fmt.Sprintf("expected task %q to not exist", name))
+		}
+	}
+	if e.Count != nil {
+		if n := len(s.TaskNames()); n != *e.Count {
+			failures = append(failures, fmt.Sprintf("expected %d tasks, got %d", *e.Count, n))
+		}
+	}
+	return failures
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,412 @@
+// Package grpcserver implements the gRPC API defined in
+// api/assistant/v1/assistant.proto.
+//
+// With WithTokens, each call has to have authorization metadata with one of
+// the bearer tokens. With WithTenants, the token's user only sees their own
+// tasks and the x-assistant-team metadata switches to a shared team list
+// instead. The tokens are required with WithTenants: nothing else says who a
+// call is from, so without them every call is rejected.
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/poy/assistant/pkg/api/assistantv1"
+	"github.com/poy/assistant/pkg/auth"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/sessions"
+	assistanttools "github.com/poy/assistant/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/assistant/pkg/tools/userinput"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements the Tasks and Assistant services.
+type Server struct {
+	assistantv1.UnimplementedTasksServer
+	assistantv1.UnimplementedAssistantServer
+
+	store   tasks.Store
+	agent   assistanttools.Agent[string]
+	timeout time.Duration
+	tenants *tasks.Tenants
+	tokens  auth.Tokens
+}
+
+// Option configures the Server.
+type Option func(*Server)
+
+// WithTimeout limits how long each run can take. Zero means there isn't a
+// deadline.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// WithTenants gives each user their own tasks. It requires WithTokens. See
+// TeamKey.
+func WithTenants(t *tasks.Tenants) Option {
+	return func(s *Server) {
+		s.tenants = t
+	}
+}
+
+// WithTokens requires each call to have one of the bearer tokens. The call
+// is from the token's user.
+func WithTokens(t auth.Tokens) Option {
+	return func(s *Server) {
+		s.tokens = t
+	}
+}
+
+// TeamKey is the metadata key for the team list a call is for. The user has
+// to be a member of the team.
+const TeamKey = "x-assistant-team"
+
+// New returns a Server for the Store and TaskAgent resolved from the context.
+func New(ctx context.Context, opts ...Option) *Server {
+	s := &Server{
+		store: injection.Resolve[tasks.Store](ctx),
+		agent: injection.Resolve[tasks.TaskAgent](ctx).Agent,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Register registers both services with the gRPC server.
+func (s *Server) Register(g *grpc.Server) {
+	assistantv1.RegisterTasksServer(g, s)
+	assistantv1.RegisterAssistantServer(g, s)
+}
+
+// AddTask implements assistantv1.TasksServer.
+func (s *Server) AddTask(ctx context.Context, req *assistantv1.AddTaskRequest) (*assistantv1.Task, error) {
+	store, err := s.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.GetName()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "a name is required")
+	}
+	t, err := store.Create(req.GetName(), req.GetDescription())
+	switch {
+	case errors.Is(err, tasks.ErrExists):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return toTask(t), nil
+}
+
+// RemoveTask implements assistantv1.TasksServer.
+func (s *Server) RemoveTask(ctx context.Context, req *assistantv1.RemoveTaskRequest) (*assistantv1.RemoveTaskResponse, error) {
+	store, err := s.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := getTask(store, req.GetName()); err != nil {
+		return nil, err
+	}
+	store.Remove(req.GetName())
+	return &assistantv1.RemoveTaskResponse{}, nil
+}
+
+// ListTaskNames implements assistantv1.TasksServer.
+func (s *Server) ListTaskNames(ctx context.Context, req *assistantv1.ListTaskNamesRequest) (*assistantv1.ListTaskNamesResponse, error) {
+	store, err := s.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &assistantv1.ListTaskNamesResponse{Names: store.TaskNames()}, nil
+}
+
+// GetTask implements assistantv1.TasksServer.
+func (s *Server) GetTask(ctx context.Context, req *assistantv1.GetTaskRequest) (*assistantv1.Task, error) {
+	t, err := s.getTask(ctx, req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return toTask(t), nil
+}
+
+// AddNotes implements assistantv1.TasksServer.
+func (s *Server) AddNotes(ctx context.Context, req *assistantv1.AddNotesRequest) (*assistantv1.Task, error) {
+	t, err := s.getTask(ctx, req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetNotes()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one note is required")
+	}
+	t.AddNotes(req.GetNotes()...)
+	return toTask(t), nil
+}
+
+// CompleteTask implements assistantv1.TasksServer.
+func (s *Server) CompleteTask(ctx context.Context, req *assistantv1.CompleteTaskRequest) (*assistantv1.Task, error) {
+	t, err := s.getTask(ctx, req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if !t.Completed() {
+		t.Complete()
+	}
+	return toTask(t), nil
+}
+
+// storeFor returns the tasks the call is for.
+func (s *Server) storeFor(ctx context.Context) (tasks.Store, error) {
+	ctx, err := s.withTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tasks.StoreFor(ctx, s.store), nil
+}
+
+// withTenant authenticates the call and returns a context whose calls use the
+// tasks of its user.
+func (s *Server) withTenant(ctx context.Context) (context.Context, error) {
+	if len(s.tokens) == 0 && s.tenants == nil {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	user, err := s.tokens.User(first("authorization"))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if s.tenants == nil {
+		return ctx, nil
+	}
+	ctx, err = s.tenants.WithUser(ctx, user, first(TeamKey))
+	switch {
+	case errors.Is(err, tasks.ErrNotMember):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return ctx, nil
+}
+
+func (s *Server) getTask(ctx context.Context, name string) (*tasks.Task, error) {
+	store, err := s.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return getTask(store, name)
+}
+
+func getTask(store tasks.Store, name string) (*tasks.Task, error) {
+	t := store.GetTask(name)
+	if t == nil {
+		return nil, status.Errorf(codes.NotFound, "task %q not found", name)
+	}
+	return t, nil
+}
+
+func toTask(t *tasks.Task) *assistantv1.Task {
+	result := &assistantv1.Task{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Completed:   t.Completed(),
+		Assignee:    t.Assignee(),
+		Watchers:    t.Watchers(),
+	}
+	if t.Completed() {
+		result.CompletedAt = timestamppb.New(t.CompletedAt())
+	}
+	for _, n := range t.Notes() {
+		result.Notes = append(result.Notes, &assistantv1.Note{
+			Time: timestamppb.New(n.Datetime()),
+			Note: n.Note(),
+		})
+	}
+	return result
+}
+
+// Run implements assistantv1.AssistantServer.
+func (s *Server) Run(stream assistantv1.Assistant_RunServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	goal := req.GetGoal()
+	if strings.TrimSpace(goal) == "" {
+		return status.Error(codes.InvalidArgument, "the first request must have a goal")
+	}
+
+	ctx, err := s.withTenant(stream.Context())
+	if err != nil {
+		return err
+	}
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r := &run{
+		ctx:       ctx,
+		asker:     userinput.NewChannel(),
+		responses: make(chan *assistantv1.RunResponse),
+	}
+
+	// The agents record, display and ask questions through this stream.
+	// Who the user was shown tasks assigned to is only for this run.
+	runCtx := sessions.WithRecorder(ctx, r)
+	runCtx = display.WithSink(runCtx, r)
+	runCtx = userinput.WithAsker(runCtx, r.asker)
+	runCtx = tasks.WithSeen(runCtx, tasks.NewSeen())
+
+	type result struct {
+		answer string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		answer, err := s.agent.Run(runCtx, goal)
+		done <- result{answer: answer, err: err}
+	}()
+
+	// The answers come in on the same stream. Anything else (e.g., another
+	// goal) ends the run.
+	answers := make(chan string)
+	recvErrs := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErrs <- err
+				return
+			}
+			answer, ok := req.GetRequest().(*assistantv1.RunRequest_Answer)
+			if !ok {
+				recvErrs <- status.Error(codes.InvalidArgument, "only answers can be sent after the goal")
+				return
+			}
+			select {
+			case answers <- answer.Answer:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var pending *userinput.Question
+	for {
+		select {
+		case resp := <-r.responses:
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		case q := <-r.asker.Questions():
+			pending = &q
+			if err := stream.Send(&assistantv1.RunResponse{
+				Response: &assistantv1.RunResponse_Question{
+					Question: &assistantv1.Question{Text: q.Text},
+				},
+			}); err != nil {
+				return err
+			}
+		case answer := <-answers:
+			if pending == nil {
+				return status.Error(codes.FailedPrecondition, "there isn't a question to answer")
+			}
+			pending.Answer(answer)
+			pending = nil
+		case err := <-recvErrs:
+			if errors.Is(err, io.EOF) && pending == nil {
+				// The client is done sending, but the run can still finish.
+				recvErrs = nil
+				continue
+			}
+			return err
+		case res := <-done:
+			if res.err != nil {
+				switch {
+				case errors.Is(ctx.Err(), context.DeadlineExceeded):
+					return status.Error(codes.DeadlineExceeded, res.err.Error())
+				case ctx.Err() != nil:
+					return status.Error(codes.Canceled, res.err.Error())
+				}
+				return status.Error(codes.Internal, res.err.Error())
+			}
+			return stream.Send(&assistantv1.RunResponse{
+				Response: &assistantv1.RunResponse_FinalAnswer{FinalAnswer: res.answer},
+			})
+		}
+	}
+}
+
+// run sends the events and output of a run to the stream.
+type run struct {
+	ctx       context.Context
+	asker     *userinput.Channel
+	responses chan *assistantv1.RunResponse
+}
+
+// Record implements sessions.Recorder.
+func (r *run) Record(e sessions.Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	r.send(&assistantv1.RunResponse{
+		Response: &assistantv1.RunResponse_Event{
+			Event: &assistantv1.Event{
+				Time:   timestamppb.New(e.Time),
+				Type:   string(e.Type),
+				Agent:  e.Agent,
+				Action: e.Action,
+				Input:  e.Input,
+				Text:   e.Text,
+			},
+		},
+	})
+}
+
+// Show implements display.Sink.
+func (r *run) Show(ctx context.Context, o display.Output) error {
+	data, err := json.Marshal(o.Data)
+	if err != nil {
+		return err
+	}
+	r.send(&assistantv1.RunResponse{
+		Response: &assistantv1.RunResponse_Output{
+			Output: &assistantv1.Output{
+				Kind:     o.Kind,
+				Text:     o.Text,
+				DataJson: string(data),
+			},
+		},
+	})
+	return nil
+}
+
+// send waits for the response to be sent, unless the stream is done.
+func (r *run) send(resp *assistantv1.RunResponse) {
+	select {
+	case r.responses <- resp:
+	case <-r.ctx.Done():
+	}
+}
@@ -0,0 +1,352 @@
+package grpcserver_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/api/assistantv1"
+	"github.com/poy/assistant/pkg/auth"
+	"github.com/poy/assistant/pkg/grpcserver"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	// Register the fake LLM.
+	_ "github.com/poy/assistant/pkg/testing"
+)
+
+// dial starts the server on an in-memory listener and returns a connection
+// to it.
+func dial(t *testing.T, ctx context.Context, opts ...grpcserver.Option) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	g := grpc.NewServer()
+	grpcserver.New(ctx, opts...).Register(g)
+	go g.Serve(lis)
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestTasks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		call         func(ctx context.Context, c assistantv1.TasksClient) error
+		expectedCode codes.Code
+		assert       func(t *testing.T, s tasks.Store)
+	}{
+		{
+			name: "adds a task",
+			call: func(ctx context.Context, c assistantv1.TasksClient) error {
+				_, err := c.AddTask(ctx, &assistantv1.AddTaskRequest{Name: "other-task", Description: "other-description"})
+				return err
+			},
+			assert: func(t *testing.T, s tasks.Store) {
+				if actual, expected := s.GetTask("other-task").Description(), "other-description"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "adding an existing task",
+			call: func(ctx context.Context, c assistantv1.TasksClient) error {
+				_, err := c.AddTask(ctx, &assistantv1.AddTaskRequest{Name: "some-task"})
+				return err
+			},
+			expectedCode: codes.AlreadyExists,
+		},
+		{
+			name: "gets a task",
+			call: func(ctx context.Context, c assistantv1.TasksClient) error {
+				resp, err := c.GetTask(ctx, &assistantv1.GetTaskRequest{Name: "some-task"})
+				if err == nil && resp.GetDescription() != "some-description" {
+					t.Errorf("expected %q, got %q", "some-description", resp.GetDescription())
+				}
+				return err
+			},
+		},
+		{
+			name: "unknown task",
+			call: func(ctx context.Context, c assistantv1.TasksClient) error {
+				_, err := c.GetTask(ctx, &assistantv1.GetTaskRequest{Name: "unknown"})
+				return err
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name: "adds notes",
+			call: func(ctx context.Context, c assistantv1.TasksClient) error {
+				resp, err := c.AddNotes(ctx, &assistantv1.AddNotesRequest{Name: "some-task", Notes: []string{"some-note"}})
+				if err == nil && len(resp.GetNotes()) != 1 {
+					t.Errorf("expected a note, got %v", resp.GetNotes())
+				}
+				return err
+			},
+		},
+		{
+			name: "completes a task",
+			call: func(ctx context.Context, c assistantv1.TasksClient) error {
+				_, err := c.CompleteTask(ctx, &assistantv1.CompleteTaskRequest{Name: "some-task"})
+				return err
+			},
+			assert: func(t *testing.T, s tasks.Store) {
+				if !s.GetTask("some-task").Completed() {
+					t.Fatal("expected the task to be completed")
+				}
+			},
+		},
+		{
+			name: "removes a task",
+			call: func(ctx context.Context, c assistantv1.TasksClient) error {
+				_, err := c.RemoveTask(ctx, &assistantv1.RemoveTaskRequest{Name: "some-task"})
+				return err
+			},
+			assert: func(t *testing.T, s tasks.Store) {
+				if s.GetTask("some-task") != nil {
+					t.Fatal("expected the task to be removed")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			s := injection.Resolve[tasks.Store](ctx)
+			s.Add("some-task", "some-description")
+
+			c := assistantv1.NewTasksClient(dial(t, ctx))
+			err := tc.call(context.Background(), c)
+			if actual, expected := status.Code(err), tc.expectedCode; actual != expected {
+				t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+			}
+			if tc.assert != nil {
+				tc.assert(t, s)
+			}
+		})
+	}
+}
+
+func TestTenants(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		token         string
+		team          string
+		noTokens      bool
+		expectedCode  codes.Code
+		expectedTasks []string
+	}{
+		{
+			name:          "lists the user's tasks",
+			token:         "alice-token",
+			expectedTasks: []string{"alice-task"},
+		},
+		{
+			name:          "doesn't show other users' tasks",
+			token:         "bob-token",
+			expectedTasks: nil,
+		},
+		{
+			name:          "lists the team's tasks",
+			token:         "bob-token",
+			team:          "platform",
+			expectedTasks: []string{"team-task"},
+		},
+		{
+			name:         "requires a token",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "rejects an unknown token",
+			token:        "alice",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "rejects everything without tokens",
+			token:        "alice-token",
+			noTokens:     true,
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "requires team membership",
+			token:        "mallory-token",
+			team:         "platform",
+			expectedCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			tenants := tasks.NewTenants("", map[string][]string{"platform": {"alice", "bob"}})
+			alice, _ := tenants.UserStore("alice")
+			alice.Add("alice-task", "")
+			team, _ := tenants.TeamStore("alice", "platform")
+			team.Add("team-task", "")
+
+			opts := []grpcserver.Option{grpcserver.WithTenants(tenants)}
+			if !tc.noTokens {
+				opts = append(opts, grpcserver.WithTokens(auth.Tokens{
+					"alice-token":   "alice",
+					"bob-token":     "bob",
+					"mallory-token": "mallory",
+				}))
+			}
+
+			var md []string
+			if tc.token != "" {
+				md = append(md, "authorization", "Bearer "+tc.token)
+			}
+			if tc.team != "" {
+				md = append(md, grpcserver.TeamKey, tc.team)
+			}
+			callCtx := metadata.AppendToOutgoingContext(context.Background(), md...)
+
+			resp, err := assistantv1.NewTasksClient(dial(t, ctx, opts...)).ListTaskNames(callCtx, &assistantv1.ListTaskNamesRequest{})
+			if actual, expected := status.Code(err), tc.expectedCode; actual != expected {
+				t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+			}
+			if err != nil {
+				return
+			}
+			if actual, expected := strings.Join(resp.GetNames(), ","), strings.Join(tc.expectedTasks, ","); actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	ctx := injectiontesting.WithTesting(t)
+
+	s := injection.Resolve[tasks.Store](ctx)
+	s.Add("some-task", "some-description")
+
+	// The root agent uses the modify tool, which asks the user which task
+	// they meant.
+	script := []string{
+		`{"thought": "I should modify the task", "action": "modify", "input": "add a note"}`,
+		`{"thought": "I should ask the user", "action": "user-input", "input": "Which task?"}`,
+		`{"thought": "I know the task", "final_answer": "some-task"}`,
+		`{"thought": "I added the note", "final_answer": "Added the note"}`,
+		`{"thought": "I know the answer", "final_answer": "some-answer"}`,
+	}
+	llm := injection.Resolve[llms.LLM[vertex.Params]](ctx).(*llmstesting.Fake[vertex.Params])
+	llm.Outputs = map[string]string{}
+	llm.GenerateF = func(ctx context.Context, prompt string) {
+		llm.Outputs[prompt] = script[len(llm.Prompts)-1]
+	}
+
+	stream, err := assistantv1.NewAssistantClient(dial(t, ctx)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&assistantv1.RunRequest{Request: &assistantv1.RunRequest_Goal{Goal: "some-goal"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var questions []string
+	var finalAnswer string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		switch r := resp.GetResponse().(type) {
+		case *assistantv1.RunResponse_Question:
+			questions = append(questions, r.Question.GetText())
+			if err := stream.Send(&assistantv1.RunRequest{Request: &assistantv1.RunRequest_Answer{Answer: "some-task"}}); err != nil {
+				t.Fatal(err)
+			}
+		case *assistantv1.RunResponse_FinalAnswer:
+			finalAnswer = r.FinalAnswer
+		}
+	}
+
+	if len(questions) != 1 || questions[0] != "Which task?" {
+		t.Fatalf("expected the question, got %v", questions)
+	}
+	if actual, expected := finalAnswer, "some-answer"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestRunSecondGoal(t *testing.T) {
+	t.Parallel()
+	ctx := injectiontesting.WithTesting(t)
+
+	// The modify tool asks the user which task they meant, so the run is
+	// waiting for an answer when the second goal is sent.
+	script := []string{
+		`{"thought": "I should modify the task", "action": "modify", "input": "add a note"}`,
+		`{"thought": "I should ask the user", "action": "user-input", "input": "Which task?"}`,
+	}
+	llm := injection.Resolve[llms.LLM[vertex.Params]](ctx).(*llmstesting.Fake[vertex.Params])
+	llm.Outputs = map[string]string{}
+	llm.GenerateF = func(ctx context.Context, prompt string) {
+		llm.Outputs[prompt] = script[min(len(llm.Prompts), len(script))-1]
+	}
+
+	stream, err := assistantv1.NewAssistantClient(dial(t, ctx)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&assistantv1.RunRequest{Request: &assistantv1.RunRequest_Goal{Goal: "some-goal"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if actual, expected := status.Code(err), codes.InvalidArgument; actual != expected {
+				t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+			}
+			return
+		}
+		if _, ok := resp.GetResponse().(*assistantv1.RunResponse_Question); ok {
+			if err := stream.Send(&assistantv1.RunRequest{Request: &assistantv1.RunRequest_Goal{Goal: "another-goal"}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
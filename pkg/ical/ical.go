@@ -0,0 +1,282 @@
+// Package ical encodes and decodes to-dos as iCalendar VTODO components
+// (RFC 5545). Only the properties the assistant uses are supported; anything
+// else is ignored when decoding.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Todo is a VTODO component.
+type Todo struct {
+	UID         string
+	Summary     string
+	Description string
+	Created     time.Time
+	// Due is zero if the to-do isn't due.
+	Due time.Time
+	// Completed is zero if the to-do isn't completed.
+	Completed time.Time
+	Comments  []Comment
+}
+
+// Comment is a COMMENT property. The time is kept in the X-TIME parameter so
+// notes keep when they were written.
+type Comment struct {
+	Time time.Time
+	Text string
+}
+
+// dateTime is the UTC form of DATE-TIME.
+const dateTime = "20060102T150405Z"
+
+// ProdID identifies the assistant as the producer of the calendar.
+const ProdID = "-//poy//assistant//EN"
+
+// Encode writes the to-dos as a VCALENDAR.
+func Encode(w io.Writer, todos ...Todo) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.line("BEGIN", "", "VCALENDAR")
+	e.line("VERSION", "", "2.0")
+	e.line("PRODID", "", ProdID)
+	for _, t := range todos {
+		e.line("BEGIN", "", "VTODO")
+		e.line("UID", "", escape(t.UID))
+		e.line("DTSTAMP", "", formatTime(t.Created))
+		if !t.Created.IsZero() {
+			e.line("CREATED", "", formatTime(t.Created))
+		}
+		e.line("SUMMARY", "", escape(t.Summary))
+		if t.Description != "" {
+			e.line("DESCRIPTION", "", escape(t.Description))
+		}
+		if !t.Due.IsZero() {
+			e.line("DUE", "", formatTime(t.Due))
+		}
+		if t.Completed.IsZero() {
+			e.line("STATUS", "", "NEEDS-ACTION")
+		} else {
+			e.line("STATUS", "", "COMPLETED")
+			e.line("COMPLETED", "", formatTime(t.Completed))
+		}
+		for _, c := range t.Comments {
+			e.line("COMMENT", ";X-TIME="+formatTime(c.Time), escape(c.Text))
+		}
+		e.line("END", "", "VTODO")
+	}
+	e.line("END", "", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a content line, folding it so no line is longer than 75
+// octets.
+func (e *encoder) line(name, params, value string) {
+	if e.err != nil {
+		return
+	}
+	l := name + params + ":" + value
+	// The continuation lines start with a space.
+	for max := 75; len(l) > max; max = 74 {
+		// Don't split a UTF-8 sequence.
+		i := max
+		for i > 0 && l[i]&0xC0 == 0x80 {
+			i--
+		}
+		if _, e.err = e.w.WriteString(l[:i] + "\r\n "); e.err != nil {
+			return
+		}
+		l = l[i:]
+	}
+	_, e.err = e.w.WriteString(l + "\r\n")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format(dateTime)
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// Decode reads the to-dos from a VCALENDAR. Other components (e.g., VEVENT)
+// are skipped.
+func Decode(r io.Reader) ([]Todo, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		todos  []Todo
+		todo   *Todo
+		depth  int
+		inTodo bool
+	)
+	for n, l := range lines {
+		name, params, value, err := parseLine(l)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch name {
+		case "BEGIN":
+			depth++
+			if strings.EqualFold(value, "VTODO") && !inTodo {
+				inTodo = true
+				todo = &Todo{}
+			}
+			continue
+		case "END":
+			depth--
+			if strings.EqualFold(value, "VTODO") && inTodo {
+				inTodo = false
+				todos = append(todos, *todo)
+				todo = nil
+			}
+			continue
+		}
+		if !inTodo {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			todo.UID = unescape(value)
+		case "SUMMARY":
+			todo.Summary = unescape(value)
+		case "DESCRIPTION":
+			todo.Description = unescape(value)
+		case "CREATED":
+			todo.Created, err = parseTime(value, params)
+		case "DUE":
+			todo.Due, err = parseTime(value, params)
+		case "COMPLETED":
+			todo.Completed, err = parseTime(value, params)
+		case "STATUS":
+			// A completed to-do without COMPLETED still needs a time.
+			if strings.EqualFold(value, "COMPLETED") && todo.Completed.IsZero() {
+				todo.Completed = time.Now()
+			}
+		case "COMMENT":
+			c := Comment{Text: unescape(value)}
+			if v, ok := params["X-TIME"]; ok {
+				c.Time, err = parseTime(v, nil)
+			}
+			todo.Comments = append(todo.Comments, c)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n+1, name, err)
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced BEGIN and END")
+	}
+	return todos, nil
+}
+
+// unfold joins the folded lines.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		l := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if l == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// parseLine splits a content line into its name, parameters and value.
+func parseLine(l string) (string, map[string]string, string, error) {
+	// The value starts at the first colon that isn't in a quoted parameter.
+	quoted := false
+	colon := -1
+	for i, r := range l {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", fmt.Errorf("invalid content line %q", l)
+	}
+
+	parts := strings.Split(l[:colon], ";")
+	params := map[string]string{}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, l[colon+1:], nil
+}
+
+// parseTime parses a DATE-TIME or DATE value. Floating times (without a Z)
+// are in the TZID parameter's time zone if it is known, otherwise local time.
+func parseTime(v string, params map[string]string) (time.Time, error) {
+	loc := time.Local
+	if strings.HasSuffix(v, "Z") {
+		loc = time.UTC
+	} else if tzid, ok := params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	for _, layout := range []string{dateTime, "20060102T150405", "20060102"} {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", v)
+}
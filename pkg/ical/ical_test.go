@@ -0,0 +1,124 @@
+package ical_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poy/assistant/pkg/ical"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	todo := ical.Todo{
+		UID:         "some-uid",
+		Summary:     "buy milk; eggs, bread",
+		Description: strings.Repeat("a long description\n", 10),
+		Created:     created,
+		Due:         created.Add(48 * time.Hour),
+		Completed:   created.Add(24 * time.Hour),
+		Comments: []ical.Comment{
+			{Time: created.Add(time.Hour), Text: "the store was closed"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, todo); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range strings.Split(buf.String(), "\r\n") {
+		if len(l) > 75 {
+			t.Fatalf("expected the line to be folded: %q", l)
+		}
+	}
+
+	todos, err := ical.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 1 {
+		t.Fatalf("expected 1 to-do, got %d", len(todos))
+	}
+	if actual, expected := todos[0], todo; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		input  string
+		assert func(t *testing.T, todos []ical.Todo, err error)
+	}{
+		{
+			name: "skips other components",
+			input: "BEGIN:VCALENDAR\r\n" +
+				"BEGIN:VEVENT\r\nSUMMARY:a meeting\r\nEND:VEVENT\r\n" +
+				"BEGIN:VTODO\r\nUID:1\r\nSUMMARY:a task\r\nEND:VTODO\r\n" +
+				"END:VCALENDAR\r\n",
+			assert: func(t *testing.T, todos []ical.Todo, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(todos) != 1 || todos[0].Summary != "a task" {
+					t.Fatalf("expected only the to-do, got %+v", todos)
+				}
+			},
+		},
+		{
+			name: "dates and time zones",
+			input: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\n" +
+				"DUE;VALUE=DATE:20230601\r\n" +
+				"CREATED;TZID=America/New_York:20230501T090000\r\n" +
+				"END:VTODO\r\nEND:VCALENDAR\r\n",
+			assert: func(t *testing.T, todos []ical.Todo, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := todos[0].Due, time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local); !actual.Equal(expected) {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+				if actual, expected := todos[0].Created.UTC(), time.Date(2023, 5, 1, 13, 0, 0, 0, time.UTC); !actual.Equal(expected) {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "completed without a time",
+			input: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+			assert: func(t *testing.T, todos []ical.Todo, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if todos[0].Completed.IsZero() {
+					t.Fatal("expected the to-do to be completed")
+				}
+			},
+		},
+		{
+			name:  "unbalanced",
+			input: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\n",
+			assert: func(t *testing.T, todos []ical.Todo, err error) {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			todos, err := ical.Decode(strings.NewReader(tc.input))
+			tc.assert(t, todos, err)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package jsonfile saves values as JSON files that several processes can
+// share (e.g., the tasks and the usage stats).
+package jsonfile
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Write encodes the value to a temporary file and then renames it over the
+// given path. This way the file is never left half written, even if the
+// process is interrupted while saving.
+func Write(p string, v any) error {
+	f, err := os.CreateTemp(filepath.Dir(p), filepath.Base(p)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", p, err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode %s: %w", p, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync %s: %w", p, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", p, err)
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", p, err)
+	}
+	return nil
+}
+
+// lockTimeout is how long to wait for another process to finish saving the
+// file. The lock is only held while a file is read and saved, so a lock older
+// than staleLock is assumed to be left over from a holder that died on
+// another host (the holders on this host are checked directly).
+const (
+	lockTimeout = 5 * time.Second
+	staleLock   = 10 * time.Minute
+)
+
+// Lock keeps other processes from saving the file until the returned
+// function is called.
+//
+// The lock is a file next to p with the holder's host, PID and a random
+// token. A lock is only removed by its holder, or by someone who found it
+// stale and checked that it is still the same lock.
+func Lock(p string) (func(), error) {
+	lock := p + ".lock"
+	owner, err := newOwner()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", p, err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, werr := f.WriteString(owner)
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				os.Remove(lock)
+				return nil, fmt.Errorf("failed to lock %s: %w", p, werr)
+			}
+			return func() { removeIfOwned(lock, owner) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %w", p, err)
+		}
+		if holder, ok := staleOwner(lock); ok {
+			removeIfOwned(lock, holder)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the lock on %s", p)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newOwner returns the contents of a lock file: "<host> <pid> <token>".
+func newOwner() (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %d %s\n", host, os.Getpid(), hex.EncodeToString(token)), nil
+}
+
+// staleOwner returns the contents of the lock if its holder is gone.
+func staleOwner(lock string) (string, bool) {
+	// The contents are read first: if the lock is replaced before the stat,
+	// the new one isn't old enough to be stale.
+	data, err := os.ReadFile(lock)
+	if err != nil {
+		return "", false
+	}
+	info, err := os.Stat(lock)
+	if err != nil {
+		return "", false
+	}
+	owner := string(data)
+	if time.Since(info.ModTime()) > staleLock {
+		return owner, true
+	}
+
+	var (
+		host     string
+		pid      int
+		token    string
+		thisHost string
+	)
+	if _, err := fmt.Sscan(owner, &host, &pid, &token); err != nil {
+		// Older versions left the lock empty, so only its age says if it is
+		// stale.
+		return "", false
+	}
+	if thisHost, err = os.Hostname(); err != nil || host != thisHost {
+		return "", false
+	}
+	return owner, !running(pid)
+}
+
+// running returns false if the process is known to be gone.
+func running(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		// Not every platform can tell, so assume it is running.
+		return true
+	}
+	return !errors.Is(proc.Signal(syscall.Signal(0)), os.ErrProcessDone)
+}
+
+// removeIfOwned removes the lock if it still has the owner's contents. The
+// lock is moved aside before it is checked, so a lock someone else just took
+// is never removed.
+func removeIfOwned(lock, owner string) {
+	aside := fmt.Sprintf("%s.%d-%d", lock, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lock, aside); err != nil {
+		return
+	}
+	defer os.Remove(aside)
+	if data, err := os.ReadFile(aside); err != nil || string(data) != owner {
+		// It isn't the owner's, so put it back. If the lock was taken in the
+		// meantime, that one is kept instead.
+		os.Link(aside, lock)
+	}
+}
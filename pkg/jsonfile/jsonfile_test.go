@@ -0,0 +1,140 @@
+package jsonfile_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/poy/assistant/pkg/jsonfile"
+)
+
+func TestLockWaits(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "some.json")
+	unlock, err := jsonfile.Lock(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locked := make(chan func())
+	go func() {
+		unlock, err := jsonfile.Lock(p)
+		if err != nil {
+			t.Error(err)
+			unlock = func() {}
+		}
+		locked <- unlock
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("expected the second lock to wait")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case unlock := <-locked:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the second lock")
+	}
+}
+
+func TestLockTakesOverStaleLocks(t *testing.T) {
+	t.Parallel()
+
+	host, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A process that has finished.
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("can't run a process: %v", err)
+	}
+	deadPID := cmd.Process.Pid
+
+	testCases := []struct {
+		name     string
+		contents string
+		age      time.Duration
+	}{
+		{name: "the holder on this host is gone", contents: fmt.Sprintf("%s %d some-token\n", host, deadPID)},
+		{name: "an old lock from another host", contents: "other-host 1 some-token\n", age: time.Hour},
+		{name: "an old lock from an older version", age: time.Hour},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := filepath.Join(t.TempDir(), "some.json")
+			if err := os.WriteFile(p+".lock", []byte(tc.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tc.age > 0 {
+				old := time.Now().Add(-tc.age)
+				if err := os.Chtimes(p+".lock", old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			unlock, err := jsonfile.Lock(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			unlock()
+		})
+	}
+}
+
+func TestUnlockOnlyRemovesItsOwnLock(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "some.json")
+	unlock, err := jsonfile.Lock(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Someone else took the lock over in the meantime.
+	if err := os.WriteFile(p+".lock", []byte("other-host 1 other-token\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+
+	data, err := os.ReadFile(p + ".lock")
+	if err != nil {
+		t.Fatalf("expected the other lock to be kept: %v", err)
+	}
+	if actual, expected := string(data), "other-host 1 other-token\n"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if matches, _ := filepath.Glob(p + ".lock.*"); len(matches) != 0 {
+		t.Fatalf("expected nothing left behind, got %v", matches)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "some.json")
+	if err := jsonfile.Write(p, map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := string(data), "{\"a\":1}\n"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if matches, _ := filepath.Glob(p + ".tmp-*"); len(matches) != 0 {
+		t.Fatalf("expected the temp file to be removed, got %v", matches)
+	}
+}
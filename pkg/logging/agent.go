@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/prompters"
+)
+
+type prompter[TPrompt, TLLMParams any] struct {
+	p      prompters.Prompter[TPrompt, TLLMParams]
+	logger *slog.Logger
+}
+
+// NewPrompter logs each hydrated prompt at LevelPrompt.
+func NewPrompter[TPrompt, TLLMParams any](p prompters.Prompter[TPrompt, TLLMParams], logger *slog.Logger) prompters.Prompter[TPrompt, TLLMParams] {
+	return prompter[TPrompt, TLLMParams]{
+		p:      p,
+		logger: logger,
+	}
+}
+
+// Hydrate implements prompters.Prompter.
+func (p prompter[TPrompt, TLLMParams]) Hydrate(ctx context.Context, req TPrompt) (string, TLLMParams, error) {
+	output, params, err := p.p.Hydrate(ctx, req)
+	if err != nil {
+		p.logger.WarnContext(ctx, "failed to hydrate the prompt", "error", err)
+		return output, params, err
+	}
+	p.logger.Log(ctx, LevelPrompt, "prompt", "prompt", output)
+	return output, params, nil
+}
+
+type agentLogger[TOut any] struct {
+	p      predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]]
+	logger *slog.Logger
+}
+
+// NewAgentLogger logs the agent's thoughts, actions and final answer at
+// debug.
+func NewAgentLogger[TOut any](
+	p predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]],
+	logger *slog.Logger,
+) predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]] {
+	return agentLogger[TOut]{
+		p:      p,
+		logger: logger,
+	}
+}
+
+// Predict implements predictors.Predictor.
+func (l agentLogger[TOut]) Predict(ctx context.Context, req agents.PromptData[TOut]) (agents.Reasoning[TOut], error) {
+	resp, err := l.p.Predict(ctx, req)
+	if err != nil {
+		// The error is returned to whoever ran the agent, so it is only
+		// logged for debugging.
+		l.logger.DebugContext(ctx, "failed to predict", "error", err)
+		return resp, err
+	}
+
+	if resp.Action != "" {
+		l.logger.DebugContext(ctx, "thought",
+			"thought", resp.Thought,
+			"action", resp.Action,
+			"input", resp.Input,
+		)
+	} else {
+		l.logger.DebugContext(ctx, "thought",
+			"thought", resp.Thought,
+			"final_answer", resp.FinalAnswer,
+		)
+	}
+	return resp, nil
+}
@@ -0,0 +1,143 @@
+// Package logging is the leveled, structured logging for the assistant. The
+// logger is resolved through injection, and is quiet (warnings and errors)
+// unless a lower level is provided.
+//
+// The levels are used as:
+//   - LevelPrompt: the full prompts sent to the LLM.
+//   - slog.LevelDebug: the agents' thoughts, actions and final answers.
+//   - slog.LevelInfo: what the assistant is doing (e.g., loading the store).
+//   - slog.LevelWarn: something failed, but the assistant carried on.
+//   - slog.LevelError: something failed and the change was lost.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// LevelPrompt is below debug, as the prompts are long.
+const LevelPrompt = slog.LevelDebug - 4
+
+// DefaultLevel only shows the warnings and errors.
+const DefaultLevel = slog.LevelWarn
+
+func init() {
+	ProvideLogger(New(os.Stderr, DefaultLevel))
+}
+
+// ProvideLogger sets the logger for everything that doesn't set its own
+// (e.g., with assistanttools.WithLogger).
+func ProvideLogger(l *slog.Logger) {
+	injection.Register[*slog.Logger](
+		func(ctx context.Context) *slog.Logger {
+			return l
+		},
+	)
+}
+
+// New returns a logger that writes text for a person to read, without the
+// times, at the given level.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(NewTextHandler(w, level))
+}
+
+// NewTextHandler writes text for a person to read, without the times.
+func NewTextHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return replaceLevel(groups, a)
+		},
+	})
+}
+
+// NewJSONHandler writes a line of JSON for each record (e.g., for a log
+// file).
+func NewJSONHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceLevel,
+	})
+}
+
+// replaceLevel names LevelPrompt, which would otherwise be "DEBUG-4".
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if l, ok := a.Value.Any().(slog.Level); ok && l == LevelPrompt {
+			return slog.String(slog.LevelKey, "PROMPT")
+		}
+	}
+	return a
+}
+
+// ParseLevel parses prompt, debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	if strings.EqualFold(s, "prompt") {
+		return LevelPrompt, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unsupported log level %q (prompt, debug, info, warn or error)", s)
+	}
+	return l, nil
+}
+
+// Tee sends each record to all of the handlers that are enabled for it
+// (e.g., the terminal and a log file).
+func Tee(handlers ...slog.Handler) slog.Handler {
+	return tee(handlers)
+}
+
+type tee []slog.Handler
+
+// Enabled implements slog.Handler.
+func (t tee) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle implements slog.Handler.
+func (t tee) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs implements slog.Handler.
+func (t tee) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := make(tee, len(t))
+	for i, h := range t {
+		result[i] = h.WithAttrs(attrs)
+	}
+	return result
+}
+
+// WithGroup implements slog.Handler.
+func (t tee) WithGroup(name string) slog.Handler {
+	result := make(tee, len(t))
+	for i, h := range t {
+		result[i] = h.WithGroup(name)
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/poy/assistant/pkg/logging"
+)
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		level    string
+		expected slog.Level
+		err      bool
+	}{
+		{name: "prompt", level: "prompt", expected: logging.LevelPrompt},
+		{name: "debug", level: "debug", expected: slog.LevelDebug},
+		{name: "warn", level: "WARN", expected: slog.LevelWarn},
+		{name: "error", level: "error", expected: slog.LevelError},
+		{name: "unsupported", level: "chatty", err: true},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := logging.ParseLevel(tc.level)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.DefaultLevel)
+	logger.Debug("some-thought")
+	logger.Warn("some-warning", "task", "some-task")
+
+	if actual := buf.String(); strings.Contains(actual, "some-thought") {
+		t.Fatalf("expected the debug log to be filtered, got %q", actual)
+	}
+	if actual, expected := buf.String(), "level=WARN msg=some-warning task=some-task\n"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestTee(t *testing.T) {
+	t.Parallel()
+
+	var terminal, file bytes.Buffer
+	logger := slog.New(logging.Tee(
+		logging.NewTextHandler(&terminal, slog.LevelWarn),
+		logging.NewJSONHandler(&file, logging.LevelPrompt),
+	)).With("agent", "some-agent")
+	logger.Log(context.Background(), logging.LevelPrompt, "prompt", "prompt", "some-prompt")
+
+	if actual := terminal.String(); actual != "" {
+		t.Fatalf("expected nothing in the terminal, got %q", actual)
+	}
+	var record map[string]string
+	if err := json.Unmarshal(file.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := record["level"], "PROMPT"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := record["agent"], "some-agent"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := record["prompt"], "some-prompt"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
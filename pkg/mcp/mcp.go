@@ -0,0 +1,336 @@
+// Package mcp serves tools and resources with the Model Context Protocol over
+// a stream (e.g., stdin and stdout). Each message is a JSON-RPC 2.0 message
+// on its own line.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/tools/userinput"
+)
+
+// ProtocolVersion is the version of MCP that is implemented.
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// Resource is something the client can read.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents are the contents of a Resource.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Resources lists and reads resources.
+type Resources interface {
+	// List returns the available resources.
+	List(ctx context.Context) ([]Resource, error)
+	// Read returns the contents of the resource.
+	Read(ctx context.Context, uri string) (ResourceContents, error)
+}
+
+// ErrResourceNotFound is returned by Resources when the URI is unknown.
+var ErrResourceNotFound = errors.New("resource not found")
+
+// Server serves the tools and resources.
+type Server struct {
+	name      string
+	version   string
+	tools     []tools.Tool
+	resources Resources
+}
+
+// NewServer returns a Server with the given tools and resources.
+func NewServer(name, version string, ts []tools.Tool, resources Resources) *Server {
+	return &Server{
+		name:      name,
+		version:   version,
+		tools:     ts,
+		resources: resources,
+	}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve reads requests from in and writes the responses to out until in is
+// closed or the context is done. Requests are handled concurrently, and a
+// request can be cancelled with the notifications/cancelled notification.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		writeMu sync.Mutex
+		enc     = json.NewEncoder(out)
+
+		inflightMu sync.Mutex
+		inflight   = map[string]context.CancelFunc{}
+	)
+	defer wg.Wait()
+
+	write := func(resp response) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		resp.JSONRPC = "2.0"
+		enc.Encode(resp)
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			write(response{ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			if len(req.ID) > 0 {
+				write(response{ID: req.ID, Error: &rpcError{Code: codeInvalidRequest, Message: "invalid request"}})
+			}
+			continue
+		}
+
+		// Notifications don't have an ID and don't get a response.
+		if len(req.ID) == 0 {
+			if req.Method == "notifications/cancelled" {
+				var params struct {
+					RequestID json.RawMessage `json:"requestId"`
+				}
+				if json.Unmarshal(req.Params, &params) == nil {
+					inflightMu.Lock()
+					if cancel, ok := inflight[string(params.RequestID)]; ok {
+						cancel()
+					}
+					inflightMu.Unlock()
+				}
+			}
+			continue
+		}
+
+		reqCtx, reqCancel := context.WithCancel(ctx)
+		inflightMu.Lock()
+		inflight[string(req.ID)] = reqCancel
+		inflightMu.Unlock()
+
+		wg.Add(1)
+		go func(req request) {
+			defer wg.Done()
+			defer func() {
+				inflightMu.Lock()
+				delete(inflight, string(req.ID))
+				inflightMu.Unlock()
+				reqCancel()
+			}()
+
+			result, err := s.handle(reqCtx, req)
+			if err != nil {
+				var rerr *rpcError
+				if !errors.As(err, &rerr) {
+					rerr = &rpcError{Code: codeInternalError, Message: err.Error()}
+				}
+				write(response{ID: req.ID, Error: rerr})
+				return
+			}
+			write(response{ID: req.ID, Result: result})
+		}(req)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handle(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{},
+				"resources": map[string]any{},
+			},
+			"serverInfo": map[string]any{
+				"name":    s.name,
+				"version": s.version,
+			},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		var result []map[string]any
+		for _, t := range s.tools {
+			result = append(result, map[string]any{
+				"name":        t.Name,
+				"description": t.Description,
+				"inputSchema": InputSchema(t),
+			})
+		}
+		return map[string]any{"tools": result}, nil
+	case "tools/call":
+		var params struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		for _, t := range s.tools {
+			if t.Name == params.Name {
+				return callTool(ctx, t, params.Arguments), nil
+			}
+		}
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+	case "resources/list":
+		resources, err := s.resources.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if resources == nil {
+			resources = []Resource{}
+		}
+		return map[string]any{"resources": resources}, nil
+	case "resources/read":
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		contents, err := s.resources.Read(ctx, params.URI)
+		if errors.Is(err, ErrResourceNotFound) {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"contents": []ResourceContents{contents}}, nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+	}
+}
+
+// InputSchema returns the JSON schema for the tool's input. Each of the
+// tool's Args is a required string.
+func InputSchema(t tools.Tool) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for _, arg := range t.Args {
+		p := map[string]any{
+			"type":        "string",
+			"description": fmt.Sprintf("The %s.", arg),
+		}
+		if len(t.Args) == 1 && len(t.Examples) > 0 {
+			p["examples"] = t.Examples
+		}
+		properties[arg] = p
+		required = append(required, arg)
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// noAsker is used because the client can't be asked questions while it is
+// waiting for the tool.
+type noAsker struct{}
+
+// Ask implements userinput.Asker.
+func (noAsker) Ask(ctx context.Context, question string) (string, error) {
+	return "", fmt.Errorf("the user can't be asked %q, make your best guess instead", question)
+}
+
+// callTool runs the tool. What the tool shows to the user is returned to the
+// client instead of being written to stdout, which is the protocol's stream.
+func callTool(ctx context.Context, t tools.Tool, arguments map[string]any) map[string]any {
+	var input []string
+	for _, arg := range t.Args {
+		v, ok := arguments[arg]
+		if !ok {
+			return toolError(fmt.Errorf("missing argument %q", arg))
+		}
+		input = append(input, fmt.Sprint(v))
+	}
+
+	buf := &display.Buffer{}
+	ctx = display.WithSink(ctx, buf)
+	ctx = userinput.WithAsker(ctx, noAsker{})
+
+	observation, err := t.Run(ctx, strings.Join(input, " "))
+	if err != nil {
+		return toolError(err)
+	}
+
+	var content []map[string]any
+	for _, o := range buf.Outputs() {
+		content = append(content, textContent(o.Text))
+	}
+	if len(content) == 0 {
+		content = append(content, textContent(observation))
+	}
+	return map[string]any{"content": content}
+}
+
+func toolError(err error) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{textContent(err.Error())},
+		"isError": true,
+	}
+}
+
+func textContent(text string) map[string]any {
+	return map[string]any{"type": "text", "text": strings.TrimSpace(text)}
+}
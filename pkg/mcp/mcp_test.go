@@ -0,0 +1,215 @@
+package mcp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/poy/assistant/pkg/mcp"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+
+	// Register the fake LLM.
+	_ "github.com/poy/assistant/pkg/testing"
+)
+
+type response struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		request string
+		assert  func(t *testing.T, resp response)
+	}{
+		{
+			name:    "initializes",
+			request: `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05"}}`,
+			assert: func(t *testing.T, resp response) {
+				var result struct {
+					ProtocolVersion string `json:"protocolVersion"`
+					ServerInfo      struct {
+						Name string `json:"name"`
+					} `json:"serverInfo"`
+				}
+				unmarshal(t, resp, &result)
+				if actual, expected := result.ProtocolVersion, mcp.ProtocolVersion; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := result.ServerInfo.Name, "assistant"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:    "lists the tools with their schemas",
+			request: `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`,
+			assert: func(t *testing.T, resp response) {
+				var result struct {
+					Tools []struct {
+						Name        string `json:"name"`
+						InputSchema struct {
+							Required []string `json:"required"`
+						} `json:"inputSchema"`
+					} `json:"tools"`
+				}
+				unmarshal(t, resp, &result)
+
+				schemas := map[string][]string{}
+				for _, tool := range result.Tools {
+					schemas[tool.Name] = tool.InputSchema.Required
+				}
+				expected := map[string]string{
+					"add":      "instructions",
+					"remove":   "instructions",
+					"read":     "name",
+					"list":     "",
+					"add-note": "instructions",
+					"complete": "instructions",
+				}
+				if len(schemas) != len(expected) {
+					t.Fatalf("expected %d tools, got %d", len(expected), len(schemas))
+				}
+				for name, args := range expected {
+					if actual := strings.Join(schemas[name], ","); actual != args {
+						t.Fatalf("%s: expected %q, got %q", name, args, actual)
+					}
+				}
+			},
+		},
+		{
+			name:    "calls a tool",
+			request: `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "list", "arguments": {}}}`,
+			assert: func(t *testing.T, resp response) {
+				var result struct {
+					Content []struct {
+						Text string `json:"text"`
+					} `json:"content"`
+					IsError bool `json:"isError"`
+				}
+				unmarshal(t, resp, &result)
+				if result.IsError || len(result.Content) != 1 {
+					t.Fatalf("expected a single result, got %+v", result)
+				}
+				if actual, expected := result.Content[0].Text, "* some-task"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:    "missing argument",
+			request: `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "read", "arguments": {}}}`,
+			assert: func(t *testing.T, resp response) {
+				var result struct {
+					IsError bool `json:"isError"`
+				}
+				unmarshal(t, resp, &result)
+				if !result.IsError {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+		{
+			name:    "lists the resources",
+			request: `{"jsonrpc": "2.0", "id": 1, "method": "resources/list"}`,
+			assert: func(t *testing.T, resp response) {
+				var result struct {
+					Resources []mcp.Resource `json:"resources"`
+				}
+				unmarshal(t, resp, &result)
+				if len(result.Resources) != 1 {
+					t.Fatalf("expected a resource, got %v", result.Resources)
+				}
+				if actual, expected := result.Resources[0].URI, "task:///some-task"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:    "reads a resource",
+			request: `{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": {"uri": "task:///some-task"}}`,
+			assert: func(t *testing.T, resp response) {
+				var result struct {
+					Contents []mcp.ResourceContents `json:"contents"`
+				}
+				unmarshal(t, resp, &result)
+				if len(result.Contents) != 1 || !strings.Contains(result.Contents[0].Text, "Description: some-description") {
+					t.Fatalf("expected the task details, got %v", result.Contents)
+				}
+			},
+		},
+		{
+			name:    "unknown resource",
+			request: `{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": {"uri": "task:///unknown"}}`,
+			assert: func(t *testing.T, resp response) {
+				if resp.Error == nil || resp.Error.Code != -32602 {
+					t.Fatalf("expected an invalid params error, got %+v", resp.Error)
+				}
+			},
+		},
+		{
+			name:    "unknown method",
+			request: `{"jsonrpc": "2.0", "id": 1, "method": "unknown"}`,
+			assert: func(t *testing.T, resp response) {
+				if resp.Error == nil || resp.Error.Code != -32601 {
+					t.Fatalf("expected a method not found error, got %+v", resp.Error)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+			injection.Resolve[tasks.Store](ctx).Add("some-task", "some-description")
+
+			// Notifications don't get a response, so only the request should.
+			in := strings.NewReader(strings.Join([]string{
+				`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+				tc.request,
+			}, "\n"))
+			var out bytes.Buffer
+			if err := mcp.New(ctx, "test").Serve(context.Background(), in, &out); err != nil {
+				t.Fatal(err)
+			}
+
+			var responses []response
+			scanner := bufio.NewScanner(&out)
+			for scanner.Scan() {
+				var resp response
+				if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				responses = append(responses, resp)
+			}
+			if len(responses) != 1 {
+				t.Fatalf("expected a single response, got %d: %s", len(responses), out.String())
+			}
+			tc.assert(t, responses[0])
+		})
+	}
+}
+
+func unmarshal(t *testing.T, resp response, v any) {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %+v", resp.Error)
+	}
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		t.Fatal(err)
+	}
+}
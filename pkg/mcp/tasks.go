@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// taskScheme is the URI scheme for the task resources.
+const taskScheme = "task:///"
+
+// New returns a Server with the task tools and each task as a resource.
+func New(ctx context.Context, version string) *Server {
+	return NewServer(
+		"assistant",
+		version,
+		[]tools.Tool{
+			tasks.Add(ctx),
+			tasks.Remove(ctx),
+			tasks.Read(ctx),
+			tasks.List(ctx),
+			tasks.AddNote(ctx),
+			tasks.Complete(ctx),
+		},
+		taskResources{s: injection.Resolve[tasks.Store](ctx)},
+	)
+}
+
+// taskResources exposes the tasks as resources.
+type taskResources struct {
+	s tasks.Store
+}
+
+// List implements Resources.
+func (r taskResources) List(ctx context.Context) ([]Resource, error) {
+	var result []Resource
+	for _, name := range r.s.TaskNames() {
+		t := r.s.GetTask(name)
+		if t == nil {
+			continue
+		}
+		result = append(result, Resource{
+			URI:         taskScheme + url.PathEscape(name),
+			Name:        name,
+			Description: t.Description(),
+			MIMEType:    "text/plain",
+		})
+	}
+	return result, nil
+}
+
+// Read implements Resources.
+func (r taskResources) Read(ctx context.Context, uri string) (ResourceContents, error) {
+	escaped, ok := strings.CutPrefix(uri, taskScheme)
+	if !ok {
+		return ResourceContents{}, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+	name, err := url.PathUnescape(escaped)
+	if err != nil {
+		return ResourceContents{}, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+
+	t := r.s.GetTask(name)
+	if t == nil {
+		return ResourceContents{}, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+	return ResourceContents{
+		URI:      uri,
+		MIMEType: "text/plain",
+		Text:     strings.TrimSpace(tasks.FormatDetails(t)),
+	}, nil
+}
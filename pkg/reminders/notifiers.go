@@ -0,0 +1,119 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"os/exec"
+	"strings"
+)
+
+// Command runs a program with the reminder's title and message as the last
+// two arguments.
+type Command struct {
+	Path string
+	Args []string
+}
+
+// NotifySend shows the reminders as desktop notifications.
+func NotifySend() Command {
+	return Command{Path: "notify-send", Args: []string{"--app-name=assistant"}}
+}
+
+// Notify implements Notifier.
+func (c Command) Notify(ctx context.Context, r Reminder) error {
+	args := append(append([]string(nil), c.Args...), r.Title(), r.Message())
+	out, err := exec.CommandContext(ctx, c.Path, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w: %s", c.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Bell rings the terminal bell and writes the reminder.
+type Bell struct {
+	W io.Writer
+}
+
+// Notify implements Notifier.
+func (b Bell) Notify(ctx context.Context, r Reminder) error {
+	_, err := fmt.Fprintf(b.W, "\a%s\n", r.Message())
+	return err
+}
+
+// Webhook posts the reminders as JSON.
+type Webhook struct {
+	URL string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// webhookBody is what is posted to the webhook.
+type webhookBody struct {
+	Reminder
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// Notify implements Notifier.
+func (w Webhook) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(webhookBody{Reminder: r, Title: r.Title(), Message: r.Message()})
+	if err != nil {
+		return fmt.Errorf("failed to encode reminder: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post reminder: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to post reminder: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Email sends the reminders through an SMTP server (e.g., a local relay).
+type Email struct {
+	// Addr is the server's host:port.
+	Addr string
+	From string
+	To   []string
+	// Auth is optional.
+	Auth smtp.Auth
+}
+
+// Notify implements Notifier. The task's name is in the subject, so line
+// breaks are removed from it and it is encoded (RFC 2047) so that it can't
+// add headers.
+func (e Email) Notify(ctx context.Context, r Reminder) error {
+	for _, addr := range append([]string{e.From}, e.To...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("invalid email address %q", addr)
+		}
+	}
+	subject := mime.QEncoding.Encode("utf-8", strings.NewReplacer("\r", " ", "\n", " ").Replace(r.Title()))
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		e.From, strings.Join(e.To, ", "), subject, r.Message())
+	if err := smtp.SendMail(e.Addr, e.Auth, e.From, e.To, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package reminders_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poy/assistant/pkg/reminders"
+)
+
+// smtpStandIn accepts a single email and sends it to the channel.
+func smtpStandIn(t *testing.T) (string, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	emails := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { fmt.Fprintf(conn, "%s\r\n", s) }
+		reply("220 localhost ready")
+		for {
+			l, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(l)[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				emails <- data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+	return l.Addr().String(), emails
+}
+
+func TestNotifiers(t *testing.T) {
+	t.Parallel()
+
+	r := reminders.Reminder{
+		Task: "call mom",
+		Due:  time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC),
+		Kind: reminders.Overdue,
+	}
+
+	testCases := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{
+			name: "bell",
+			run: func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := (reminders.Bell{W: &buf}).Notify(context.Background(), r); err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := buf.String(), "\a"+r.Message()+"\n"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "webhook",
+			run: func(t *testing.T) {
+				bodies := make(chan map[string]any, 1)
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var body map[string]any
+					json.NewDecoder(r.Body).Decode(&body)
+					bodies <- body
+				}))
+				defer srv.Close()
+
+				if err := (reminders.Webhook{URL: srv.URL}).Notify(context.Background(), r); err != nil {
+					t.Fatal(err)
+				}
+				body := <-bodies
+				if body["task"] != "call mom" || body["kind"] != "overdue" || body["message"] != r.Message() {
+					t.Fatalf("unexpected body %v", body)
+				}
+			},
+		},
+		{
+			name: "webhook failure",
+			run: func(t *testing.T) {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				}))
+				defer srv.Close()
+
+				if err := (reminders.Webhook{URL: srv.URL}).Notify(context.Background(), r); err == nil {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+		{
+			name: "email",
+			run: func(t *testing.T) {
+				addr, emails := smtpStandIn(t)
+				email := reminders.Email{Addr: addr, From: "assistant@localhost", To: []string{"me@localhost"}}
+				if err := email.Notify(context.Background(), r); err != nil {
+					t.Fatal(err)
+				}
+				msg := <-emails
+				if !strings.Contains(msg, "Subject: Overdue: call mom\r\n") || !strings.Contains(msg, r.Message()) {
+					t.Fatalf("unexpected email %q", msg)
+				}
+			},
+		},
+		{
+			name: "email with line breaks in the task",
+			run: func(t *testing.T) {
+				addr, emails := smtpStandIn(t)
+				email := reminders.Email{Addr: addr, From: "assistant@localhost", To: []string{"me@localhost"}}
+				r := r
+				r.Task = "call mom\r\nBcc: someone@example.com"
+				if err := email.Notify(context.Background(), r); err != nil {
+					t.Fatal(err)
+				}
+				headers, _, _ := strings.Cut(<-emails, "\r\n\r\n")
+				if actual, expected := headers, "From: assistant@localhost\r\nTo: me@localhost\r\nSubject: Overdue: call mom  Bcc: someone@example.com\r\nContent-Type: text/plain; charset=utf-8"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "email with a non-ASCII task",
+			run: func(t *testing.T) {
+				addr, emails := smtpStandIn(t)
+				email := reminders.Email{Addr: addr, From: "assistant@localhost", To: []string{"me@localhost"}}
+				r := r
+				r.Task = "café"
+				if err := email.Notify(context.Background(), r); err != nil {
+					t.Fatal(err)
+				}
+				if msg := <-emails; !strings.Contains(msg, "Subject: =?utf-8?q?Overdue:_caf=C3=A9?=\r\n") {
+					t.Fatalf("unexpected email %q", msg)
+				}
+			},
+		},
+		{
+			name: "email with an invalid address",
+			run: func(t *testing.T) {
+				email := reminders.Email{Addr: "127.0.0.1:1", From: "assistant@localhost\r\nBcc: someone@example.com", To: []string{"me@localhost"}}
+				if err := email.Notify(context.Background(), r); err == nil {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+		{
+			name: "command",
+			run: func(t *testing.T) {
+				out := filepath.Join(t.TempDir(), "out")
+				cmd := reminders.Command{Path: "sh", Args: []string{"-c", `printf '%s|%s' "$0" "$1" > ` + out}}
+				if err := cmd.Notify(context.Background(), r); err != nil {
+					t.Fatal(err)
+				}
+				data, err := os.ReadFile(out)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := string(data), r.Title()+"|"+r.Message(); actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tc.run(t)
+		})
+	}
+}
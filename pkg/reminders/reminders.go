@@ -0,0 +1,285 @@
+// Package reminders nudges the user about tasks that are coming up or overdue.
+// A Scheduler checks the store every so often and sends each reminder to the
+// Notifiers once. A reminder that none of the Notifiers could send is tried
+// again at the next check.
+//
+// A task is reminded about at its reminder (see tasks.Task.SetReminder) and
+// again when it is due. Reminders can be snoozed and dismissed on the task, so
+// that works from any assistant sharing the store.
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poy/assistant/pkg/jsonfile"
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+// Clock tells the time. It is faked in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Kind is why the user is being reminded.
+type Kind string
+
+const (
+	// Upcoming is for a task's reminder before it is due.
+	Upcoming Kind = "upcoming"
+	// Overdue is for a task that is past its due date.
+	Overdue Kind = "overdue"
+)
+
+// Reminder is a nudge about a task.
+type Reminder struct {
+	Task string    `json:"task"`
+	Due  time.Time `json:"due"`
+	Kind Kind      `json:"kind"`
+	// Time is when the reminder was sent.
+	Time time.Time `json:"time"`
+}
+
+// Title is a short summary of the reminder.
+func (r Reminder) Title() string {
+	if r.Kind == Overdue {
+		return "Overdue: " + r.Task
+	}
+	return "Coming up: " + r.Task
+}
+
+// Message says when the task is due.
+func (r Reminder) Message() string {
+	if r.Kind == Overdue {
+		return fmt.Sprintf("%s was due %s", r.Task, r.Due.Format(time.RFC1123))
+	}
+	return fmt.Sprintf("%s is due in %s (%s)", r.Task, tasks.FormatBefore(r.Due.Sub(r.Time).Round(time.Minute)), r.Due.Format(time.RFC1123))
+}
+
+// Notifier sends reminders to the user.
+type Notifier interface {
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// Scheduler sends the reminders for the tasks in a store.
+type Scheduler struct {
+	s         tasks.Store
+	clock     Clock
+	interval  time.Duration
+	notifiers []Notifier
+
+	mu sync.Mutex
+	// sent are the reminders that were already sent. They are saved to
+	// sentPath, if it is set.
+	sent     map[string]bool
+	sentPath string
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithClock replaces the real clock.
+func WithClock(c Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+// WithInterval is how often the store is checked. It defaults to a minute.
+func WithInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.interval = d
+	}
+}
+
+// WithNotifier adds a notifier. Each reminder is sent to every notifier.
+func WithNotifier(n Notifier) Option {
+	return func(s *Scheduler) {
+		s.notifiers = append(s.notifiers, n)
+	}
+}
+
+// WithSentPath saves which reminders were sent to the file, so they aren't
+// sent again after a restart.
+func WithSentPath(p string) Option {
+	return func(s *Scheduler) {
+		s.sentPath = p
+	}
+}
+
+// New returns a Scheduler for the store.
+func New(s tasks.Store, opts ...Option) *Scheduler {
+	sc := &Scheduler{
+		s:        s,
+		clock:    realClock{},
+		interval: time.Minute,
+		sent:     map[string]bool{},
+	}
+	for _, o := range opts {
+		o(sc)
+	}
+	if err := sc.load(); err != nil {
+		slog.Warn("failed to load the sent reminders", "path", sc.sentPath, "error", err)
+	}
+	return sc
+}
+
+// Run checks the store until the context is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		if _, err := s.Check(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to send reminders", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.clock.After(s.interval):
+		}
+	}
+}
+
+// Check sends the reminders that are due now and returns the ones that were
+// sent.
+func (s *Scheduler) Check(ctx context.Context) ([]Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var (
+		reminders []Reminder
+		errs      []error
+	)
+	// Only the reminders that are still due are kept, as the others can't
+	// come back (see reminderFor).
+	sent := map[string]bool{}
+	for _, name := range s.s.TaskNames() {
+		t := s.s.GetTask(name)
+		if t == nil {
+			continue
+		}
+		r, key, ok := reminderFor(t, now)
+		if !ok {
+			continue
+		}
+		if s.sent[key] {
+			sent[key] = true
+			continue
+		}
+
+		if err := s.notify(ctx, r); err != nil {
+			// Try again at the next check.
+			errs = append(errs, err)
+			continue
+		}
+		sent[key] = true
+		reminders = append(reminders, r)
+	}
+
+	changed := len(sent) != len(s.sent) || len(reminders) > 0
+	s.sent = sent
+	if changed {
+		if err := s.save(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return reminders, errors.Join(errs...)
+}
+
+// notify sends the reminder to the notifiers. It only fails if none of them
+// could send it.
+func (s *Scheduler) notify(ctx context.Context, r Reminder) error {
+	if len(s.notifiers) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, n := range s.notifiers {
+		if err := n.Notify(ctx, r); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send the reminder for %q: %w", r.Task, err))
+		}
+	}
+	if len(errs) < len(s.notifiers) {
+		for _, err := range errs {
+			slog.WarnContext(ctx, "failed to send a reminder", "task", r.Task, "error", err)
+		}
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// load reads the sent reminders. A missing file means nothing was sent.
+func (s *Scheduler) load() error {
+	if s.sentPath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.sentPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the sent reminders: %w", err)
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("failed to decode the sent reminders %s: %w", s.sentPath, err)
+	}
+	for _, key := range keys {
+		s.sent[key] = true
+	}
+	return nil
+}
+
+// save writes the sent reminders.
+func (s *Scheduler) save() error {
+	if s.sentPath == "" {
+		return nil
+	}
+	keys := make([]string, 0, len(s.sent))
+	for key := range s.sent {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if err := jsonfile.Write(s.sentPath, keys); err != nil {
+		return fmt.Errorf("failed to save the sent reminders: %w", err)
+	}
+	return nil
+}
+
+// reminderFor returns the reminder for the task if there should be one now.
+// The key identifies the reminder so it is only sent once. Changing the due
+// date, reminder or snooze makes a new one.
+func reminderFor(t *tasks.Task, now time.Time) (Reminder, string, bool) {
+	due := t.Due()
+	if due.IsZero() || t.Completed() || t.Dismissed() {
+		return Reminder{}, "", false
+	}
+	snoozed := t.SnoozedUntil()
+	if now.Before(snoozed) {
+		return Reminder{}, "", false
+	}
+
+	r := Reminder{Task: t.Name(), Due: due, Kind: Overdue, Time: now}
+	before, _ := t.Reminder()
+	if now.Before(due) {
+		if now.Before(due.Add(-before)) {
+			return Reminder{}, "", false
+		}
+		r.Kind = Upcoming
+	}
+
+	key := fmt.Sprintf("%s|%d|%d|%d|%s", strings.ToLower(r.Task), due.UnixNano(), before, snoozed.UnixNano(), r.Kind)
+	return r, key, true
+}
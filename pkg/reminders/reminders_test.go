@@ -0,0 +1,345 @@
+package reminders_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poy/assistant/pkg/reminders"
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+// fakeClock only moves when it is advanced.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := waiter{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	return w.c
+}
+
+// Advance moves the clock and fires the waiters that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	var waiters []waiter
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = waiters
+}
+
+// Waiting returns how many are waiting for the clock.
+func (c *fakeClock) Waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// fakeNotifier records the reminders.
+type fakeNotifier struct {
+	mu        sync.Mutex
+	reminders []reminders.Reminder
+	err       error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, r reminders.Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.reminders = append(n.reminders, r)
+	return n.err
+}
+
+func (n *fakeNotifier) Sent() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var sent []string
+	for _, r := range n.reminders {
+		sent = append(sent, fmt.Sprintf("%s %s", r.Kind, r.Task))
+	}
+	return sent
+}
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// run makes the changes and checks with check, which returns what
+		// was sent.
+		run func(t *testing.T, s tasks.Store, clock *fakeClock, check func() string)
+	}{
+		{
+			name: "reminds before the task is due and when it is overdue",
+			run: func(t *testing.T, s tasks.Store, clock *fakeClock, check func() string) {
+				s.Add("call mom", "")
+				s.GetTask("call mom").SetDue(clock.Now().Add(2 * time.Hour))
+				s.GetTask("call mom").SetReminder(time.Hour)
+
+				for _, step := range []struct {
+					advance  time.Duration
+					expected string
+				}{
+					{advance: 0, expected: ""},
+					{advance: 59 * time.Minute, expected: ""},
+					{advance: time.Minute, expected: "upcoming call mom"},
+					{advance: time.Minute, expected: ""},
+					{advance: time.Hour, expected: "overdue call mom"},
+					{advance: time.Hour, expected: ""},
+				} {
+					clock.Advance(step.advance)
+					if actual := check(); actual != step.expected {
+						t.Fatalf("at %v, expected %q, got %q", clock.Now(), step.expected, actual)
+					}
+				}
+			},
+		},
+		{
+			name: "reminds when the task is due without a reminder",
+			run: func(t *testing.T, s tasks.Store, clock *fakeClock, check func() string) {
+				s.Add("call mom", "")
+				s.GetTask("call mom").SetDue(clock.Now().Add(time.Hour))
+
+				if actual := check(); actual != "" {
+					t.Fatalf("expected nothing, got %q", actual)
+				}
+				clock.Advance(time.Hour)
+				if actual, expected := check(), "overdue call mom"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "skips tasks without due dates, completed and dismissed tasks",
+			run: func(t *testing.T, s tasks.Store, clock *fakeClock, check func() string) {
+				for _, name := range []string{"no due date", "completed", "dismissed", "overdue"} {
+					s.Add(name, "")
+				}
+				for _, name := range []string{"completed", "dismissed", "overdue"} {
+					s.GetTask(name).SetDue(clock.Now().Add(-time.Hour))
+				}
+				s.GetTask("completed").Complete()
+				s.GetTask("dismissed").Dismiss()
+
+				if actual, expected := check(), "overdue overdue"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "snoozed reminders are sent again later",
+			run: func(t *testing.T, s tasks.Store, clock *fakeClock, check func() string) {
+				s.Add("call mom", "")
+				s.GetTask("call mom").SetDue(clock.Now())
+				if actual, expected := check(), "overdue call mom"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+
+				s.GetTask("call mom").Snooze(clock.Now().Add(30 * time.Minute))
+				clock.Advance(29 * time.Minute)
+				if actual := check(); actual != "" {
+					t.Fatalf("expected nothing, got %q", actual)
+				}
+				clock.Advance(time.Minute)
+				if actual, expected := check(), "overdue call mom"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "a new due date reminds again",
+			run: func(t *testing.T, s tasks.Store, clock *fakeClock, check func() string) {
+				s.Add("call mom", "")
+				s.GetTask("call mom").SetDue(clock.Now())
+				s.GetTask("call mom").Dismiss()
+				if actual := check(); actual != "" {
+					t.Fatalf("expected nothing, got %q", actual)
+				}
+
+				s.GetTask("call mom").SetDue(clock.Now().Add(time.Minute))
+				clock.Advance(time.Minute)
+				if actual, expected := check(), "overdue call mom"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := tasks.NewStore("")
+			clock := newFakeClock()
+			n := &fakeNotifier{}
+			sc := reminders.New(s, reminders.WithClock(clock), reminders.WithNotifier(n))
+
+			tc.run(t, s, clock, func() string {
+				before := len(n.Sent())
+				if _, err := sc.Check(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				return strings.Join(n.Sent()[before:], ",")
+			})
+		})
+	}
+}
+
+func TestSchedulerNotifierFailures(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		errs          []error
+		expectedError bool
+		expectedSent  int
+	}{
+		{name: "marks the reminder as sent if a notifier sent it", errs: []error{errors.New("some-error"), nil}, expectedSent: 1},
+		{name: "tries again if no notifier sent it", errs: []error{errors.New("some-error"), errors.New("other-error")}, expectedError: true, expectedSent: 2},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := tasks.NewStore("")
+			s.Add("call mom", "")
+			clock := newFakeClock()
+			s.GetTask("call mom").SetDue(clock.Now())
+
+			opts := []reminders.Option{reminders.WithClock(clock)}
+			var notifiers []*fakeNotifier
+			for _, err := range tc.errs {
+				n := &fakeNotifier{err: err}
+				notifiers = append(notifiers, n)
+				opts = append(opts, reminders.WithNotifier(n))
+			}
+			sc := reminders.New(s, opts...)
+
+			for i := 0; i < 2; i++ {
+				_, err := sc.Check(context.Background())
+				if actual, expected := err != nil, tc.expectedError; actual != expected {
+					t.Fatalf("expected an error %v, got %v", expected, err)
+				}
+			}
+			if actual, expected := len(notifiers[0].Sent()), tc.expectedSent; actual != expected {
+				t.Fatalf("expected %d attempts, got %d", expected, actual)
+			}
+		})
+	}
+}
+
+func TestSchedulerSentPath(t *testing.T) {
+	t.Parallel()
+
+	s := tasks.NewStore("")
+	s.Add("call mom", "")
+	clock := newFakeClock()
+	s.GetTask("call mom").SetDue(clock.Now())
+	p := filepath.Join(t.TempDir(), "sent.json")
+
+	for i, expected := range []int{1, 0} {
+		// A new scheduler each time, as if the daemon restarted.
+		n := &fakeNotifier{}
+		sc := reminders.New(s, reminders.WithClock(clock), reminders.WithNotifier(n), reminders.WithSentPath(p))
+		if _, err := sc.Check(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if actual := len(n.Sent()); actual != expected {
+			t.Fatalf("run %d: expected %d reminders, got %v", i, expected, n.Sent())
+		}
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	t.Parallel()
+
+	s := tasks.NewStore("")
+	s.Add("call mom", "")
+	clock := newFakeClock()
+	s.GetTask("call mom").SetDue(clock.Now().Add(5 * time.Minute))
+
+	n := &fakeNotifier{}
+	sc := reminders.New(s,
+		reminders.WithClock(clock),
+		reminders.WithInterval(time.Minute),
+		reminders.WithNotifier(n),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- sc.Run(ctx) }()
+
+	for i := 0; i < 5; i++ {
+		waitFor(t, func() bool { return clock.Waiting() == 1 })
+		if actual := n.Sent(); len(actual) != 0 {
+			t.Fatalf("expected nothing, got %v", actual)
+		}
+		clock.Advance(time.Minute)
+	}
+	waitFor(t, func() bool { return len(n.Sent()) == 1 })
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReminderMessage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	r := reminders.Reminder{Task: "call mom", Due: now.Add(90 * time.Minute), Kind: reminders.Upcoming, Time: now}
+	if actual, expected := r.Message(), "call mom is due in 1h30m (Mon, 01 May 2023 10:30:00 UTC)"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	r.Kind = reminders.Overdue
+	if actual, expected := r.Title(), "Overdue: call mom"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func waitFor(t *testing.T, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
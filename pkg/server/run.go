@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/sessions"
+	"github.com/poy/assistant/pkg/tools/userinput"
+)
+
+// run is a goal being worked on for a request. It records the events and
+// shows the output by sending them to the request's stream.
+type run struct {
+	id     string
+	user   string
+	ctx    context.Context
+	asker  *userinput.Channel
+	events chan event
+
+	mu      sync.Mutex
+	pending *userinput.Question
+}
+
+// event is a server-sent event.
+type event struct {
+	name string
+	data any
+}
+
+// Record implements sessions.Recorder.
+func (r *run) Record(e sessions.Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	r.send(event{name: string(e.Type), data: e})
+}
+
+// Show implements display.Sink.
+func (r *run) Show(ctx context.Context, o display.Output) error {
+	r.send(event{name: "display", data: o})
+	return nil
+}
+
+// send waits for the event to be streamed, unless the request is gone.
+func (r *run) send(e event) {
+	select {
+	case r.events <- e:
+	case <-r.ctx.Done():
+	}
+}
+
+func (r *run) setPending(q userinput.Question) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = &q
+}
+
+func (r *run) takePending() (userinput.Question, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		return userinput.Question{}, false
+	}
+	q := *r.pending
+	r.pending = nil
+	return q, true
+}
@@ -0,0 +1,462 @@
+// Package server exposes the tasks and the agents over a local HTTP API.
+//
+// The tasks are managed with REST endpoints:
+//
+//	GET    /tasks                 list the tasks
+//	POST   /tasks                 add a task ({"name": ..., "description": ...})
+//	GET    /tasks/{name}          get a task
+//	DELETE /tasks/{name}          remove a task
+//	POST   /tasks/{name}/notes    add a note ({"note": ...})
+//	POST   /tasks/{name}/complete complete the task
+//	POST   /tasks/{name}/assign   assign the task ({"assignee": ...}, empty to unassign)
+//
+// An assignment fails with 409 Conflict if the task isn't assigned to
+// "expected_assignee" anymore (when it is given), so a client doesn't
+// overwrite someone else's assignment.
+//
+// Goals are given to the TaskAgent with POST /ask ({"goal": ...}). The
+// response is a stream of server-sent events with the thoughts, tool calls,
+// observations and final answer of the agents. When an agent asks the user a
+// question, a "question" event is sent and the client answers it with
+// POST /ask/{id}/answer ({"answer": ...}).
+//
+// With WithTokens, each request has to have an Authorization header with one
+// of the bearer tokens. With WithTenants, the token's user only sees their
+// own tasks and the X-Assistant-Team header switches to a shared team list
+// instead. The tokens are required with WithTenants: nothing else says who
+// a request is from, so without them every request is rejected.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poy/assistant/pkg/auth"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/sessions"
+	assistanttools "github.com/poy/assistant/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/assistant/pkg/tools/userinput"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// Server is an http.Handler for the API.
+type Server struct {
+	store   tasks.Store
+	agent   assistanttools.Agent[string]
+	timeout time.Duration
+	tenants *tasks.Tenants
+	tokens  auth.Tokens
+	mux     *http.ServeMux
+
+	mu   sync.Mutex
+	runs map[string]*run
+	next int
+}
+
+// Option configures the Server.
+type Option func(*Server)
+
+// WithTimeout limits how long each goal can run for. Zero means there isn't a
+// deadline.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// WithTenants gives each user their own tasks. It requires WithTokens. See
+// TeamHeader.
+func WithTenants(t *tasks.Tenants) Option {
+	return func(s *Server) {
+		s.tenants = t
+	}
+}
+
+// WithTokens requires each request to have one of the bearer tokens. The
+// request is from the token's user.
+func WithTokens(t auth.Tokens) Option {
+	return func(s *Server) {
+		s.tokens = t
+	}
+}
+
+// TeamHeader is the team list a request is for. The user has to be a member
+// of the team.
+const TeamHeader = "X-Assistant-Team"
+
+// New returns a Server for the Store and TaskAgent resolved from the context.
+func New(ctx context.Context, opts ...Option) *Server {
+	s := &Server{
+		store: injection.Resolve[tasks.Store](ctx),
+		agent: injection.Resolve[tasks.TaskAgent](ctx).Agent,
+		mux:   http.NewServeMux(),
+		runs:  map[string]*run{},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+
+	s.mux.HandleFunc("/tasks", s.handleTasks)
+	s.mux.HandleFunc("/tasks/", s.handleTask)
+	s.mux.HandleFunc("/ask", s.handleAsk)
+	s.mux.HandleFunc("/ask/", s.handleAnswer)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(s.tokens) > 0 || s.tenants != nil {
+		user, err := s.tokens.User(r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+	}
+	if s.tenants != nil {
+		ctx, err := s.tenants.WithUser(r.Context(), userFor(r), r.Header.Get(TeamHeader))
+		switch {
+		case errors.Is(err, tasks.ErrNotMember):
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		case err != nil:
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		r = r.WithContext(ctx)
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+type userKey struct{}
+
+// userFor returns the authenticated user of the request. It is empty without
+// WithTokens.
+func userFor(r *http.Request) string {
+	user, _ := r.Context().Value(userKey{}).(string)
+	return user
+}
+
+// storeFor returns the tasks the request is for.
+func (s *Server) storeFor(r *http.Request) tasks.Store {
+	return tasks.StoreFor(r.Context(), s.store)
+}
+
+// Task is the JSON representation of a task.
+type Task struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Notes       []Note     `json:"notes,omitempty"`
+	Assignee    string     `json:"assignee,omitempty"`
+	Watchers    []string   `json:"watchers,omitempty"`
+}
+
+// Note is the JSON representation of a note.
+type Note struct {
+	Time time.Time `json:"time"`
+	Note string    `json:"note"`
+}
+
+func toTask(t *tasks.Task) Task {
+	result := Task{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Completed:   t.Completed(),
+		Assignee:    t.Assignee(),
+		Watchers:    t.Watchers(),
+	}
+	if t.Completed() {
+		at := t.CompletedAt()
+		result.CompletedAt = &at
+	}
+	for _, n := range t.Notes() {
+		result.Notes = append(result.Notes, Note{Time: n.Datetime(), Note: n.Note()})
+	}
+	return result
+}
+
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	store := s.storeFor(r)
+	switch r.Method {
+	case http.MethodGet:
+		result := []Task{}
+		for _, name := range store.TaskNames() {
+			if t := store.GetTask(name); t != nil {
+				result = append(result, toTask(t))
+			}
+		}
+		writeJSON(w, http.StatusOK, result)
+	case http.MethodPost:
+		var req struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		}
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if strings.TrimSpace(req.Name) == "" {
+			writeError(w, http.StatusBadRequest, "a name is required")
+			return
+		}
+		t, err := store.Create(req.Name, req.Description)
+		switch {
+		case errors.Is(err, tasks.ErrExists):
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, toTask(t))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/tasks/")
+	action := ""
+	for _, a := range []string{"notes", "complete", "assign"} {
+		if n, ok := strings.CutSuffix(name, "/"+a); ok {
+			name, action = n, a
+			break
+		}
+	}
+
+	store := s.storeFor(r)
+	t := store.GetTask(name)
+	if t == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("task %q not found", name))
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, toTask(t))
+	case action == "" && r.Method == http.MethodDelete:
+		store.Remove(name)
+		w.WriteHeader(http.StatusNoContent)
+	case action == "notes" && r.Method == http.MethodPost:
+		var req struct {
+			Note string `json:"note"`
+		}
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if strings.TrimSpace(req.Note) == "" {
+			writeError(w, http.StatusBadRequest, "a note is required")
+			return
+		}
+		t.AddNotes(req.Note)
+		writeJSON(w, http.StatusOK, toTask(t))
+	case action == "assign" && r.Method == http.MethodPost:
+		// Clients that read the task earlier say who they saw it assigned
+		// to, otherwise it is who it was assigned to when it was looked up.
+		var req struct {
+			Assignee string  `json:"assignee"`
+			Expected *string `json:"expected_assignee"`
+		}
+		seen := t.Assignee()
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if req.Expected != nil {
+			seen = *req.Expected
+		}
+		if err := t.Assign(seen, strings.TrimSpace(req.Assignee)); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, tasks.ErrConflict) {
+				status = http.StatusConflict
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, toTask(t))
+	case action == "complete" && r.Method == http.MethodPost:
+		if !t.Completed() {
+			t.Complete()
+		}
+		writeJSON(w, http.StatusOK, toTask(t))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) handleAsk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		Goal string `json:"goal"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Goal) == "" {
+		writeError(w, http.StatusBadRequest, "a goal is required")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	ctx := r.Context()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	run := s.startRun(ctx, userFor(r))
+	defer s.endRun(run.id)
+
+	// The agents record, display and ask questions through this request.
+	// The injected Recorder, Sink, Asker and Seen are shared by every
+	// request, so they are overridden on the context instead (the same as
+	// the gRPC and chat servers do).
+	ctx = sessions.WithRecorder(ctx, run)
+	ctx = display.WithSink(ctx, run)
+	ctx = userinput.WithAsker(ctx, run.asker)
+	ctx = tasks.WithSeen(ctx, tasks.NewSeen())
+
+	type result struct {
+		answer string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		run.Record(sessions.Event{Type: sessions.EventUserInput, Text: req.Goal})
+		answer, err := s.agent.Run(ctx, req.Goal)
+		done <- result{answer: answer, err: err}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, data any) {
+		writeEvent(w, event, data)
+		flusher.Flush()
+	}
+	send("run", map[string]string{"id": run.id})
+
+	for {
+		select {
+		case e := <-run.events:
+			send(e.name, e.data)
+		case q := <-run.asker.Questions():
+			run.setPending(q)
+			send("question", map[string]string{"id": run.id, "question": q.Text})
+		case res := <-done:
+			// Everything was recorded before the run finished because
+			// recording waits for the event to be sent.
+			if res.err != nil {
+				if ctx.Err() != nil {
+					res.err = fmt.Errorf("stopped the goal: %w", ctx.Err())
+				}
+				send("error", map[string]string{"error": res.err.Error()})
+				return
+			}
+			send("done", map[string]string{"answer": res.answer})
+			return
+		}
+	}
+}
+
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/ask/"), "/answer")
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.Lock()
+	run := s.runs[id]
+	s.mu.Unlock()
+	// Users can only answer their own questions.
+	if run == nil || run.user != userFor(r) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("run %q not found", id))
+		return
+	}
+
+	var req struct {
+		Answer string `json:"answer"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	q, ok := run.takePending()
+	if !ok {
+		writeError(w, http.StatusConflict, "there isn't a question to answer")
+		return
+	}
+	q.Answer(req.Answer)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) startRun(ctx context.Context, user string) *run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	r := &run{
+		id:     fmt.Sprintf("%d-%d", time.Now().Unix(), s.next),
+		user:   user,
+		ctx:    ctx,
+		asker:  userinput.NewChannel(),
+		events: make(chan event),
+	}
+	s.runs[r.id] = r
+	return r
+}
+
+func (s *Server) endRun(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.runs, id)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeEvent(w http.ResponseWriter, event string, data any) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		b, _ = json.Marshal(map[string]string{"error": err.Error()})
+		event = "error"
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+}
@@ -0,0 +1,423 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/auth"
+	"github.com/poy/assistant/pkg/server"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+
+	// Register the fake LLM.
+	_ "github.com/poy/assistant/pkg/testing"
+)
+
+func TestTasks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		assert         func(t *testing.T, body string, s tasks.Store)
+	}{
+		{
+			name:           "lists the tasks",
+			method:         http.MethodGet,
+			path:           "/tasks",
+			expectedStatus: http.StatusOK,
+			assert: func(t *testing.T, body string, s tasks.Store) {
+				var result []server.Task
+				if err := json.Unmarshal([]byte(body), &result); err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(result), 1; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := result[0].Name, "some-task"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:           "adds a task",
+			method:         http.MethodPost,
+			path:           "/tasks",
+			body:           `{"name": "other-task", "description": "other-description"}`,
+			expectedStatus: http.StatusCreated,
+			assert: func(t *testing.T, body string, s tasks.Store) {
+				if actual, expected := s.GetTask("other-task").Description(), "other-description"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:           "adding an existing task conflicts",
+			method:         http.MethodPost,
+			path:           "/tasks",
+			body:           `{"name": "some-task"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "gets a task",
+			method:         http.MethodGet,
+			path:           "/tasks/some-task",
+			expectedStatus: http.StatusOK,
+			assert: func(t *testing.T, body string, s tasks.Store) {
+				if !strings.Contains(body, `"description":"some-description"`) {
+					t.Fatalf("expected the description, got %s", body)
+				}
+			},
+		},
+		{
+			name:           "unknown task",
+			method:         http.MethodGet,
+			path:           "/tasks/unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "adds a note",
+			method:         http.MethodPost,
+			path:           "/tasks/some-task/notes",
+			body:           `{"note": "some-note"}`,
+			expectedStatus: http.StatusOK,
+			assert: func(t *testing.T, body string, s tasks.Store) {
+				notes := s.GetTask("some-task").Notes()
+				if len(notes) != 1 || notes[0].Note() != "some-note" {
+					t.Fatalf("expected the note, got %v", notes)
+				}
+			},
+		},
+		{
+			name:           "completes a task",
+			method:         http.MethodPost,
+			path:           "/tasks/some-task/complete",
+			expectedStatus: http.StatusOK,
+			assert: func(t *testing.T, body string, s tasks.Store) {
+				if !s.GetTask("some-task").Completed() {
+					t.Fatal("expected the task to be completed")
+				}
+			},
+		},
+		{
+			name:           "assigns a task",
+			method:         http.MethodPost,
+			path:           "/tasks/some-task/assign",
+			body:           `{"assignee": "alex", "expected_assignee": ""}`,
+			expectedStatus: http.StatusOK,
+			assert: func(t *testing.T, body string, s tasks.Store) {
+				if actual, expected := s.GetTask("some-task").Assignee(), "alex"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:           "assigning a task that was assigned since conflicts",
+			method:         http.MethodPost,
+			path:           "/tasks/some-task/assign",
+			body:           `{"assignee": "alex", "expected_assignee": "sam"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "removes a task",
+			method:         http.MethodDelete,
+			path:           "/tasks/some-task",
+			expectedStatus: http.StatusNoContent,
+			assert: func(t *testing.T, body string, s tasks.Store) {
+				if s.GetTask("some-task") != nil {
+					t.Fatal("expected the task to be removed")
+				}
+			},
+		},
+		{
+			name:           "invalid body",
+			method:         http.MethodPost,
+			path:           "/tasks",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			s := injection.Resolve[tasks.Store](ctx)
+			s.Add("some-task", "some-description")
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			server.New(ctx).ServeHTTP(rec, req)
+
+			if actual, expected := rec.Code, tc.expectedStatus; actual != expected {
+				t.Fatalf("expected %d, got %d (%s)", expected, actual, rec.Body)
+			}
+			if tc.assert != nil {
+				tc.assert(t, rec.Body.String(), s)
+			}
+		})
+	}
+}
+
+func TestAddConcurrently(t *testing.T) {
+	t.Parallel()
+	ctx := injectiontesting.WithTesting(t)
+	srv := server.New(ctx)
+
+	const requests = 10
+	codes := make(chan int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"name": "some-task"}`))
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			codes <- rec.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	created := 0
+	for code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if actual, expected := created, 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+type sse struct {
+	event string
+	data  string
+}
+
+func readEvents(t *testing.T, body string) []sse {
+	t.Helper()
+	var events []sse
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	var e sse
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			events = append(events, e)
+			e = sse{}
+		}
+	}
+	return events
+}
+
+func TestAsk(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		setup  func(llm *llmstesting.Fake[vertex.Params])
+		assert func(t *testing.T, events []sse)
+	}{
+		{
+			name: "streams the final answer",
+			setup: func(llm *llmstesting.Fake[vertex.Params]) {
+				llm.AlwaysText = `{"thought": "I know the answer", "final_answer": "some-answer"}`
+			},
+			assert: func(t *testing.T, events []sse) {
+				var names []string
+				for _, e := range events {
+					names = append(names, e.event)
+				}
+				if actual, expected := strings.Join(names, ","), "run,user_input,thought,final_answer,done"; actual != expected {
+					t.Fatalf("expected %q, got %q (%v)", expected, actual, events)
+				}
+				if actual, expected := events[len(events)-1].data, `{"answer":"some-answer"}`; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "streams the error",
+			setup: func(llm *llmstesting.Fake[vertex.Params]) {
+				llm.Err = errors.New("some-error")
+			},
+			assert: func(t *testing.T, events []sse) {
+				if actual, expected := events[len(events)-1].event, "error"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			llm := injection.Resolve[llms.LLM[vertex.Params]](ctx).(*llmstesting.Fake[vertex.Params])
+			tc.setup(llm)
+
+			srv := httptest.NewServer(server.New(ctx))
+			defer srv.Close()
+
+			resp, err := http.Post(srv.URL+"/ask", "application/json", strings.NewReader(`{"goal": "some-goal"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var body strings.Builder
+			if _, err := bufio.NewReader(resp.Body).WriteTo(&body); err != nil {
+				t.Fatal(err)
+			}
+			tc.assert(t, readEvents(t, body.String()))
+		})
+	}
+}
+
+func TestAnswerWithoutQuestion(t *testing.T) {
+	t.Parallel()
+	ctx := injectiontesting.WithTesting(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/ask/unknown/answer", strings.NewReader(`{"answer": "yes"}`))
+	rec := httptest.NewRecorder()
+	server.New(ctx).ServeHTTP(rec, req)
+
+	if actual, expected := rec.Code, http.StatusNotFound; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestTenants(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		token          string
+		team           string
+		noTokens       bool
+		expectedStatus int
+		expectedTasks  []string
+	}{
+		{
+			name:           "lists the user's tasks",
+			token:          "alice-token",
+			expectedStatus: http.StatusOK,
+			expectedTasks:  []string{"alice-task"},
+		},
+		{
+			name:           "doesn't show other users' tasks",
+			token:          "bob-token",
+			expectedStatus: http.StatusOK,
+			expectedTasks:  []string{},
+		},
+		{
+			name:           "lists the team's tasks",
+			token:          "bob-token",
+			team:           "platform",
+			expectedStatus: http.StatusOK,
+			expectedTasks:  []string{"team-task"},
+		},
+		{
+			name:           "requires a token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "rejects an unknown token",
+			token:          "alice",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "rejects everything without tokens",
+			token:          "alice-token",
+			noTokens:       true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "requires team membership",
+			token:          "mallory-token",
+			team:           "platform",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			tenants := tasks.NewTenants("", map[string][]string{"platform": {"alice", "bob"}})
+			alice, _ := tenants.UserStore("alice")
+			alice.Add("alice-task", "")
+			team, _ := tenants.TeamStore("alice", "platform")
+			team.Add("team-task", "")
+
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			if tc.team != "" {
+				req.Header.Set(server.TeamHeader, tc.team)
+			}
+			rec := httptest.NewRecorder()
+			opts := []server.Option{server.WithTenants(tenants)}
+			if !tc.noTokens {
+				opts = append(opts, server.WithTokens(auth.Tokens{
+					"alice-token":   "alice",
+					"bob-token":     "bob",
+					"mallory-token": "mallory",
+				}))
+			}
+			server.New(ctx, opts...).ServeHTTP(rec, req)
+
+			if actual, expected := rec.Code, tc.expectedStatus; actual != expected {
+				t.Fatalf("expected %d, got %d (%s)", expected, actual, rec.Body)
+			}
+			if tc.expectedTasks == nil {
+				return
+			}
+
+			var result []server.Task
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, task := range result {
+				names = append(names, task.Name)
+			}
+			if actual, expected := strings.Join(names, ","), strings.Join(tc.expectedTasks, ","); actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
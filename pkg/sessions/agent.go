@@ -0,0 +1,67 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/tools"
+)
+
+type agentRecorder[TOut any] struct {
+	p     predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]]
+	r     Recorder
+	agent string
+}
+
+// NewAgentRecorder chains a Predictor that records the thoughts, tool calls
+// and final answers of the given agent.
+func NewAgentRecorder[TOut any](
+	p predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]],
+	r Recorder,
+	agent string,
+) predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]] {
+	return agentRecorder[TOut]{
+		p:     p,
+		r:     r,
+		agent: agent,
+	}
+}
+
+// Predict implements Predictor.
+func (a agentRecorder[TOut]) Predict(
+	ctx context.Context,
+	req agents.PromptData[TOut],
+) (agents.Reasoning[TOut], error) {
+	r := recorderFor(ctx, a.r)
+	resp, err := a.p.Predict(ctx, req)
+	if err != nil {
+		r.Record(Event{Type: EventError, Agent: a.agent, Text: err.Error()})
+		return resp, err
+	}
+
+	r.Record(Event{Type: EventThought, Agent: a.agent, Text: resp.Thought})
+	if resp.Action != "" {
+		r.Record(Event{Type: EventToolCall, Agent: a.agent, Action: resp.Action, Input: resp.Input})
+	} else {
+		r.Record(Event{Type: EventFinalAnswer, Agent: a.agent, Text: fmt.Sprint(resp.FinalAnswer)})
+	}
+	return resp, nil
+}
+
+// RecordTool wraps the tool so that its observations are recorded.
+func RecordTool(t tools.Tool, r Recorder, agent string) tools.Tool {
+	run := t.Run
+	t.Run = func(ctx context.Context, input string) (string, error) {
+		r := recorderFor(ctx, r)
+		observation, err := run(ctx, input)
+		if err != nil {
+			r.Record(Event{Type: EventError, Agent: agent, Action: t.Name, Text: err.Error()})
+			return observation, err
+		}
+		r.Record(Event{Type: EventObservation, Agent: agent, Action: t.Name, Text: observation})
+		return observation, nil
+	}
+	return t
+}
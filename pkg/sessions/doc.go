@@ -0,0 +1,3 @@
+// Package sessions records what happens during a conversation with the
+// assistant so that it can be looked at or resumed later.
+package sessions
@@ -0,0 +1,282 @@
+package sessions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// EventType is the kind of event that was recorded.
+type EventType string
+
+const (
+	// EventUserInput is what the user typed in.
+	EventUserInput EventType = "user_input"
+	// EventThought is a thought from an agent.
+	EventThought EventType = "thought"
+	// EventToolCall is an agent deciding to use a tool.
+	EventToolCall EventType = "tool_call"
+	// EventObservation is the result of a tool.
+	EventObservation EventType = "observation"
+	// EventFinalAnswer is the final answer from an agent.
+	EventFinalAnswer EventType = "final_answer"
+	// EventError is an error from a tool or an agent.
+	EventError EventType = "error"
+)
+
+// Event is a single entry in a session transcript.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Type   EventType `json:"type"`
+	Agent  string    `json:"agent,omitempty"`
+	Action string    `json:"action,omitempty"`
+	Input  string    `json:"input,omitempty"`
+	Text   string    `json:"text,omitempty"`
+}
+
+// Recorder records events for a session.
+type Recorder interface {
+	// Record saves the event.
+	Record(e Event)
+}
+
+// Dir is the directory the sessions are saved to.
+type Dir string
+
+// ProvideRecorder records the session events with the given Recorder.
+func ProvideRecorder(r Recorder) {
+	injection.Register[Recorder](
+		func(ctx context.Context) Recorder {
+			return r
+		},
+	)
+}
+
+func init() {
+	// By default, nothing is recorded.
+	ProvideRecorder(NopRecorder{})
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context that records the events with the given Recorder.
+func WithRecorder(ctx context.Context, r Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// recorderFor returns the Recorder from the context, or r if there isn't one.
+func recorderFor(ctx context.Context, r Recorder) Recorder {
+	if cr, ok := ctx.Value(recorderKey{}).(Recorder); ok {
+		return cr
+	}
+	return r
+}
+
+// NopRecorder doesn't record anything.
+type NopRecorder struct{}
+
+// Record implements Recorder.
+func (NopRecorder) Record(Event) {}
+
+// Session is a transcript saved to disk.
+type Session struct {
+	ID   string
+	Path string
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	f   *os.File
+}
+
+// New creates a new Session in the given directory.
+func New(dir Dir) (*Session, error) {
+	if err := os.MkdirAll(string(dir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+
+	id := time.Now().Format("20060102-150405")
+	for i := 1; ; i++ {
+		if _, err := os.Stat(sessionPath(dir, id)); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), i)
+	}
+	return Open(dir, id)
+}
+
+// Open opens an existing (or new) Session so more events can be appended.
+func Open(dir Dir, id string) (*Session, error) {
+	p := sessionPath(dir, id)
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session %s: %w", id, err)
+	}
+
+	return &Session{
+		ID:   id,
+		Path: p,
+		enc:  json.NewEncoder(f),
+		f:    f,
+	}, nil
+}
+
+// Record implements Recorder.
+func (s *Session) Record(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(e); err != nil {
+		slog.Warn("failed to record the session event", "error", err)
+	}
+}
+
+// Close closes the underlying file.
+func (s *Session) Close() error {
+	return s.f.Close()
+}
+
+// Info describes a saved session.
+type Info struct {
+	ID        string
+	Started   time.Time
+	Events    int
+	FirstGoal string
+}
+
+// List returns the saved sessions, the most recent first.
+func List(dir Dir) ([]Info, error) {
+	entries, err := os.ReadDir(string(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+
+	var infos []Info
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".jsonl" {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), ".jsonl")
+		events, err := Load(dir, id)
+		if err != nil {
+			return nil, err
+		}
+
+		info := Info{
+			ID:     id,
+			Events: len(events),
+		}
+		if len(events) > 0 {
+			info.Started = events[0].Time
+		}
+		for _, e := range events {
+			if e.Type == EventUserInput {
+				info.FirstGoal = e.Text
+				break
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Started.After(infos[j].Started)
+	})
+	return infos, nil
+}
+
+// Load reads the events of the given session.
+func Load(dir Dir, id string) ([]Event, error) {
+	f, err := os.Open(sessionPath(dir, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("unknown session %q", id)
+		}
+		return nil, fmt.Errorf("failed to open session %s: %w", id, err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to decode session %s: %w", id, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", id, err)
+	}
+	return events, nil
+}
+
+// Turn is a single exchange between the user and the assistant.
+type Turn struct {
+	UserInput string
+	Answer    string
+}
+
+// Conversation reduces the events to what the user said and what the
+// assistant answered. Nested agents answer before the root agent does, so the
+// last final answer before the next user input is the one the user saw.
+func Conversation(events []Event) []Turn {
+	var turns []Turn
+	for _, e := range events {
+		switch e.Type {
+		case EventUserInput:
+			turns = append(turns, Turn{UserInput: e.Text})
+		case EventFinalAnswer:
+			if len(turns) > 0 {
+				turns[len(turns)-1].Answer = e.Text
+			}
+		case EventError:
+			// Only errors that made it all the way to the user.
+			if len(turns) > 0 && e.Agent == "" {
+				turns[len(turns)-1].Answer = "ERROR: " + e.Text
+			}
+		}
+	}
+	return turns
+}
+
+// HistoryPrompt gives the agent the last maxTurns turns of the conversation
+// along with the goal so that the user can refer back to them.
+func HistoryPrompt(history []Turn, goal string, maxTurns int) string {
+	if len(history) == 0 {
+		return goal
+	}
+	if len(history) > maxTurns {
+		history = history[len(history)-maxTurns:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Previous conversation:\n")
+	for _, t := range history {
+		fmt.Fprintf(&sb, "User: %s\nAI: %s\n", t.UserInput, t.Answer)
+	}
+	fmt.Fprintf(&sb, "\nCurrent request: %s", goal)
+	return sb.String()
+}
+
+func sessionPath(dir Dir, id string) string {
+	return filepath.Join(string(dir), id+".jsonl")
+}
@@ -0,0 +1,140 @@
+package sessions_test
+
+import (
+	"testing"
+
+	"github.com/poy/assistant/pkg/sessions"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		setup  func(*testing.T, sessions.Dir)
+		assert func(*testing.T, sessions.Dir)
+	}{
+		{
+			name: "no sessions",
+			assert: func(t *testing.T, dir sessions.Dir) {
+				infos, err := sessions.List(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(infos), 0; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "records and loads events",
+			setup: func(t *testing.T, dir sessions.Dir) {
+				s, err := sessions.Open(dir, "some-id")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer s.Close()
+				s.Record(sessions.Event{Type: sessions.EventUserInput, Text: "some-goal"})
+				s.Record(sessions.Event{Type: sessions.EventToolCall, Agent: "Tasks", Action: "add", Input: "some-input"})
+				s.Record(sessions.Event{Type: sessions.EventFinalAnswer, Agent: "Tasks", Text: "some-answer"})
+			},
+			assert: func(t *testing.T, dir sessions.Dir) {
+				infos, err := sessions.List(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(infos), 1; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := infos[0].ID, "some-id"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := infos[0].Events, 3; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := infos[0].FirstGoal, "some-goal"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+
+				events, err := sessions.Load(dir, "some-id")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := events[1].Action, "add"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := events[1].Time.IsZero(), false; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name: "resumed session appends",
+			setup: func(t *testing.T, dir sessions.Dir) {
+				for _, goal := range []string{"first-goal", "second-goal"} {
+					s, err := sessions.Open(dir, "some-id")
+					if err != nil {
+						t.Fatal(err)
+					}
+					s.Record(sessions.Event{Type: sessions.EventUserInput, Text: goal})
+					s.Close()
+				}
+			},
+			assert: func(t *testing.T, dir sessions.Dir) {
+				events, err := sessions.Load(dir, "some-id")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(events), 2; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "unknown session",
+			assert: func(t *testing.T, dir sessions.Dir) {
+				if _, err := sessions.Load(dir, "unknown"); err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := sessions.Dir(t.TempDir())
+
+			if tc.setup != nil {
+				tc.setup(t, dir)
+			}
+			tc.assert(t, dir)
+		})
+	}
+}
+
+func TestConversation(t *testing.T) {
+	t.Parallel()
+
+	turns := sessions.Conversation([]sessions.Event{
+		{Type: sessions.EventUserInput, Text: "first-goal"},
+		{Type: sessions.EventFinalAnswer, Agent: "TaskFinder", Text: "some-task"},
+		{Type: sessions.EventObservation, Agent: "TaskFinder", Action: "user-input", Text: "some-answer"},
+		{Type: sessions.EventFinalAnswer, Agent: "Tasks", Text: "first-answer"},
+		{Type: sessions.EventUserInput, Text: "second-goal"},
+		{Type: sessions.EventError, Agent: "Tasks", Text: "tool-error"},
+		{Type: sessions.EventError, Text: "run-error"},
+	})
+
+	if actual, expected := len(turns), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := turns[0], (sessions.Turn{UserInput: "first-goal", Answer: "first-answer"}); actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := turns[1], (sessions.Turn{UserInput: "second-goal", Answer: "ERROR: run-error"}); actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,134 @@
+package taskfile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+// csvHeader are the columns. Times are RFC 3339, watchers are separated by
+// semicolons and each note is a line with its time and text.
+var csvHeader = []string{"id", "name", "description", "created", "due", "completed", "assignee", "watchers", "notes"}
+
+func encodeCSV(w io.Writer, items []Item) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, item := range items {
+		var notes []string
+		for _, n := range item.Notes {
+			notes = append(notes, formatTime(n.Time)+" "+strings.ReplaceAll(n.Note, "\n", " "))
+		}
+		if err := cw.Write([]string{
+			item.ID,
+			item.Name,
+			item.Description,
+			formatTime(item.Created),
+			formatTime(item.Due),
+			formatTime(item.CompletedAt),
+			item.Assignee,
+			strings.Join(item.Watchers, ";"),
+			strings.Join(notes, "\n"),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// decodeCSV reads the columns by their name in the header, so they can be in
+// any order and only the name is needed.
+func decodeCSV(r io.Reader) ([]Item, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, h := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("the CSV doesn't have a name column")
+	}
+
+	var items []Item
+	for n, record := range records[1:] {
+		get := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		item := Item{
+			ID: get("id"),
+			Data: tasks.Data{
+				Name:        get("name"),
+				Description: get("description"),
+				Assignee:    get("assignee"),
+			},
+		}
+		if item.Name == "" {
+			return nil, fmt.Errorf("row %d: the task doesn't have a name", n+2)
+		}
+		for _, w := range strings.Split(get("watchers"), ";") {
+			if w = strings.TrimSpace(w); w != "" {
+				item.Watchers = append(item.Watchers, w)
+			}
+		}
+		for column, t := range map[string]*time.Time{
+			"created":   &item.Created,
+			"due":       &item.Due,
+			"completed": &item.CompletedAt,
+		} {
+			if *t, err = parseTime(get(column)); err != nil {
+				return nil, fmt.Errorf("row %d: %s: %w", n+2, column, err)
+			}
+		}
+		for _, l := range strings.Split(get("notes"), "\n") {
+			if l = strings.TrimSpace(l); l == "" {
+				continue
+			}
+			ts, text, _ := strings.Cut(l, " ")
+			t, err := parseTime(ts)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: notes: %w", n+2, err)
+			}
+			item.Notes = append(item.Notes, tasks.NoteData{Time: t, Note: text})
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// parseTime parses RFC 3339 or what parseDay does. An empty string is the
+// zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return parseDay(s)
+}
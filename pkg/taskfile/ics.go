@@ -0,0 +1,54 @@
+package taskfile
+
+import (
+	"io"
+
+	"github.com/poy/assistant/pkg/ical"
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+// encodeICS writes a VTODO per task. The notes are comments.
+func encodeICS(w io.Writer, items []Item) error {
+	var todos []ical.Todo
+	for _, item := range items {
+		todo := ical.Todo{
+			UID:         item.ID,
+			Summary:     item.Name,
+			Description: item.Description,
+			Created:     item.Created,
+			Due:         item.Due,
+			Completed:   item.CompletedAt,
+		}
+		for _, n := range item.Notes {
+			todo.Comments = append(todo.Comments, ical.Comment{Time: n.Time, Text: n.Note})
+		}
+		todos = append(todos, todo)
+	}
+	return ical.Encode(w, todos...)
+}
+
+func decodeICS(r io.Reader) ([]Item, error) {
+	todos, err := ical.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, todo := range todos {
+		item := Item{
+			ID: todo.UID,
+			Data: tasks.Data{
+				Name:        todo.Summary,
+				Description: todo.Description,
+				Created:     todo.Created,
+				Due:         todo.Due,
+				CompletedAt: todo.Completed,
+			},
+		}
+		for _, c := range todo.Comments {
+			item.Notes = append(item.Notes, tasks.NoteData{Time: c.Time, Note: c.Text})
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
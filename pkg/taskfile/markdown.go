@@ -0,0 +1,117 @@
+package taskfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+var (
+	// checklistItem is a task (e.g., "- [x] buy milk"). Items can be nested
+	// and use any list marker.
+	checklistItem = regexp.MustCompile(`^\s*[-*+] \[([ xX])\] (.+)$`)
+	// noteItem is a note under a task (e.g., "  - _2023-05-01 10:00_ the
+	// store was closed").
+	noteItem = regexp.MustCompile(`^- _([^_]+)_ (.*)$`)
+)
+
+// encodeMarkdown writes a checklist (e.g., "- [ ] buy milk"). Each task's
+// fields are indented under it: "Due:", "Completed:" and "Assignee:" lines,
+// then the description, then the notes as a list (e.g., "- _2023-05-01 10:00_
+// the store was closed").
+func encodeMarkdown(w io.Writer, items []Item) error {
+	bw := bufio.NewWriter(w)
+	for _, item := range items {
+		check := " "
+		if !item.CompletedAt.IsZero() {
+			check = "x"
+		}
+		fmt.Fprintf(bw, "- [%s] %s\n", check, item.Name)
+		if !item.Due.IsZero() {
+			fmt.Fprintf(bw, "  Due: %s\n", formatDay(item.Due))
+		}
+		if !item.CompletedAt.IsZero() {
+			fmt.Fprintf(bw, "  Completed: %s\n", formatDay(item.CompletedAt))
+		}
+		if item.Assignee != "" {
+			fmt.Fprintf(bw, "  Assignee: %s\n", item.Assignee)
+		}
+		for _, l := range strings.Split(strings.TrimSpace(item.Description), "\n") {
+			if l != "" {
+				fmt.Fprintf(bw, "  %s\n", l)
+			}
+		}
+		for _, n := range item.Notes {
+			fmt.Fprintf(bw, "  - _%s_ %s\n", n.Time.Local().Format(minute), strings.ReplaceAll(n.Note, "\n", " "))
+		}
+	}
+	return bw.Flush()
+}
+
+func decodeMarkdown(r io.Reader) ([]Item, error) {
+	var (
+		items       []Item
+		item        *Item
+		description []string
+	)
+	done := func() {
+		if item != nil {
+			item.Description = strings.Join(description, "\n")
+			items = append(items, *item)
+		}
+		item, description = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		l := scanner.Text()
+		if m := checklistItem.FindStringSubmatch(l); m != nil {
+			done()
+			item = &Item{Data: tasks.Data{Name: strings.TrimSpace(m[2])}}
+			if m[1] != " " {
+				item.CompletedAt = time.Now()
+			}
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		// Anything that isn't indented (e.g., a heading) isn't part of the
+		// task.
+		if item == nil || !strings.HasPrefix(l, " ") && !strings.HasPrefix(l, "\t") {
+			done()
+			continue
+		}
+
+		l = strings.TrimSpace(l)
+		var err error
+		switch {
+		case strings.HasPrefix(l, "Due: "):
+			item.Due, err = parseDay(strings.TrimPrefix(l, "Due: "))
+		case strings.HasPrefix(l, "Completed: "):
+			item.CompletedAt, err = parseDay(strings.TrimPrefix(l, "Completed: "))
+		case strings.HasPrefix(l, "Assignee: "):
+			item.Assignee = strings.TrimPrefix(l, "Assignee: ")
+		case noteItem.MatchString(l):
+			m := noteItem.FindStringSubmatch(l)
+			var t time.Time
+			t, err = parseDay(m[1])
+			item.Notes = append(item.Notes, tasks.NoteData{Time: t, Note: m[2]})
+		default:
+			description = append(description, l)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	done()
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Markdown: %w", err)
+	}
+	return items, nil
+}
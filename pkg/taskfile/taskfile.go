@@ -0,0 +1,182 @@
+// Package taskfile moves tasks in and out of the store with standard task
+// formats: todo.txt, iCalendar VTODO (.ics), Markdown checklists and CSV.
+//
+// Not every format has every field. todo.txt doesn't have descriptions or
+// notes, and Markdown and todo.txt only keep the day something was done.
+package taskfile
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+// Format is a task file format.
+type Format string
+
+const (
+	TodoTxt  Format = "todotxt"
+	ICS      Format = "ics"
+	Markdown Format = "md"
+	CSV      Format = "csv"
+)
+
+// Formats are the supported formats.
+var Formats = []Format{TodoTxt, ICS, Markdown, CSV}
+
+// ParseFormat returns the format with the name.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(name, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q, expected one of %v", name, Formats)
+}
+
+// FormatFor returns the format for the file's extension.
+func FormatFor(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt":
+		return TodoTxt, nil
+	case ".ics":
+		return ICS, nil
+	case ".md", ".markdown":
+		return Markdown, nil
+	case ".csv":
+		return CSV, nil
+	default:
+		return "", fmt.Errorf("can't tell the format of %s, expected one of %v", path, Formats)
+	}
+}
+
+// Item is a task in a file.
+type Item struct {
+	// ID is from the file. It is empty if the format doesn't have IDs.
+	ID string
+	tasks.Data
+}
+
+// Items returns the tasks in the store.
+func Items(s tasks.Store) []Item {
+	var items []Item
+	for _, name := range s.TaskNames() {
+		t := s.GetTask(name)
+		if t == nil {
+			continue
+		}
+		d := t.Data()
+		items = append(items, Item{ID: d.ID(), Data: d})
+	}
+	return items
+}
+
+// Encode writes the items in the format.
+func Encode(w io.Writer, f Format, items []Item) error {
+	switch f {
+	case TodoTxt:
+		return encodeTodoTxt(w, items)
+	case ICS:
+		return encodeICS(w, items)
+	case Markdown:
+		return encodeMarkdown(w, items)
+	case CSV:
+		return encodeCSV(w, items)
+	default:
+		return fmt.Errorf("unknown format %q", f)
+	}
+}
+
+// Decode reads the items in the format.
+func Decode(r io.Reader, f Format) ([]Item, error) {
+	switch f {
+	case TodoTxt:
+		return decodeTodoTxt(r)
+	case ICS:
+		return decodeICS(r)
+	case Markdown:
+		return decodeMarkdown(r)
+	case CSV:
+		return decodeCSV(r)
+	default:
+		return nil, fmt.Errorf("unknown format %q", f)
+	}
+}
+
+// ImportResult is what an import did (or would do).
+type ImportResult struct {
+	Added []string
+	// Skipped are the items that are already in the store (by name or ID),
+	// or that are in the file more than once.
+	Skipped []string
+}
+
+// Import adds the items to the store. Items that are already in the store are
+// skipped. If dryRun is true, the store isn't changed.
+func Import(s tasks.Store, items []Item, dryRun bool) (ImportResult, error) {
+	names := map[string]bool{}
+	ids := map[string]bool{}
+	for _, item := range Items(s) {
+		names[strings.ToLower(item.Name)] = true
+		ids[item.ID] = true
+	}
+
+	var result ImportResult
+	for i, item := range items {
+		if strings.TrimSpace(item.Name) == "" {
+			return result, fmt.Errorf("item %d doesn't have a name", i+1)
+		}
+		if names[strings.ToLower(item.Name)] || (item.ID != "" && ids[item.ID]) {
+			result.Skipped = append(result.Skipped, item.Name)
+			continue
+		}
+		names[strings.ToLower(item.Name)] = true
+		if item.ID != "" {
+			ids[item.ID] = true
+		}
+		result.Added = append(result.Added, item.Name)
+		if dryRun {
+			continue
+		}
+
+		s.Add(item.Name, item.Description)
+		t := s.GetTask(item.Name)
+		if t == nil {
+			return result, fmt.Errorf("failed to add task %q", item.Name)
+		}
+		if err := t.SetData(item.Data); err != nil {
+			return result, fmt.Errorf("failed to import task %q: %w", item.Name, err)
+		}
+	}
+	return result, nil
+}
+
+// day is how the formats without times write dates.
+const day = "2006-01-02"
+
+// minute is how the formats for people write times.
+const minute = "2006-01-02 15:04"
+
+// formatDay writes the time as a day if it is midnight, otherwise to the
+// minute.
+func formatDay(t time.Time) string {
+	t = t.Local()
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return t.Format(day)
+	}
+	return t.Format(minute)
+}
+
+// parseDay parses what formatDay writes, in local time.
+func parseDay(s string) (time.Time, error) {
+	for _, layout := range []string{minute, day} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
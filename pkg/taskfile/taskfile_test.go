@@ -0,0 +1,272 @@
+package taskfile_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poy/assistant/pkg/taskfile"
+	"github.com/poy/assistant/pkg/tools/tasks"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	item := taskfile.Item{
+		ID: "assistant-1",
+		Data: tasks.Data{
+			Name:        "buy milk",
+			Description: "2%, not skim",
+			Created:     time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local),
+			Due:         time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local),
+			CompletedAt: time.Date(2023, 5, 2, 0, 0, 0, 0, time.Local),
+			Notes: []tasks.NoteData{
+				{Time: time.Date(2023, 5, 1, 10, 30, 0, 0, time.Local), Note: "the store was closed"},
+			},
+			Assignee: "alex",
+			Watchers: []string{"sam", "kim"},
+		},
+	}
+	open := taskfile.Item{Data: tasks.Data{Name: "call the dentist"}}
+
+	testCases := []struct {
+		format taskfile.Format
+		// expected is what the format keeps of the item.
+		expected taskfile.Item
+	}{
+		{
+			format: taskfile.TodoTxt,
+			expected: taskfile.Item{ID: item.ID, Data: tasks.Data{
+				Name:        item.Name,
+				Created:     item.Created,
+				Due:         item.Due,
+				CompletedAt: item.CompletedAt,
+				Assignee:    item.Assignee,
+			}},
+		},
+		{
+			format: taskfile.ICS,
+			expected: taskfile.Item{ID: item.ID, Data: tasks.Data{
+				Name:        item.Name,
+				Description: item.Description,
+				Created:     item.Created,
+				Due:         item.Due,
+				CompletedAt: item.CompletedAt,
+				Notes:       item.Notes,
+			}},
+		},
+		{
+			format: taskfile.Markdown,
+			expected: taskfile.Item{Data: tasks.Data{
+				Name:        item.Name,
+				Description: item.Description,
+				Due:         item.Due,
+				CompletedAt: item.CompletedAt,
+				Notes:       item.Notes,
+				Assignee:    item.Assignee,
+			}},
+		},
+		{
+			format:   taskfile.CSV,
+			expected: item,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(string(tc.format), func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if err := taskfile.Encode(&buf, tc.format, []taskfile.Item{item, open}); err != nil {
+				t.Fatal(err)
+			}
+			items, err := taskfile.Decode(&buf, tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 2 {
+				t.Fatalf("expected 2 items, got %d", len(items))
+			}
+			if actual, expected := normalize(items[0]), normalize(tc.expected); !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+			if actual, expected := items[1].Name, open.Name; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if !items[1].CompletedAt.IsZero() {
+				t.Fatal("expected the task to not be completed")
+			}
+		})
+	}
+}
+
+// normalize makes the times comparable with reflect.DeepEqual.
+func normalize(item taskfile.Item) taskfile.Item {
+	for _, t := range []*time.Time{&item.Created, &item.Due, &item.CompletedAt} {
+		if !t.IsZero() {
+			*t = t.UTC()
+		}
+	}
+	for i := range item.Notes {
+		item.Notes[i].Time = item.Notes[i].Time.UTC()
+	}
+	return item
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		format taskfile.Format
+		input  string
+		assert func(t *testing.T, items []taskfile.Item, err error)
+	}{
+		{
+			name:   "todo.txt priorities and projects",
+			format: taskfile.TodoTxt,
+			input:  "(A) 2023-05-01 call mom +family @phone due:2023-05-03\nx buy milk\n\n",
+			assert: func(t *testing.T, items []taskfile.Item, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := items[0].Name, "call mom +family @phone"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := items[0].Due, time.Date(2023, 5, 3, 0, 0, 0, 0, time.Local); !actual.Equal(expected) {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+				if items[1].CompletedAt.IsZero() {
+					t.Fatal("expected the task to be completed")
+				}
+			},
+		},
+		{
+			name:   "invalid todo.txt due date",
+			format: taskfile.TodoTxt,
+			input:  "call mom due:tomorrow\n",
+			assert: func(t *testing.T, items []taskfile.Item, err error) {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+		{
+			name:   "Markdown with other content",
+			format: taskfile.Markdown,
+			input:  "# Groceries\n\nSome notes.\n\n* [X] milk\n  - [ ] eggs\n\n    from the farm\nThe end.\n",
+			assert: func(t *testing.T, items []taskfile.Item, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(items) != 2 {
+					t.Fatalf("expected 2 items, got %+v", items)
+				}
+				if items[0].Name != "milk" || items[0].CompletedAt.IsZero() {
+					t.Fatalf("unexpected item %+v", items[0])
+				}
+				if items[1].Name != "eggs" || items[1].Description != "from the farm" {
+					t.Fatalf("unexpected item %+v", items[1])
+				}
+			},
+		},
+		{
+			name:   "CSV columns in any order",
+			format: taskfile.CSV,
+			input:  "Due,Name\n2023-06-01,buy milk\n",
+			assert: func(t *testing.T, items []taskfile.Item, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if items[0].Name != "buy milk" || !items[0].Due.Equal(time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local)) {
+					t.Fatalf("unexpected item %+v", items[0])
+				}
+			},
+		},
+		{
+			name:   "CSV without names",
+			format: taskfile.CSV,
+			input:  "description\nsomething\n",
+			assert: func(t *testing.T, items []taskfile.Item, err error) {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			items, err := taskfile.Decode(strings.NewReader(tc.input), tc.format)
+			tc.assert(t, items, err)
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		dryRun bool
+		assert func(t *testing.T, s tasks.Store)
+	}{
+		{
+			name: "adds the new tasks",
+			assert: func(t *testing.T, s tasks.Store) {
+				task := s.GetTask("call mom")
+				if task == nil {
+					t.Fatal("expected the task")
+				}
+				if task.Description() != "about Sunday" || task.Assignee() != "alex" {
+					t.Fatalf("unexpected task %+v", task.Data())
+				}
+			},
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+			assert: func(t *testing.T, s tasks.Store) {
+				if s.GetTask("call mom") != nil {
+					t.Fatal("expected the task to not be added")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := tasks.NewStore("")
+			s.Add("buy milk", "")
+			items := []taskfile.Item{
+				{Data: tasks.Data{Name: "Buy Milk"}},
+				// The same task, renamed since it was exported.
+				{ID: taskfile.Items(s)[0].ID, Data: tasks.Data{Name: "buy oat milk"}},
+				{Data: tasks.Data{Name: "call mom", Description: "about Sunday", Assignee: "alex"}},
+				{Data: tasks.Data{Name: "call mom"}},
+			}
+
+			result, err := taskfile.Import(s, items, tc.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := strings.Join(result.Added, ","), "call mom"; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if actual, expected := strings.Join(result.Skipped, ","), "Buy Milk,buy oat milk,call mom"; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			tc.assert(t, s)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package taskfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// priority is a todo.txt priority (e.g., "(A)"). The store doesn't have
+// priorities, so they are dropped.
+var priority = regexp.MustCompile(`^\([A-Z]\)$`)
+
+// encodeTodoTxt writes a line per task (see
+// https://github.com/todotxt/todo.txt). The due date, ID and assignee are
+// key:value tags.
+func encodeTodoTxt(w io.Writer, items []Item) error {
+	bw := bufio.NewWriter(w)
+	for _, item := range items {
+		var parts []string
+		if !item.CompletedAt.IsZero() {
+			parts = append(parts, "x", item.CompletedAt.Local().Format(day))
+		}
+		if !item.Created.IsZero() {
+			parts = append(parts, item.Created.Local().Format(day))
+		}
+		parts = append(parts, item.Name)
+		if !item.Due.IsZero() {
+			parts = append(parts, "due:"+item.Due.Local().Format(day))
+		}
+		if item.Assignee != "" {
+			parts = append(parts, "assignee:"+strings.ReplaceAll(item.Assignee, " ", "_"))
+		}
+		if item.ID != "" {
+			parts = append(parts, "id:"+item.ID)
+		}
+		fmt.Fprintln(bw, strings.Join(parts, " "))
+	}
+	return bw.Flush()
+}
+
+func decodeTodoTxt(r io.Reader) ([]Item, error) {
+	var items []Item
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var item Item
+		if fields[0] == "x" {
+			fields = fields[1:]
+			item.CompletedAt = time.Now()
+			if t, ok := leadingDay(fields); ok {
+				item.CompletedAt, fields = t, fields[1:]
+			}
+		} else if priority.MatchString(fields[0]) {
+			fields = fields[1:]
+		}
+		if t, ok := leadingDay(fields); ok {
+			item.Created, fields = t, fields[1:]
+		}
+
+		var name []string
+		for _, f := range fields {
+			k, v, ok := strings.Cut(f, ":")
+			if !ok || v == "" {
+				name = append(name, f)
+				continue
+			}
+			switch k {
+			case "due":
+				t, err := parseDay(v)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", n, err)
+				}
+				item.Due = t
+			case "id":
+				item.ID = v
+			case "assignee":
+				item.Assignee = strings.ReplaceAll(v, "_", " ")
+			default:
+				name = append(name, f)
+			}
+		}
+		item.Name = strings.Join(name, " ")
+		if item.Name == "" {
+			return nil, fmt.Errorf("line %d: the task doesn't have a name", n)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo.txt: %w", err)
+	}
+	return items, nil
+}
+
+func leadingDay(fields []string) (time.Time, bool) {
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(day, fields[0], time.Local)
+	return t, err == nil
+}
@@ -0,0 +1,80 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	gotesting "testing"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/cassette"
+)
+
+// RecordEnv is the environment variable to set to a GCP project ID to record
+// the cassettes against the real LLM instead of replaying them.
+const RecordEnv = "ASSISTANT_RECORD_CASSETTES"
+
+// recordEndpoint is the LLM that the cassettes are recorded against.
+const recordEndpoint = "us-central1-aiplatform.googleapis.com"
+
+// WithCassette returns a context whose injection containers all use the LLM
+// interactions in the cassette at the given path. The test fails if a prompt
+// isn't in the cassette or if some of the cassette isn't used, which means
+// the prompts or the flow changed and the cassette needs to be recorded
+// again (see RecordEnv).
+func WithCassette(ctx context.Context, t gotesting.TB, p string) context.Context {
+	t.Helper()
+
+	if project := os.Getenv(RecordEnv); project != "" {
+		llm, err := vertex.New(ctx, recordEndpoint, project)
+		if err != nil {
+			t.Fatalf("failed to create LLM to record %s: %v", p, err)
+		}
+		r := cassette.NewRecorder(llm)
+		t.Cleanup(func() {
+			if t.Failed() {
+				t.Logf("not saving %s because the test failed", p)
+				return
+			}
+			if err := r.Cassette().Save(p); err != nil {
+				t.Error(err)
+			}
+		})
+		return WithLLM(ctx, r)
+	}
+
+	c, err := cassette.Load(p)
+	if err != nil {
+		t.Fatalf("%v (set %s to record it)", err, RecordEnv)
+	}
+	r := cassette.NewReplayer[vertex.Params](c)
+	t.Cleanup(func() {
+		if unplayed := r.Unplayed(); len(unplayed) > 0 && !t.Failed() {
+			t.Errorf("%d of the interactions in %s weren't replayed (set %s to record it again)", len(unplayed), p, RecordEnv)
+		}
+	})
+	return WithLLM(ctx, &loudLLM{llm: r, t: t, path: p})
+}
+
+// loudLLM fails the test as soon as a prompt isn't in the cassette. The
+// agents retry and fall back on errors, so the error alone could go unseen.
+// Only the first prompt is reported, as the retries would just repeat it.
+type loudLLM struct {
+	llm  llms.LLM[vertex.Params]
+	t    gotesting.TB
+	path string
+	once sync.Once
+}
+
+// Generate implements llms.LLM.
+func (l *loudLLM) Generate(ctx context.Context, prompt string, params vertex.Params) (string, error) {
+	resp, err := l.llm.Generate(ctx, prompt, params)
+	if errors.Is(err, cassette.ErrUnrecorded) {
+		l.once.Do(func() {
+			l.t.Errorf("%s: %v (set %s to record it again)", l.path, err, RecordEnv)
+		})
+	}
+	return resp, err
+}
@@ -6,9 +6,27 @@ import (
 	"github.com/google/go-react/pkg/llms"
 	llmstesting "github.com/google/go-react/pkg/llms/testing"
 	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/display"
+	assistanttools "github.com/poy/assistant/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/userinput"
 	"github.com/poy/go-dependency-injection/pkg/injection"
 )
 
+type llmKey struct{}
+
+// WithFakeLLM returns a context whose injection containers all use the given
+// fake LLM. This is useful when the code under test creates its own
+// containers.
+func WithFakeLLM(ctx context.Context, f *llmstesting.Fake[vertex.Params]) context.Context {
+	return WithLLM(ctx, f)
+}
+
+// WithLLM returns a context whose injection containers all use the given
+// LLM (e.g., a ScriptedLLM).
+func WithLLM(ctx context.Context, llm llms.LLM[vertex.Params]) context.Context {
+	return context.WithValue(ctx, llmKey{}, llm)
+}
+
 func init() {
 	injection.Register[vertex.Params](
 		func(ctx context.Context) vertex.Params {
@@ -17,7 +35,20 @@ func init() {
 	)
 	injection.Register[llms.LLM[vertex.Params]](
 		func(ctx context.Context) llms.LLM[vertex.Params] {
-			return &llmstesting.Fake[vertex.Params]{}
+			if llm, ok := ctx.Value(llmKey{}).(llms.LLM[vertex.Params]); ok {
+				return assistanttools.LLMFor(ctx, llm)
+			}
+			return assistanttools.LLMFor[vertex.Params](ctx, &llmstesting.Fake[vertex.Params]{})
+		},
+	)
+	injection.Register[display.Sink](
+		func(ctx context.Context) display.Sink {
+			return &display.Buffer{}
+		},
+	)
+	injection.Register[userinput.Asker](
+		func(ctx context.Context) userinput.Asker {
+			return &userinput.Scripted{}
 		},
 	)
 }
@@ -0,0 +1,158 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/vertex"
+	assistanttools "github.com/poy/assistant/pkg/tools"
+)
+
+// ErrOutOfScript is returned by the ScriptedLLM for a prompt that doesn't
+// match any of the rules once the script has run out.
+var ErrOutOfScript = errors.New("the scripted LLM ran out of responses")
+
+// ScriptedLLM is a fake LLM for multi-turn agent runs. The responses come
+// from the rules (see On) and then from the script (see Then), in order. It
+// records every prompt. It is safe to use from multiple goroutines.
+type ScriptedLLM struct {
+	mu      sync.Mutex
+	rules   []rule
+	script  []response
+	prompts []Prompt
+}
+
+var _ llms.LLM[vertex.Params] = (*ScriptedLLM)(nil)
+
+// Prompt is a prompt the ScriptedLLM received.
+type Prompt struct {
+	// Agent is the name of the agent that sent the prompt. It is empty for
+	// the predictors.
+	Agent string
+	Text  string
+}
+
+type rule struct {
+	agent    string
+	contains string
+	response
+}
+
+type response struct {
+	text string
+	err  error
+}
+
+// NewScriptedLLM returns a ScriptedLLM that returns the responses in order.
+func NewScriptedLLM(responses ...string) *ScriptedLLM {
+	s := &ScriptedLLM{}
+	for _, r := range responses {
+		s.Then(r)
+	}
+	return s
+}
+
+// Then adds the response to the end of the script.
+func (s *ScriptedLLM) Then(text string) *ScriptedLLM {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, response{text: text})
+	return s
+}
+
+// ThenErr adds a failure to the end of the script.
+func (s *ScriptedLLM) ThenErr(err error) *ScriptedLLM {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, response{err: err})
+	return s
+}
+
+// On returns the response every time the agent (see
+// assistanttools.WithName) sends a prompt that has the given text. An empty
+// agent matches any agent and the predictors, and an empty text matches
+// every prompt. The rules are checked in the order they were added and
+// before the script.
+func (s *ScriptedLLM) On(agent, contains, text string) *ScriptedLLM {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, rule{agent: agent, contains: contains, response: response{text: text}})
+	return s
+}
+
+// OnErr is like On, but the LLM fails.
+func (s *ScriptedLLM) OnErr(agent, contains string, err error) *ScriptedLLM {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, rule{agent: agent, contains: contains, response: response{err: err}})
+	return s
+}
+
+// Generate implements llms.LLM.
+func (s *ScriptedLLM) Generate(ctx context.Context, prompt string, params vertex.Params) (string, error) {
+	agent := assistanttools.AgentName(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prompts = append(s.prompts, Prompt{Agent: agent, Text: prompt})
+
+	for _, r := range s.rules {
+		if (r.agent == "" || r.agent == agent) && strings.Contains(prompt, r.contains) {
+			return r.text, r.err
+		}
+	}
+	if len(s.script) == 0 {
+		return "", fmt.Errorf("%w (prompt %d from %q)", ErrOutOfScript, len(s.prompts), agent)
+	}
+	r := s.script[0]
+	s.script = s.script[1:]
+	return r.text, r.err
+}
+
+// Prompts returns the prompts that were received, in order.
+func (s *ScriptedLLM) Prompts() []Prompt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Prompt(nil), s.prompts...)
+}
+
+// PromptsFrom returns the prompts the agent sent, in order.
+func (s *ScriptedLLM) PromptsFrom(agent string) []string {
+	var result []string
+	for _, p := range s.Prompts() {
+		if p.Agent == agent {
+			result = append(result, p.Text)
+		}
+	}
+	return result
+}
+
+// Remaining returns how many responses are left in the script.
+func (s *ScriptedLLM) Remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.script)
+}
+
+// Action is an agent's response that uses the tool.
+func Action(thought, action, input string) string {
+	return reasoning(map[string]string{"thought": thought, "action": action, "input": input})
+}
+
+// Final is an agent's response with the final answer.
+func Final(answer string) string {
+	return reasoning(map[string]string{"thought": "I know the answer", "final_answer": answer})
+}
+
+func reasoning(m map[string]string) string {
+	data, err := json.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
@@ -2,8 +2,9 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
 	"reflect"
 
 	"github.com/google/go-react/pkg/agents"
@@ -12,33 +13,82 @@ import (
 	"github.com/google/go-react/pkg/predictors"
 	"github.com/google/go-react/pkg/prompters"
 	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/logging"
+	"github.com/poy/assistant/pkg/sessions"
+	"github.com/poy/assistant/pkg/tracing"
+	"github.com/poy/assistant/pkg/usage"
 	"github.com/poy/go-dependency-injection/pkg/injection"
 )
 
+// ErrMaxIterations is returned when an agent reasons for too long without
+// coming up with a final answer.
+var ErrMaxIterations = errors.New("reached the maximum number of reasoning iterations")
+
+// DefaultMaxIterations is used when the MaxIterations are not provided.
+const DefaultMaxIterations = 10
+
+// MaxIterations is the maximum number of reasoning iterations an agent can
+// go through for a single run.
+type MaxIterations int
+
+// ProvideMaxIterations sets the maximum number of reasoning iterations for
+// every agent that doesn't set its own.
+func ProvideMaxIterations(n int) {
+	injection.Register[MaxIterations](
+		func(ctx context.Context) MaxIterations {
+			return MaxIterations(n)
+		},
+	)
+}
+
+// Agent is an agent built by AgentBuilder.
+type Agent[TOut any] interface {
+	// Run reasons and uses the tools until it has the final answer for the
+	// goal.
+	Run(ctx context.Context, goal string) (TOut, error)
+}
+
 // Option is an option for the agent.
 type Option[TOut any] func(*agentBuilder[TOut])
 
 type agentBuilder[TOut any] struct {
-	name     string
-	preamble string
-	rules    []string
-	examples []agents.PromptDataExample[TOut]
+	name          string
+	preamble      string
+	rules         []string
+	examples      []agents.PromptDataExample[TOut]
+	maxIterations int
+	logger        *slog.Logger
 }
 
 // AgentBuilder builds an agent.
 func AgentBuilder[TOut, TToolGroup any](
 	ctx context.Context,
 	opts ...Option[TOut],
-) agents.Agent[TOut] {
+) Agent[TOut] {
 	b := &agentBuilder[TOut]{
 		name: "Agent",
 	}
+	if n, ok := injection.TryResolve[MaxIterations](ctx); ok {
+		b.maxIterations = int(n)
+	} else {
+		b.maxIterations = DefaultMaxIterations
+	}
+	if l, ok := injection.TryResolve[*slog.Logger](ctx); ok {
+		b.logger = l
+	} else {
+		b.logger = slog.Default()
+	}
 	for _, o := range opts {
 		o(b)
 	}
+	logger := b.logger.With("agent", b.name)
 
 	params := injection.Resolve[vertex.Params](ctx)
-	llm := injection.Resolve[llms.LLM[vertex.Params]](ctx)
+	var llm llms.LLM[vertex.Params] = agentLLM[vertex.Params]{
+		llm:  injection.Resolve[llms.LLM[vertex.Params]](ctx),
+		name: b.name,
+	}
+	llm = usage.NewLLM(tracing.NewLLM(llm, b.name), b.name)
 
 	var promptOpts []prompters.Option[agents.PromptData[TOut]]
 	if b.preamble != "" {
@@ -55,11 +105,18 @@ func AgentBuilder[TOut, TToolGroup any](
 		params,
 		promptOpts...,
 	)
-	prompt = prompters.NewLogger(prompt, os.Stderr)
+	prompt = logging.NewPrompter(prompt, logger)
 	parser := agents.NewDefaultParser[TOut]()
 	predictor := predictors.New(llm, prompt, parser)
 	predictor = predictors.NewRetrier(predictor)
-	predictor = agents.NewCLILogger(predictor, os.Stderr, agents.WithCLILoggerPrefix[TOut](b.name))
+	predictor = limitPredictor[TOut]{
+		p:             predictor,
+		maxIterations: b.maxIterations,
+	}
+	predictor = logging.NewAgentLogger(predictor, logger)
+	recorder := injection.Resolve[sessions.Recorder](ctx)
+	predictor = sessions.NewAgentRecorder(predictor, recorder, b.name)
+	predictor = tracing.NewPredictor(predictor, b.name)
 	ts := injection.Resolve[injection.Group[TToolGroup]](ctx).Vals()
 
 	var toolSet []tools.Tool
@@ -74,10 +131,57 @@ type MyTool struct {
   tools.Tool
 }`, t))
 		}
-		toolSet = append(toolSet, *tool)
+		toolSet = append(toolSet, usage.TrackTool(tracing.Tool(sessions.RecordTool(*tool, recorder, b.name), b.name)))
+	}
+
+	return tracedAgent[TOut]{
+		agent: agents.NewAgent(predictor, toolSet...),
+		name:  b.name,
+	}
+}
+
+// tracedAgent makes each run of the agent a span.
+type tracedAgent[TOut any] struct {
+	agent agents.Agent[TOut]
+	name  string
+}
+
+// Run implements Agent.
+func (a tracedAgent[TOut]) Run(ctx context.Context, goal string) (TOut, error) {
+	ctx, span := tracing.Start(ctx, "agent.run", tracing.AgentKey.String(a.name), tracing.InputSizeKey.Int(len(goal)))
+	result, err := a.agent.Run(ctx, goal)
+	if err == nil {
+		span.SetAttributes(tracing.OutputSizeKey.Int(len(fmt.Sprint(result))))
 	}
+	tracing.End(span, err)
+	return result, err
+}
+
+// limitPredictor stops the agent once the context is done, it has gone
+// through too many iterations or the goal's token budget is used up.
+// Otherwise the agent would keep reasoning after the run has been cancelled
+// or when it is stuck in a loop. None of the errors are retried, so the
+// agent's Run returns them.
+type limitPredictor[TOut any] struct {
+	p             predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]]
+	maxIterations int
+}
 
-	return agents.NewAgent(predictor, toolSet...)
+// Predict implements Predictor.
+func (l limitPredictor[TOut]) Predict(
+	ctx context.Context,
+	req agents.PromptData[TOut],
+) (agents.Reasoning[TOut], error) {
+	if err := ctx.Err(); err != nil {
+		return agents.Reasoning[TOut]{}, err
+	}
+	if l.maxIterations > 0 && len(req.Chains) >= l.maxIterations {
+		return agents.Reasoning[TOut]{}, fmt.Errorf("%w (%d)", ErrMaxIterations, l.maxIterations)
+	}
+	if err := usage.CheckBudget(ctx); err != nil {
+		return agents.Reasoning[TOut]{}, err
+	}
+	return l.p.Predict(ctx, req)
 }
 
 // WithName sets the name for the agent.
@@ -101,6 +205,22 @@ func WithRules[TOut any](rules []string) Option[TOut] {
 	}
 }
 
+// WithMaxIterations sets the maximum number of reasoning iterations for a
+// single run of the agent. Zero means there isn't a limit.
+func WithMaxIterations[TOut any](n int) Option[TOut] {
+	return func(b *agentBuilder[TOut]) {
+		b.maxIterations = n
+	}
+}
+
+// WithLogger sets the logger for the agent's prompts and thoughts instead of
+// the one that was provided (see logging.ProvideLogger).
+func WithLogger[TOut any](l *slog.Logger) Option[TOut] {
+	return func(b *agentBuilder[TOut]) {
+		b.logger = l
+	}
+}
+
 // WithExamples sets the examples for the agent.
 func WithExamples[TOut any](examples []agents.PromptDataExample[TOut]) Option[TOut] {
 	return func(b *agentBuilder[TOut]) {
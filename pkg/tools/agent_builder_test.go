@@ -0,0 +1,217 @@
+package tools_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/logging"
+	assistanttesting "github.com/poy/assistant/pkg/testing"
+	assistanttools "github.com/poy/assistant/pkg/tools"
+	"github.com/poy/assistant/pkg/usage"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+type testTool struct {
+	tools.Tool
+}
+
+func init() {
+	injection.Register[injection.Group[testTool]](func(ctx context.Context) injection.Group[testTool] {
+		return injection.AddToGroup[testTool](ctx, testTool{
+			Tool: tools.Tool{
+				Name:        "noop",
+				Description: "Does nothing.",
+				Run: func(ctx context.Context, input string) (string, error) {
+					return "nothing happened", nil
+				},
+			},
+		})
+	})
+}
+
+func TestAgentBuilder(t *testing.T) {
+	t.Parallel()
+
+	const loopForever = `{"thought": "I should try again", "action": "noop", "input": ""}`
+
+	testCases := []struct {
+		name   string
+		opts   []assistanttools.Option[string]
+		setup  func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) context.Context
+		assert func(t *testing.T, val string, err error, llm *llmstesting.Fake[vertex.Params])
+	}{
+		{
+			name: "returns the final answer",
+			setup: func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) context.Context {
+				llm.AlwaysText = `{"thought": "I know the answer", "final_answer": "some-answer"}`
+				return ctx
+			},
+			assert: func(t *testing.T, val string, err error, llm *llmstesting.Fake[vertex.Params]) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := val, "some-answer"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "stops after the default max iterations",
+			setup: func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) context.Context {
+				llm.AlwaysText = loopForever
+				return ctx
+			},
+			assert: func(t *testing.T, val string, err error, llm *llmstesting.Fake[vertex.Params]) {
+				if actual, expected := errors.Is(err, assistanttools.ErrMaxIterations), true; actual != expected {
+					t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+				}
+				if actual, expected := len(llm.Prompts), assistanttools.DefaultMaxIterations; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "stops after the given max iterations",
+			opts: []assistanttools.Option[string]{assistanttools.WithMaxIterations[string](2)},
+			setup: func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) context.Context {
+				llm.AlwaysText = loopForever
+				return ctx
+			},
+			assert: func(t *testing.T, val string, err error, llm *llmstesting.Fake[vertex.Params]) {
+				if actual, expected := errors.Is(err, assistanttools.ErrMaxIterations), true; actual != expected {
+					t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+				}
+				if actual, expected := len(llm.Prompts), 2; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "stops once the token budget is used up",
+			setup: func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) context.Context {
+				llm.AlwaysText = loopForever
+				return usage.WithTracker(ctx, usage.NewTracker(1))
+			},
+			assert: func(t *testing.T, val string, err error, llm *llmstesting.Fake[vertex.Params]) {
+				if actual, expected := errors.Is(err, usage.ErrBudgetExceeded), true; actual != expected {
+					t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+				}
+				if actual, expected := len(llm.Prompts), 1; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "stops when the context is cancelled",
+			setup: func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) context.Context {
+				llm.AlwaysText = loopForever
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+				return ctx
+			},
+			assert: func(t *testing.T, val string, err error, llm *llmstesting.Fake[vertex.Params]) {
+				if actual, expected := errors.Is(err, context.Canceled), true; actual != expected {
+					t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+				}
+				if actual, expected := len(llm.Prompts), 0; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			llm := injection.Resolve[llms.LLM[vertex.Params]](ctx).(*llmstesting.Fake[vertex.Params])
+			runCtx := tc.setup(context.Background(), llm)
+
+			agent := assistanttools.AgentBuilder[string, testTool](ctx, tc.opts...)
+			result, err := agent.Run(runCtx, "some-goal")
+			tc.assert(t, result, err, llm)
+		})
+	}
+}
+
+type blockingLLM struct{}
+
+func (blockingLLM) Generate(ctx context.Context, prompt string, params vertex.Params) (string, error) {
+	// Ignores the context, like an LLM client that doesn't support it.
+	select {}
+}
+
+func TestContextLLM(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	llm := assistanttools.NewContextLLM[vertex.Params](blockingLLM{})
+	_, err := llm.Generate(ctx, "some-prompt", vertex.Params{})
+	if actual, expected := errors.Is(err, context.DeadlineExceeded), true; actual != expected {
+		t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+	}
+}
+
+func TestAgentName(t *testing.T) {
+	t.Parallel()
+
+	var names []string
+	llm := &llmstesting.Fake[vertex.Params]{Outputs: map[string]string{}}
+	llm.GenerateF = func(ctx context.Context, prompt string) {
+		names = append(names, assistanttools.AgentName(ctx))
+		llm.Outputs[prompt] = `{"thought": "I know the answer", "final_answer": "some-answer"}`
+	}
+	ctx := injection.WithInjection(assistanttesting.WithFakeLLM(context.Background(), llm))
+
+	agent := assistanttools.AgentBuilder[string, testTool](ctx, assistanttools.WithName[string]("some-agent"))
+	if _, err := agent.Run(context.Background(), "some-goal"); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := strings.Join(names, ","), "some-agent"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual := assistanttools.AgentName(context.Background()); actual != "" {
+		t.Fatalf("expected no name outside of an agent, got %q", actual)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	llm := &llmstesting.Fake[vertex.Params]{
+		AlwaysText: `{"thought": "I know the answer", "final_answer": "some-answer"}`,
+	}
+	ctx := injection.WithInjection(assistanttesting.WithFakeLLM(context.Background(), llm))
+
+	var buf bytes.Buffer
+	agent := assistanttools.AgentBuilder[string, testTool](ctx,
+		assistanttools.WithName[string]("some-agent"),
+		assistanttools.WithLogger[string](logging.New(&buf, slog.LevelDebug)),
+	)
+	if _, err := agent.Run(context.Background(), "some-goal"); err != nil {
+		t.Fatal(err)
+	}
+
+	actual := buf.String()
+	if expected := `level=DEBUG msg=thought agent=some-agent thought="I know the answer" final_answer=some-answer`; !strings.Contains(actual, expected) {
+		t.Fatalf("expected %q in %q", expected, actual)
+	}
+	if strings.Contains(actual, "some-goal") {
+		t.Fatalf("expected the prompt to not be logged at debug, got %q", actual)
+	}
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+type contextLLM[TParams any] struct {
+	llm llms.LLM[TParams]
+}
+
+// NewContextLLM wraps the LLM so that Generate returns as soon as the context
+// is done, even if the underlying LLM doesn't support cancellation. This keeps
+// a stuck LLM call from holding up a cancelled run.
+func NewContextLLM[TParams any](llm llms.LLM[TParams]) llms.LLM[TParams] {
+	return contextLLM[TParams]{llm: llm}
+}
+
+// Generate implements llms.LLM.
+func (c contextLLM[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	type result struct {
+		text string
+		err  error
+	}
+	// Buffered so the goroutine can finish after we stop waiting on it.
+	results := make(chan result, 1)
+	go func() {
+		text, err := c.llm.Generate(ctx, prompt, params)
+		results <- result{text: text, err: err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.text, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+type llmKey struct{}
+
+// WithLLM returns a context whose injection containers use the given LLM
+// instead of the registered one (e.g., to measure each run on its own).
+func WithLLM[TParams any](ctx context.Context, llm llms.LLM[TParams]) context.Context {
+	return context.WithValue(ctx, llmKey{}, llm)
+}
+
+// LLMFor returns the LLM given to WithLLM, falling back to llm.
+func LLMFor[TParams any](ctx context.Context, llm llms.LLM[TParams]) llms.LLM[TParams] {
+	if l, ok := ctx.Value(llmKey{}).(llms.LLM[TParams]); ok {
+		return l
+	}
+	return llm
+}
+
+type agentNameKey struct{}
+
+// AgentName returns the name of the agent (see WithName) that the LLM is
+// generating for. It is empty if the LLM isn't being used by an agent (e.g.,
+// a predictor).
+func AgentName(ctx context.Context) string {
+	name, _ := ctx.Value(agentNameKey{}).(string)
+	return name
+}
+
+// agentLLM tells the LLM which agent is using it (see AgentName).
+type agentLLM[TParams any] struct {
+	llm  llms.LLM[TParams]
+	name string
+}
+
+// Generate implements llms.LLM.
+func (a agentLLM[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	return a.llm.Generate(context.WithValue(ctx, agentNameKey{}, a.name), prompt, params)
+}
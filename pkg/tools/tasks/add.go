@@ -4,13 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
-	"github.com/google/go-react/pkg/llms"
-	"github.com/google/go-react/pkg/llms/vertex"
-	"github.com/google/go-react/pkg/parsers"
 	"github.com/google/go-react/pkg/predictors"
-	"github.com/google/go-react/pkg/prompters"
 	"github.com/google/go-react/pkg/tools"
 	"github.com/poy/go-dependency-injection/pkg/injection"
 )
@@ -21,8 +19,9 @@ func init() {
 			Tool: Add(ctx),
 		})
 	})
-	setupTaskTitleGenerator()
-	setupTaskRewriter()
+	registerTextPredictor[generateTaskTitleParams]("TaskTitleGenerator", generateTaskTitlePromptTempl)
+	registerTextPredictor[rewriteTaskParams]("TaskRewriter", rewriteTaskPromptTempl)
+	registerTextPredictor[scheduleTaskParams]("TaskScheduler", scheduleTaskPromptTempl)
 }
 
 // Add returns a tool that adds tasks.
@@ -31,6 +30,7 @@ func Add(ctx context.Context) tools.Tool {
 
 	taskTitlePredictor := injection.Resolve[predictors.Predictor[generateTaskTitleParams, string]](ctx)
 	taskRewriter := injection.Resolve[predictors.Predictor[rewriteTaskParams, string]](ctx)
+	taskScheduler := injection.Resolve[predictors.Predictor[scheduleTaskParams, string]](ctx)
 
 	return tools.Tool{
 		Name:        "add",
@@ -56,6 +56,18 @@ func Add(ctx context.Context) tools.Tool {
 				return "", err
 			}
 
+			schedule, err := taskScheduler.Predict(ctx, scheduleTaskParams{
+				Now:         time.Now().Format(time.RFC1123),
+				Description: description,
+			})
+			if err != nil {
+				// The schedule is optional, so the task is still added,
+				// just without a due date or reminder.
+				slog.WarnContext(ctx, "failed to schedule the task, adding it without a due date", "error", err)
+				schedule = ""
+			}
+			due, before, remind := parseSchedule(schedule)
+
 			description, err = taskRewriter.Predict(ctx, rewriteTaskParams{
 				Description: description,
 			})
@@ -63,9 +75,34 @@ func Add(ctx context.Context) tools.Tool {
 				return "", err
 			}
 
-			s.Add(title, description)
+			// Don't change the store if the run was cancelled while predicting.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			store := StoreFor(ctx, s)
+			traceChange(ctx, "add", title, func() error {
+				store.Add(title, description)
+				return nil
+			})
 
-			return fmt.Sprintf("Added task %q - %s", title, description), nil
+			result := fmt.Sprintf("Added task %q - %s", title, description)
+			t := store.GetTask(title)
+			if t == nil {
+				return result, nil
+			}
+			if !due.IsZero() {
+				if err := traceChange(ctx, "set-due", t.Name(), func() error { return t.SetDue(due) }); err != nil {
+					return "", conflictError(err)
+				}
+				result += fmt.Sprintf(", due %s", due.Format(time.RFC1123))
+			}
+			if remind {
+				if err := traceChange(ctx, "set-reminder", t.Name(), func() error { return t.SetReminder(before) }); err != nil {
+					return "", conflictError(err)
+				}
+				result += fmt.Sprintf(", with a reminder %s before", FormatBefore(before))
+			}
+			return result, nil
 		},
 	}
 }
@@ -92,38 +129,38 @@ type rewriteTaskParams struct {
 	Description string
 }
 
-func setupTaskTitleGenerator() {
-	injection.Register[predictors.Predictor[generateTaskTitleParams, string]](
-		func(ctx context.Context) predictors.Predictor[generateTaskTitleParams, string] {
-			llm := injection.Resolve[llms.LLM[vertex.Params]](ctx)
-			params := injection.Resolve[vertex.Params](ctx)
-
-			prompter := prompters.NewTextTemplate[generateTaskTitleParams, vertex.Params](
-				generateTaskTitlePromptTempl,
-				params,
-			)
-			parser := parsers.NewTextParser()
-			predictor := predictors.New(llm, prompter, parser)
-			predictor = predictors.NewRetrier(predictor)
-			return predictor
-		},
-	)
+const (
+	scheduleTaskPromptTempl = `Given the description of the task, say when it is due and how long before then the user wants to be reminded. Write the due date as YYYY-MM-DDTHH:MM and the reminder like 30m, 1h or 2d. Write none for anything the description doesn't say.
+
+Current time: Mon, 01 May 2023 09:00:00 PDT
+Task description: call the dentist tomorrow at 3pm and remind me an hour before
+Output: 2023-05-02T15:00, 1h
+
+Current time: Mon, 01 May 2023 09:00:00 PDT
+Task description: buy things for dinner
+Output: none, none
+
+Current time: {{.Now}}
+Task description: {{.Description}}
+Output: `
+)
+
+type scheduleTaskParams struct {
+	Now         string
+	Description string
 }
 
-func setupTaskRewriter() {
-	injection.Register[predictors.Predictor[rewriteTaskParams, string]](
-		func(ctx context.Context) predictors.Predictor[rewriteTaskParams, string] {
-			llm := injection.Resolve[llms.LLM[vertex.Params]](ctx)
-			params := injection.Resolve[vertex.Params](ctx)
-
-			prompter := prompters.NewTextTemplate[rewriteTaskParams, vertex.Params](
-				rewriteTaskPromptTempl,
-				params,
-			)
-			parser := parsers.NewTextParser()
-			predictor := predictors.New(llm, prompter, parser)
-			predictor = predictors.NewRetrier(predictor)
-			return predictor
-		},
-	)
+// parseSchedule parses the output of the task scheduler. The schedule is
+// optional, so anything that can't be parsed is ignored.
+func parseSchedule(s string) (due time.Time, before time.Duration, remind bool) {
+	d, b, _ := strings.Cut(strings.TrimSpace(s), ",")
+	if d = strings.TrimSpace(d); !strings.EqualFold(d, "none") {
+		due, _ = ParseDue(d)
+	}
+	if b = strings.TrimSpace(b); b != "" && !strings.EqualFold(b, "none") {
+		var err error
+		before, err = ParseBefore(b)
+		remind = err == nil
+	}
+	return due, before, remind
 }
@@ -56,7 +56,14 @@ func AddNote(ctx context.Context) tools.Tool {
 				return "", fmt.Errorf("failed to rewrite task description: %w", err)
 			}
 
-			t.AddNotes(instructions)
+			// Don't change the store if the run was cancelled while predicting.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			traceChange(ctx, "add-notes", t.Name(), func() error {
+				t.AddNotes(instructions)
+				return nil
+			})
 
 			return fmt.Sprintf("done adding note to %s", t.Name()), nil
 		},
@@ -2,8 +2,11 @@ package tasks_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-react/pkg/llms"
 	llmstesting "github.com/google/go-react/pkg/llms/testing"
@@ -53,6 +56,65 @@ func TestAdd(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "adds the due date and reminder",
+			input: "call the dentist on Jan 2nd 2030 at 3pm and remind me an hour before",
+			setup: func(llm *llmstesting.Fake[vertex.Params]) {
+				llm.Outputs = map[string]string{}
+				llm.GenerateF = func(ctx context.Context, prompt string) {
+					llm.Outputs[prompt] = "some-llm-output"
+					if strings.Contains(prompt, "how long before then") {
+						llm.Outputs[prompt] = "2030-01-02T15:00, 1h"
+					}
+				}
+			},
+			assert: func(t *testing.T, val string, err error, s tasks.Store) {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				task := s.GetTask("some-llm-output")
+				if expected, actual := time.Date(2030, 1, 2, 15, 0, 0, 0, time.Local), task.Due(); !actual.Equal(expected) {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+				if before, ok := task.Reminder(); !ok || before != time.Hour {
+					t.Fatalf("expected a reminder 1h before, got %v", before)
+				}
+				if !strings.HasSuffix(val, "with a reminder 1h before") {
+					t.Fatalf("expected the reminder in %q", val)
+				}
+			},
+		},
+		{
+			name:  "adds the task without a schedule if scheduling fails",
+			input: "call the dentist tomorrow",
+			setup: func(llm *llmstesting.Fake[vertex.Params]) {
+				llm.Outputs = map[string]string{}
+				llm.Errs = map[string]error{}
+				llm.GenerateF = func(ctx context.Context, prompt string) {
+					llm.Outputs[prompt] = "some-llm-output"
+					if strings.Contains(prompt, "how long before then") {
+						llm.Errs[prompt] = errors.New("some-error")
+					}
+				}
+			},
+			assert: func(t *testing.T, val string, err error, s tasks.Store) {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				task := s.GetTask("some-llm-output")
+				if task == nil {
+					t.Fatal("expected the task")
+				}
+				if actual := task.Due(); !actual.IsZero() {
+					t.Fatalf("expected no due date, got %v", actual)
+				}
+				if expected, actual := "Added task \"some-llm-output\" - some-llm-output", val; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
 		{
 			name:  "too few arguments",
 			input: "",
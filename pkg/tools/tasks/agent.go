@@ -2,16 +2,25 @@ package tasks
 
 import (
 	"context"
+	"log/slog"
+	"strings"
 
 	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/google/go-react/pkg/parsers"
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/prompters"
 	"github.com/google/go-react/pkg/tools"
 	assistanttools "github.com/poy/assistant/pkg/tools"
+	"github.com/poy/assistant/pkg/tracing"
+	"github.com/poy/assistant/pkg/usage"
 	"github.com/poy/go-dependency-injection/pkg/injection"
 )
 
 // TaskAgent is an agent that can be used to interact with tasks.
 type TaskAgent struct {
-	agents.Agent[string]
+	assistanttools.Agent[string]
 }
 
 func init() {
@@ -27,6 +36,56 @@ func init() {
 	})
 }
 
+// predictorLLM returns the LLM for the predictor with the given name, which
+// has its usage and spans recorded against the name.
+func predictorLLM(ctx context.Context, name string) llms.LLM[vertex.Params] {
+	llm := injection.Resolve[llms.LLM[vertex.Params]](ctx)
+	return usage.NewLLM(tracing.NewLLM(llm, name), name)
+}
+
+// registerTextPredictor registers the predictor that fills in the template
+// and returns the LLM's text. Its usage and spans are recorded against the
+// name (see predictorLLM).
+func registerTextPredictor[T any](name, templ string) {
+	injection.Register[predictors.Predictor[T, string]](
+		func(ctx context.Context) predictors.Predictor[T, string] {
+			llm := predictorLLM(ctx, name)
+			params := injection.Resolve[vertex.Params](ctx)
+
+			prompter := prompters.NewTextTemplate[T, vertex.Params](templ, params)
+			predictor := predictors.New(llm, prompter, parsers.NewTextParser())
+			return predictors.NewRetrier(predictor)
+		},
+	)
+}
+
+// predictText uses the predictor to write something for the user (e.g., the
+// day's plan). If the LLM fails or writes nothing, the fallback is used so the
+// user still gets an answer. If the context was cancelled or timed out, its
+// error is returned instead, as the run is being stopped.
+func predictText[T any](ctx context.Context, p predictors.Predictor[T, string], req T, what string, fallback func() string) (string, error) {
+	text, err := p.Predict(ctx, req)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to "+what+", using the fallback", "error", err)
+		return fallback(), nil
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return fallback(), nil
+	}
+	return text, nil
+}
+
+// traceChange makes the change to the store in a span. The store doesn't
+// have the context, so the tools trace their changes.
+func traceChange(ctx context.Context, op, task string, change func() error) error {
+	return tracing.Run(ctx, "store."+op, func(context.Context) error {
+		return change()
+	}, tracing.TaskKey.String(task))
+}
+
 // taskTool wraps a tools.Tool so it can be grouped and used by injection.
 type taskTool struct {
 	tools.Tool
@@ -46,6 +105,22 @@ var (
 				Input:   "show the user the tasks",
 			},
 		},
+		{
+			Question: "What should I focus on today?",
+			Output: agents.Reasoning[string]{
+				Thought: "I should use the briefing tool",
+				Action:  "briefing",
+				Input:   "plan the user's day",
+			},
+		},
+		{
+			Question: "How did my week go?",
+			Output: agents.Reasoning[string]{
+				Thought: "I should use the review tool",
+				Action:  "review",
+				Input:   "7",
+			},
+		},
 		{
 			Question: "Show me the details of the grocery store task",
 			PreviousContext: []agents.ThoughtIteration[string]{
@@ -0,0 +1,170 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func init() {
+	injection.Register[injection.Group[modifyTaskTool]](func(ctx context.Context) injection.Group[modifyTaskTool] {
+		return injection.AddToGroup[modifyTaskTool](ctx, modifyTaskTool{
+			Tool: Assign(ctx),
+		})
+	})
+	injection.Register[injection.Group[modifyTaskTool]](func(ctx context.Context) injection.Group[modifyTaskTool] {
+		return injection.AddToGroup[modifyTaskTool](ctx, modifyTaskTool{
+			Tool: Unassign(ctx),
+		})
+	})
+	injection.Register[injection.Group[modifyTaskTool]](func(ctx context.Context) injection.Group[modifyTaskTool] {
+		return injection.AddToGroup[modifyTaskTool](ctx, modifyTaskTool{
+			Tool: Watch(ctx),
+		})
+	})
+}
+
+// Assign assigns a task to someone. It fails with ErrConflict if the task
+// was assigned to someone other than who the user was shown (see Seen).
+func Assign(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	seen := injection.Resolve[*Seen](ctx)
+	return tools.Tool{
+		Name:        "assign",
+		Description: "Assign a task to someone. The first word is who it is assigned to, the rest is the task.",
+		Args: []string{
+			"person",
+			"task",
+		},
+		Examples: []string{
+			"alex the grocery store task",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			person, t, err := wordAndTask(ctx, f, input)
+			if err != nil {
+				return "", err
+			}
+			if strings.EqualFold(t.Assignee(), person) {
+				return fmt.Sprintf("Task %s is already assigned to %s", t.Name(), person), nil
+			}
+
+			// Don't change the store if the run was cancelled while finding the task.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			if err := assign(ctx, SeenFor(ctx, seen), t, person); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Assigned task %s to %s", t.Name(), person), nil
+		},
+	}
+}
+
+// Unassign removes the assignee from a task. See Assign.
+func Unassign(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	seen := injection.Resolve[*Seen](ctx)
+	return tools.Tool{
+		Name:        "unassign",
+		Description: "Remove whoever a task is assigned to. Provide the instructions from the user.",
+		Args: []string{
+			"instructions",
+		},
+		Examples: []string{
+			"nobody is working on the grocery store task anymore",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			if len(strings.Fields(input)) == 0 {
+				return "", errors.New("wrong number of arguments")
+			}
+
+			t, err := f.FindTask(ctx, input)
+			if err != nil {
+				return "", fmt.Errorf("failed to find task: %w", err)
+			}
+			if t.Assignee() == "" {
+				return fmt.Sprintf("Task %s isn't assigned to anyone", t.Name()), nil
+			}
+
+			// Don't change the store if the run was cancelled while finding the task.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			if err := assign(ctx, SeenFor(ctx, seen), t, ""); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Task %s isn't assigned to anyone now", t.Name()), nil
+		},
+	}
+}
+
+// Watch adds someone to the watchers of a task.
+func Watch(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	return tools.Tool{
+		Name:        "watch",
+		Description: "Have someone follow a task without it being assigned to them. The first word is who, the rest is the task.",
+		Args: []string{
+			"person",
+			"task",
+		},
+		Examples: []string{
+			"sam the grocery store task",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			person, t, err := wordAndTask(ctx, f, input)
+			if err != nil {
+				return "", err
+			}
+
+			// Don't change the store if the run was cancelled while finding the task.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			if err := traceChange(ctx, "watch", t.Name(), func() error { return t.Watch(person) }); err != nil {
+				return "", conflictError(err)
+			}
+			return fmt.Sprintf("%s is watching task %s", person, t.Name()), nil
+		},
+	}
+}
+
+// wordAndTask splits the input into its first word (e.g., the person) and
+// finds the task from the rest.
+func wordAndTask(ctx context.Context, f TaskFinder, input string) (string, *Task, error) {
+	fields := strings.Fields(input)
+	if len(fields) < 2 {
+		return "", nil, errors.New("wrong number of arguments, expected a single word and then the task")
+	}
+
+	t, err := f.FindTask(ctx, strings.Join(fields[1:], " "))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find task: %w", err)
+	}
+	return fields[0], t, nil
+}
+
+// assign assigns the task to the person (or nobody) if it is still assigned
+// to who the user was shown. Either way, the user is told who it is assigned
+// to now, so that is recorded.
+func assign(ctx context.Context, seen *Seen, t *Task, person string) error {
+	op := "assign"
+	if person == "" {
+		op = "unassign"
+	}
+	err := traceChange(ctx, op, t.Name(), func() error { return t.Assign(seen.Assignee(t), person) })
+	seen.Show(t, t.Assignee())
+	return conflictError(err)
+}
+
+// conflictError tells the agent what to do about a conflict.
+func conflictError(err error) error {
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("%w. Tell the user instead of trying again", err)
+	}
+	return err
+}
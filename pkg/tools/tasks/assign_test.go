@@ -0,0 +1,173 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+func TestAssign(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		tool   func(context.Context) tools.Tool
+		input  string
+		setup  func(f *fakeTaskFinder)
+		assert func(t *testing.T, val string, err error, f *fakeTaskFinder)
+	}{
+		{
+			name:  "assigns the task",
+			tool:  tasks.Assign,
+			input: "alex task 1",
+			setup: func(f *fakeTaskFinder) {
+				f.Add("task 1", "")
+			},
+			assert: func(t *testing.T, val string, err error, f *fakeTaskFinder) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := f.GetTask("task 1").Assignee(), "alex"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := val, "Assigned task task 1 to alex"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "assigning needs the person and the task",
+			tool:  tasks.Assign,
+			input: "alex",
+			assert: func(t *testing.T, val string, err error, f *fakeTaskFinder) {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+		{
+			name:  "unassigns the task",
+			tool:  tasks.Unassign,
+			input: "task 1",
+			setup: func(f *fakeTaskFinder) {
+				f.Add("task 1", "")
+				f.GetTask("task 1").Assign("", "alex")
+			},
+			assert: func(t *testing.T, val string, err error, f *fakeTaskFinder) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := f.GetTask("task 1").Assignee(), ""; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "watches the task",
+			tool:  tasks.Watch,
+			input: "sam task 1",
+			setup: func(f *fakeTaskFinder) {
+				f.Add("task 1", "")
+				f.GetTask("task 1").Watch("SAM")
+			},
+			assert: func(t *testing.T, val string, err error, f *fakeTaskFinder) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(f.GetTask("task 1").Watchers()), 1; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			f := injection.Resolve[tasks.TaskFinder](ctx).(*fakeTaskFinder)
+			if tc.setup != nil {
+				tc.setup(f)
+			}
+			result, err := tc.tool(ctx).Run(context.Background(), tc.input)
+			tc.assert(t, result, err, f)
+		})
+	}
+}
+
+func TestAssignWhatTheUserSaw(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		tool  func(context.Context) tools.Tool
+		input string
+	}{
+		{name: "assign", tool: tasks.Assign, input: "alex task 1"},
+		{name: "unassign", tool: tasks.Unassign, input: "task 1"},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			f := injection.Resolve[tasks.TaskFinder](ctx).(*fakeTaskFinder)
+			f.Add("task 1", "")
+			f.GetTask("task 1").Assign("", "sam")
+
+			// The user sees the task assigned to sam, and then someone else
+			// assigns it to taylor.
+			if _, err := tasks.Read(ctx).Run(context.Background(), "task 1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.GetTask("task 1").Assign("sam", "taylor"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tc.tool(ctx).Run(context.Background(), tc.input); !errors.Is(err, tasks.ErrConflict) {
+				t.Fatalf("expected %v, got %v", tasks.ErrConflict, err)
+			}
+			if actual, expected := f.GetTask("task 1").Assignee(), "taylor"; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+
+			// The user was told about taylor, so trying again works.
+			if _, err := tc.tool(ctx).Run(context.Background(), tc.input); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestWorkloadFor(t *testing.T) {
+	t.Parallel()
+	s := tasks.NewStore("")
+	s.Add("task 1", "")
+	s.Add("task 2", "")
+	s.Add("task 3", "")
+	s.Add("task 4", "")
+	s.GetTask("task 1").Assign("", "alex")
+	s.GetTask("task 2").Assign("", "Alex")
+	s.GetTask("task 2").Complete()
+	s.GetTask("task 3").Watch("alex")
+	s.GetTask("task 4").Assign("", "sam")
+
+	w := tasks.WorkloadFor(s, "ALEX")
+	if actual, expected := w.Assigned, []string{"task 1"}; len(actual) != 1 || actual[0] != expected[0] {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := w.Watching, []string{"task 3"}; len(actual) != 1 || actual[0] != expected[0] {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
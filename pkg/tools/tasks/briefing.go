@@ -0,0 +1,233 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func init() {
+	injection.Register[injection.Group[taskTool]](func(ctx context.Context) injection.Group[taskTool] {
+		return injection.AddToGroup[taskTool](ctx, taskTool{
+			Tool: Briefing(ctx),
+		})
+	})
+	registerTextPredictor[briefingParams]("BriefingPlanner", briefingPromptTempl)
+}
+
+// recentlyNoted is how recent a note has to be for the task to be in the
+// briefing.
+const recentlyNoted = 24 * time.Hour
+
+// Briefing shows the user what needs their attention today and a plan for the
+// day.
+func Briefing(ctx context.Context) tools.Tool {
+	s := injection.Resolve[Store](ctx)
+	d := injection.Resolve[*display.Displayer](ctx)
+	planner := injection.Resolve[predictors.Predictor[briefingParams, string]](ctx)
+	return tools.Tool{
+		Name:        "briefing",
+		Description: "Show the user the overdue, due today and recently noted tasks with a prioritized plan for the day.",
+		Run: func(ctx context.Context, input string) (string, error) {
+			a := AgendaFor(StoreFor(ctx, s), time.Now())
+			plan, err := planFor(ctx, planner, a)
+			if err != nil {
+				return "", err
+			}
+			a.Plan = plan
+			return d.Display(ctx, BriefingOutput(a))
+		},
+	}
+}
+
+// Agenda is what needs the user's attention today.
+type Agenda struct {
+	Date          string       `json:"date"`
+	Overdue       []AgendaItem `json:"overdue"`
+	DueToday      []AgendaItem `json:"due_today"`
+	RecentlyNoted []AgendaItem `json:"recently_noted"`
+	// Plan is the prioritized plan for the day.
+	Plan string `json:"plan"`
+}
+
+// AgendaItem is a task in the Agenda.
+type AgendaItem struct {
+	Name string `json:"name"`
+	// Due is zero if the task doesn't have a due date.
+	Due time.Time `json:"due"`
+	// Note is the latest note.
+	Note string `json:"note,omitempty"`
+}
+
+// Empty returns true if nothing needs the user's attention.
+func (a Agenda) Empty() bool {
+	return len(a.Overdue)+len(a.DueToday)+len(a.RecentlyNoted) == 0
+}
+
+// AgendaFor returns the open tasks that are overdue, due today or were
+// recently noted.
+func AgendaFor(s Store, now time.Time) Agenda {
+	a := Agenda{
+		Date:          now.Format("Mon, 02 Jan 2006"),
+		Overdue:       []AgendaItem{},
+		DueToday:      []AgendaItem{},
+		RecentlyNoted: []AgendaItem{},
+	}
+	y, m, d := now.Date()
+	tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+
+	var noted []time.Time
+	for _, name := range s.TaskNames() {
+		t := s.GetTask(name)
+		if t == nil || t.Completed() {
+			continue
+		}
+		item := AgendaItem{Name: t.Name(), Due: t.Due()}
+		var latest time.Time
+		if notes := t.Notes(); len(notes) > 0 {
+			item.Note = notes[len(notes)-1].Note()
+			latest = notes[len(notes)-1].Datetime()
+		}
+
+		switch {
+		case !item.Due.IsZero() && item.Due.Before(now):
+			a.Overdue = append(a.Overdue, item)
+		case !item.Due.IsZero() && item.Due.Before(tomorrow):
+			a.DueToday = append(a.DueToday, item)
+		case now.Sub(latest) <= recentlyNoted:
+			a.RecentlyNoted = append(a.RecentlyNoted, item)
+			noted = append(noted, latest)
+		}
+	}
+
+	byDue := func(items []AgendaItem) func(i, j int) bool {
+		return func(i, j int) bool { return items[i].Due.Before(items[j].Due) }
+	}
+	sort.SliceStable(a.Overdue, byDue(a.Overdue))
+	sort.SliceStable(a.DueToday, byDue(a.DueToday))
+	// The most recently noted first.
+	sort.Sort(byNoted{items: a.RecentlyNoted, noted: noted})
+	return a
+}
+
+type byNoted struct {
+	items []AgendaItem
+	noted []time.Time
+}
+
+func (b byNoted) Len() int           { return len(b.items) }
+func (b byNoted) Less(i, j int) bool { return b.noted[i].After(b.noted[j]) }
+func (b byNoted) Swap(i, j int) {
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+	b.noted[i], b.noted[j] = b.noted[j], b.noted[i]
+}
+
+// FallbackPlan is the plan for the day without the LLM. The overdue tasks are
+// first (the oldest first), then the tasks due today and then the recently
+// noted ones.
+func FallbackPlan(a Agenda) string {
+	if a.Empty() {
+		return "Nothing is overdue, due today or recently noted."
+	}
+
+	var lines []string
+	for _, item := range a.Overdue {
+		lines = append(lines, fmt.Sprintf("%d. %s (overdue since %s)", len(lines)+1, item.Name, item.Due.Format("Mon 15:04")))
+	}
+	for _, item := range a.DueToday {
+		lines = append(lines, fmt.Sprintf("%d. %s (due at %s)", len(lines)+1, item.Name, item.Due.Format("15:04")))
+	}
+	for _, item := range a.RecentlyNoted {
+		lines = append(lines, fmt.Sprintf("%d. Follow up on %s", len(lines)+1, item.Name))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// planFor uses the LLM to write the plan. See predictText.
+func planFor(ctx context.Context, planner predictors.Predictor[briefingParams, string], a Agenda) (string, error) {
+	if a.Empty() {
+		return FallbackPlan(a), nil
+	}
+	return predictText(ctx, planner, briefingParams{
+		Date:          a.Date,
+		Overdue:       formatAgendaItems(a.Overdue),
+		DueToday:      formatAgendaItems(a.DueToday),
+		RecentlyNoted: formatAgendaItems(a.RecentlyNoted),
+	}, "plan the day", func() string { return FallbackPlan(a) })
+}
+
+// BriefingOutput is the output for the Agenda.
+func BriefingOutput(a Agenda) display.Output {
+	lines := []string{fmt.Sprintf("Plan for %s:", a.Date), a.Plan}
+	for _, section := range []struct {
+		title string
+		items []AgendaItem
+	}{
+		{"Overdue", a.Overdue},
+		{"Due today", a.DueToday},
+		{"Recently noted", a.RecentlyNoted},
+	} {
+		if len(section.items) == 0 {
+			continue
+		}
+		lines = append(lines, "", section.title+":")
+		for _, item := range section.items {
+			lines = append(lines, "* "+formatAgendaItem(item))
+		}
+	}
+
+	return display.Output{
+		Kind: "task-briefing",
+		Text: strings.Join(lines, "\n"),
+		Data: a,
+	}
+}
+
+func formatAgendaItem(item AgendaItem) string {
+	s := item.Name
+	if !item.Due.IsZero() {
+		s += fmt.Sprintf(" (due %s)", item.Due.Format("Mon 15:04"))
+	}
+	if item.Note != "" {
+		s += ": " + item.Note
+	}
+	return s
+}
+
+func formatAgendaItems(items []AgendaItem) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	var lines []string
+	for _, item := range items {
+		lines = append(lines, "* "+formatAgendaItem(item))
+	}
+	return strings.Join(lines, "\n")
+}
+
+const (
+	briefingPromptTempl = `Given the user's tasks, write a short prioritized plan for their day as a numbered list. Put what matters most first, only use the given tasks and don't use more than 5 items.
+
+Today: {{.Date}}
+Overdue:
+{{.Overdue}}
+Due today:
+{{.DueToday}}
+Recently noted:
+{{.RecentlyNoted}}
+Output: `
+)
+
+type briefingParams struct {
+	Date          string
+	Overdue       string
+	DueToday      string
+	RecentlyNoted string
+}
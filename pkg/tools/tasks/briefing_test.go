@@ -0,0 +1,164 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+func TestAgendaFor(t *testing.T) {
+	t.Parallel()
+
+	s := tasks.NewStore("")
+	now := time.Now()
+	y, m, d := now.Date()
+	endOfDay := time.Date(y, m, d, 23, 59, 0, 0, now.Location())
+	for _, name := range []string{"later", "overdue", "very overdue", "due today", "noted", "completed", "nothing"} {
+		s.Add(name, "")
+	}
+	s.GetTask("later").SetDue(endOfDay.Add(2 * time.Minute))
+	s.GetTask("overdue").SetDue(now.Add(-time.Minute))
+	s.GetTask("very overdue").SetDue(now.Add(-48 * time.Hour))
+	s.GetTask("due today").SetDue(endOfDay)
+	s.GetTask("noted").AddNotes("called the plumber")
+	s.GetTask("completed").SetDue(now.Add(-time.Hour))
+	s.GetTask("completed").Complete()
+
+	a := tasks.AgendaFor(s, now)
+	for _, tc := range []struct {
+		items    []tasks.AgendaItem
+		expected string
+	}{
+		{items: a.Overdue, expected: "very overdue,overdue"},
+		{items: a.DueToday, expected: "due today"},
+		{items: a.RecentlyNoted, expected: "noted"},
+	} {
+		var names []string
+		for _, item := range tc.items {
+			names = append(names, item.Name)
+		}
+		if actual := strings.Join(names, ","); actual != tc.expected {
+			t.Fatalf("expected %q, got %q", tc.expected, actual)
+		}
+	}
+	if actual, expected := a.RecentlyNoted[0].Note, "called the plumber"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if plan := tasks.FallbackPlan(a); !strings.HasPrefix(plan, "1. very overdue") || !strings.Contains(plan, "4. Follow up on noted") {
+		t.Fatalf("unexpected plan %q", plan)
+	}
+}
+
+func TestBriefing(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		setup  func(llm *llmstesting.Fake[vertex.Params], s tasks.Store)
+		assert func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params])
+	}{
+		{
+			name: "the LLM writes the plan",
+			setup: func(llm *llmstesting.Fake[vertex.Params], s tasks.Store) {
+				llm.AlwaysText = "1. call mom"
+				s.Add("call mom", "")
+				s.GetTask("call mom").SetDue(time.Now().Add(-time.Hour))
+			},
+			assert: func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if actual, expected := o.Data.(tasks.Agenda).Plan, "1. call mom"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if !strings.Contains(llm.Prompts[0], "Overdue:\n* call mom") {
+					t.Fatalf("expected the overdue task in the prompt, got %q", llm.Prompts[0])
+				}
+				if !strings.Contains(o.Text, "Overdue:\n* call mom") {
+					t.Fatalf("expected the overdue task in %q", o.Text)
+				}
+			},
+		},
+		{
+			name: "falls back without the LLM",
+			setup: func(llm *llmstesting.Fake[vertex.Params], s tasks.Store) {
+				llm.Err = errors.New("unavailable")
+				s.Add("call mom", "")
+				s.GetTask("call mom").SetDue(time.Now().Add(-time.Hour))
+			},
+			assert: func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if actual := o.Data.(tasks.Agenda).Plan; !strings.HasPrefix(actual, "1. call mom (overdue since") {
+					t.Fatalf("expected the fallback plan, got %q", actual)
+				}
+			},
+		},
+		{
+			name: "doesn't use the LLM if there is nothing to do",
+			setup: func(llm *llmstesting.Fake[vertex.Params], s tasks.Store) {
+				s.Add("someday", "")
+			},
+			assert: func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if len(llm.Prompts) != 0 {
+					t.Fatalf("expected the LLM to not be used, got %d prompts", len(llm.Prompts))
+				}
+				if actual, expected := o.Data.(tasks.Agenda).Plan, "Nothing is overdue, due today or recently noted."; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			llm := injection.Resolve[llms.LLM[vertex.Params]](ctx).(*llmstesting.Fake[vertex.Params])
+			s := injection.Resolve[tasks.Store](ctx)
+			tc.setup(llm, s)
+
+			buf := &display.Buffer{}
+			if _, err := tasks.Briefing(ctx).Run(display.WithSink(context.Background(), buf), ""); err != nil {
+				t.Fatal(err)
+			}
+			outputs := buf.Outputs()
+			if len(outputs) != 1 || outputs[0].Kind != "task-briefing" {
+				t.Fatalf("expected the briefing, got %+v", outputs)
+			}
+			tc.assert(t, outputs[0], llm)
+		})
+	}
+}
+
+func TestBriefingStopped(t *testing.T) {
+	t.Parallel()
+	ctx := injectiontesting.WithTesting(t)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	llm := injection.Resolve[llms.LLM[vertex.Params]](ctx).(*llmstesting.Fake[vertex.Params])
+	llm.GenerateF = func(ctx context.Context, prompt string) {
+		// The run is stopped while the LLM is writing the plan.
+		cancel()
+	}
+	s := injection.Resolve[tasks.Store](ctx)
+	s.Add("call mom", "")
+	s.GetTask("call mom").SetDue(time.Now().Add(-time.Hour))
+
+	buf := &display.Buffer{}
+	if _, err := tasks.Briefing(ctx).Run(display.WithSink(runCtx, buf), ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if outputs := buf.Outputs(); len(outputs) != 0 {
+		t.Fatalf("expected no briefing, got %+v", outputs)
+	}
+}
@@ -0,0 +1,58 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func init() {
+	injection.Register[injection.Group[modifyTaskTool]](func(ctx context.Context) injection.Group[modifyTaskTool] {
+		return injection.AddToGroup[modifyTaskTool](ctx, modifyTaskTool{
+			Tool: Complete(ctx),
+		})
+	})
+}
+
+// Complete marks a task as completed.
+func Complete(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	return tools.Tool{
+		Name:        "complete",
+		Description: "Mark a task as completed. Provide the instructions from the user.",
+		Args: []string{
+			"instructions",
+		},
+		Examples: []string{
+			"I finished buying the groceries",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			if len(strings.Fields(input)) == 0 {
+				return "", errors.New("wrong number of arguments")
+			}
+
+			t, err := f.FindTask(ctx, input)
+			if err != nil {
+				return "", fmt.Errorf("failed to find task: %w", err)
+			}
+			if t.Completed() {
+				return fmt.Sprintf("Task %s was already completed", t.Name()), nil
+			}
+
+			// Don't change the store if the run was cancelled while finding the task.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			traceChange(ctx, "complete", t.Name(), func() error {
+				t.Complete()
+				return nil
+			})
+
+			return fmt.Sprintf("Completed task %s", t.Name()), nil
+		},
+	}
+}
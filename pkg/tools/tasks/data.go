@@ -0,0 +1,88 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+)
+
+// Data is a copy of a Task's fields. It is used to move tasks in and out of
+// the store (e.g., syncing with a calendar) and to show them (e.g., in the
+// JSON output), as it can be used without holding the store's lock.
+type Data struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Created     time.Time `json:"created"`
+	// Due is zero if the task doesn't have a due date.
+	Due time.Time `json:"due"`
+	// CompletedAt is zero if the task isn't completed.
+	CompletedAt time.Time  `json:"completed_at"`
+	Notes       []NoteData `json:"notes,omitempty"`
+	Assignee    string     `json:"assignee,omitempty"`
+	Watchers    []string   `json:"watchers,omitempty"`
+}
+
+// ID identifies the task, even if it is renamed. It is based on when the
+// task was created.
+func (d Data) ID() string {
+	return fmt.Sprintf("assistant-%d", d.Created.UnixNano())
+}
+
+// NoteData is a copy of a Note's fields.
+type NoteData struct {
+	Time time.Time `json:"time"`
+	Note string    `json:"note"`
+}
+
+// Data returns a copy of the task's fields.
+func (t *Task) Data() Data {
+	defer t.lock()()
+	d := Data{
+		Name:        t.name,
+		Description: t.description,
+		Created:     time.Unix(0, t.datetime),
+		Assignee:    t.assignee,
+		Watchers:    append([]string(nil), t.watchers...),
+	}
+	if t.due != nil {
+		d.Due = time.Unix(0, *t.due)
+	}
+	if t.completed != nil {
+		d.CompletedAt = time.Unix(0, *t.completed)
+	}
+	for _, n := range t.notes {
+		d.Notes = append(d.Notes, NoteData{Time: time.Unix(0, n.datetime), Note: n.note})
+	}
+	return d
+}
+
+// SetData replaces the task's fields with the data. The name, creation time
+// and reminder aren't changed, but a snoozed or dismissed reminder is reset
+// if the due date changes (see SetDue).
+func (t *Task) SetData(d Data) error {
+	return t.update(func(t *Task) error {
+		t.description = d.Description
+		due := unixNano(d.Due)
+		if (due == nil) != (t.due == nil) || (due != nil && *due != *t.due) {
+			t.snoozed = nil
+			t.dismissed = false
+		}
+		t.due = due
+		t.completed = unixNano(d.CompletedAt)
+		t.assignee = d.Assignee
+		t.watchers = append([]string(nil), d.Watchers...)
+		t.notes = nil
+		for _, n := range d.Notes {
+			t.notes = append(t.notes, Note{datetime: n.Time.UnixNano(), note: n.Note})
+		}
+		return nil
+	})
+}
+
+// unixNano returns nil for the zero time.
+func unixNano(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	ns := t.UnixNano()
+	return &ns
+}
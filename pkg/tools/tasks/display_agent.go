@@ -27,7 +27,7 @@ func newDisplayTaskTool(ctx context.Context) tools.Tool {
 	agent := newDisplayTaskAgent(ctx)
 	return tools.Tool{
 		Name:        "display",
-		Description: "Display information about the tasks. The data will be showed to the user instead of being returned to the agent. You don't need to know anything about the data, let me handle it.",
+		Description: "Display information about the tasks to the user. A summary of what was shown is returned so you can answer follow-up questions.",
 		Args: []string{
 			"instructions",
 		},
@@ -44,7 +44,7 @@ func newDisplayTaskTool(ctx context.Context) tools.Tool {
 	}
 }
 
-func newDisplayTaskAgent(ctx context.Context) agents.Agent[string] {
+func newDisplayTaskAgent(ctx context.Context) assistanttools.Agent[string] {
 	return assistanttools.AgentBuilder[string, displayTaskTool](
 		ctx,
 		assistanttools.WithName[string]("TaskDisplayer"),
@@ -67,6 +67,22 @@ var (
 				Input:   "",
 			},
 		},
+		{
+			Question: "What's on Alex's plate?",
+			Output: agents.Reasoning[string]{
+				Thought: "I should use the plate tool",
+				Action:  "plate",
+				Input:   "alex",
+			},
+		},
+		{
+			Question: "Where are we with the kitchen renovation?",
+			Output: agents.Reasoning[string]{
+				Thought: "I should use the summarize tool",
+				Action:  "summarize",
+				Input:   "renovate kitchen",
+			},
+		},
 		{
 			Question: "Show me the details of the grocery store task",
 			PreviousContext: []agents.ThoughtIteration[string]{
@@ -76,12 +92,12 @@ var (
 						Action:  "read",
 						Input:   "display the details of the grocery store task",
 					},
-					Observation: "Displayed of the task pick up groceries at the store were shown to the user",
+					Observation: "Displayed the following to the user:\nName: pick up groceries at the store\nDescription: milk and eggs",
 				},
 			},
 			Output: agents.Reasoning[string]{
 				Thought:     "I know the answer",
-				FinalAnswer: "Displayed of the task pick up groceries at the store were shown to the user",
+				FinalAnswer: "Displayed the details of the task pick up groceries at the store. It is about buying milk and eggs.",
 			},
 		},
 		{
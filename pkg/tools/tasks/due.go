@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func init() {
+	injection.Register[injection.Group[modifyTaskTool]](func(ctx context.Context) injection.Group[modifyTaskTool] {
+		return injection.AddToGroup[modifyTaskTool](ctx, modifyTaskTool{
+			Tool: Due(ctx),
+		})
+	})
+}
+
+// dueLayouts are the formats a due date can be given in.
+var dueLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseDue parses a due date. Dates without a time zone are in the local time
+// zone. "none" means there isn't a due date.
+func ParseDue(s string) (time.Time, error) {
+	if strings.EqualFold(s, "none") {
+		return time.Time{}, nil
+	}
+	for _, layout := range dueLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid due date %q, expected YYYY-MM-DD, YYYY-MM-DDTHH:MM or none", s)
+}
+
+// Due sets when a task is due.
+func Due(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	return tools.Tool{
+		Name:        "due",
+		Description: "Set when a task is due. The first word is the date (YYYY-MM-DD, YYYY-MM-DDTHH:MM or none to remove it), the rest is the task.",
+		Args: []string{
+			"date",
+			"task",
+		},
+		Examples: []string{
+			"2023-06-01 the grocery store task",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			date, t, err := wordAndTask(ctx, f, input)
+			if err != nil {
+				return "", err
+			}
+			due, err := ParseDue(date)
+			if err != nil {
+				return "", err
+			}
+
+			// Don't change the store if the run was cancelled while finding the task.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			if err := traceChange(ctx, "set-due", t.Name(), func() error { return t.SetDue(due) }); err != nil {
+				return "", conflictError(err)
+			}
+			if due.IsZero() {
+				return fmt.Sprintf("Task %s doesn't have a due date now", t.Name()), nil
+			}
+			return fmt.Sprintf("Task %s is due %s", t.Name(), due.Format(time.RFC1123)), nil
+		},
+	}
+}
@@ -0,0 +1,89 @@
+package tasks_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+func TestDue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		input  string
+		setup  func(f *fakeTaskFinder)
+		assert func(t *testing.T, val string, err error, f *fakeTaskFinder)
+	}{
+		{
+			name:  "sets the due date",
+			input: "2023-06-01T17:30 task 1",
+			setup: func(f *fakeTaskFinder) {
+				f.Add("task 1", "")
+			},
+			assert: func(t *testing.T, val string, err error, f *fakeTaskFinder) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := time.Date(2023, 6, 1, 17, 30, 0, 0, time.Local)
+				if actual := f.GetTask("task 1").Due(); !actual.Equal(expected) {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "removes the due date",
+			input: "none task 1",
+			setup: func(f *fakeTaskFinder) {
+				f.Add("task 1", "")
+				f.GetTask("task 1").SetDue(time.Now())
+			},
+			assert: func(t *testing.T, val string, err error, f *fakeTaskFinder) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual := f.GetTask("task 1").Due(); !actual.IsZero() {
+					t.Fatalf("expected no due date, got %v", actual)
+				}
+				if actual, expected := val, "Task task 1 doesn't have a due date now"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "invalid date",
+			input: "tomorrow task 1",
+			setup: func(f *fakeTaskFinder) {
+				f.Add("task 1", "")
+			},
+			assert: func(t *testing.T, val string, err error, f *fakeTaskFinder) {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if actual := f.GetTask("task 1").Due(); !actual.IsZero() {
+					t.Fatalf("expected no due date, got %v", actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			f := injection.Resolve[tasks.TaskFinder](ctx).(*fakeTaskFinder)
+			if tc.setup != nil {
+				tc.setup(f)
+			}
+			result, err := tasks.Due(ctx).Run(context.Background(), tc.input)
+			tc.assert(t, result, err, f)
+		})
+	}
+}
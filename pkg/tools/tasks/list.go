@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/display"
 	"github.com/poy/go-dependency-injection/pkg/injection"
 )
 
@@ -25,21 +26,31 @@ func init() {
 // List returns a list of task names.
 func List(ctx context.Context) tools.Tool {
 	s := injection.Resolve[Store](ctx)
+	d := injection.Resolve[*display.Displayer](ctx)
 	return tools.Tool{
 		Name:        "list",
 		Description: "List all task names.",
 		Run: func(ctx context.Context, input string) (string, error) {
-			var names []string
-			for _, name := range s.TaskNames() {
-				names = append(names, fmt.Sprintf("* %s", name))
-			}
-
-			if len(names) > 0 {
-				fmt.Println(strings.Join(names, "\n"))
-			} else {
-				fmt.Println("You don't have any tasks yet...")
-			}
-			return "Displayed the current list of tasks to the user", nil
+			return d.Display(ctx, ListOutput(StoreFor(ctx, s).TaskNames()))
 		},
 	}
 }
+
+// ListOutput is the output for the list of task names.
+func ListOutput(names []string) display.Output {
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("* %s", name))
+	}
+
+	text := strings.Join(lines, "\n")
+	if len(lines) == 0 {
+		text = "You don't have any tasks yet..."
+	}
+
+	return display.Output{
+		Kind: "task-list",
+		Text: text,
+		Data: names,
+	}
+}
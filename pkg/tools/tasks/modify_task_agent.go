@@ -50,7 +50,7 @@ func newModifyTaskTool(ctx context.Context) tools.Tool {
 	}
 }
 
-func newModifyTaskAgent(ctx context.Context) agents.Agent[string] {
+func newModifyTaskAgent(ctx context.Context) assistanttools.Agent[string] {
 	return assistanttools.AgentBuilder[string, modifyTaskTool](
 		ctx,
 		assistanttools.WithName[string]("TaskModifier"),
@@ -82,6 +82,22 @@ var (
 				Input:   "add a note to the change the tires task that a spare is needed too",
 			},
 		},
+		{
+			Question: "for the task change the tires, do the following: give it to alex",
+			Output: agents.Reasoning[string]{
+				Thought: "I should use the assign tool",
+				Action:  "assign",
+				Input:   "alex change the tires",
+			},
+		},
+		{
+			Question: "for the task change the tires, do the following: remind me 1 hour before",
+			Output: agents.Reasoning[string]{
+				Thought: "I should use the remind tool",
+				Action:  "remind",
+				Input:   "1h change the tires",
+			},
+		},
 		{
 			Question: "for the task change the tires, do the following: add a note",
 			Output: agents.Reasoning[string]{
@@ -0,0 +1,114 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func init() {
+	injection.Register[injection.Group[displayTaskTool]](func(ctx context.Context) injection.Group[displayTaskTool] {
+		return injection.AddToGroup[displayTaskTool](ctx, displayTaskTool{
+			Tool: Plate(ctx),
+		})
+	})
+}
+
+// Plate shows what someone is working on.
+func Plate(ctx context.Context) tools.Tool {
+	s := injection.Resolve[Store](ctx)
+	d := injection.Resolve[*display.Displayer](ctx)
+	seen := injection.Resolve[*Seen](ctx)
+	return tools.Tool{
+		Name:        "plate",
+		Description: "Show the open tasks that are assigned to someone or that they are watching.",
+		Args: []string{
+			"person",
+		},
+		Examples: []string{
+			"alex",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			fields := strings.Fields(input)
+			if len(fields) != 1 {
+				return "", errors.New("wrong number of arguments, expected the person")
+			}
+			store := StoreFor(ctx, s)
+			o := PlateOutput(store, fields[0])
+
+			// The user is shown who the assigned tasks belong to.
+			for _, name := range o.Data.(Workload).Assigned {
+				if t := store.GetTask(name); t != nil && strings.EqualFold(t.Assignee(), fields[0]) {
+					SeenFor(ctx, seen).Show(t, t.Assignee())
+				}
+			}
+			return d.Display(ctx, o)
+		},
+	}
+}
+
+// Workload is what someone is working on.
+type Workload struct {
+	Person   string   `json:"person"`
+	Assigned []string `json:"assigned"`
+	Watching []string `json:"watching"`
+}
+
+// PlateOutput is the output for the open tasks assigned to or watched by the
+// person.
+func PlateOutput(s Store, person string) display.Output {
+	p := WorkloadFor(s, person)
+
+	var lines []string
+	if len(p.Assigned) == 0 {
+		lines = append(lines, fmt.Sprintf("Nothing is assigned to %s.", person))
+	} else {
+		lines = append(lines, fmt.Sprintf("Assigned to %s:", person))
+		for _, name := range p.Assigned {
+			lines = append(lines, fmt.Sprintf("* %s", name))
+		}
+	}
+	if len(p.Watching) > 0 {
+		lines = append(lines, "", "Watching:")
+		for _, name := range p.Watching {
+			lines = append(lines, fmt.Sprintf("* %s", name))
+		}
+	}
+
+	return display.Output{
+		Kind: "task-plate",
+		Text: strings.Join(lines, "\n"),
+		Data: p,
+	}
+}
+
+// WorkloadFor returns the open tasks assigned to or watched by the person.
+func WorkloadFor(s Store, person string) Workload {
+	p := Workload{
+		Person:   person,
+		Assigned: []string{},
+		Watching: []string{},
+	}
+	for _, name := range s.TaskNames() {
+		t := s.GetTask(name)
+		if t == nil || t.Completed() {
+			continue
+		}
+		if strings.EqualFold(t.Assignee(), person) {
+			p.Assigned = append(p.Assigned, t.Name())
+			continue
+		}
+		for _, w := range t.Watchers() {
+			if strings.EqualFold(w, person) {
+				p.Watching = append(p.Watching, t.Name())
+				break
+			}
+		}
+	}
+	return p
+}
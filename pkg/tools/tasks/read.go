@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/display"
 	"github.com/poy/go-dependency-injection/pkg/injection"
 )
 
@@ -20,6 +22,8 @@ func init() {
 // Read returns a task.
 func Read(ctx context.Context) tools.Tool {
 	f := injection.Resolve[TaskFinder](ctx)
+	d := injection.Resolve[*display.Displayer](ctx)
+	seen := injection.Resolve[*Seen](ctx)
 	return tools.Tool{
 		Name:        "read",
 		Description: "Read a task by its name. If you don't know the name, I can guess it.",
@@ -35,26 +39,53 @@ func Read(ctx context.Context) tools.Tool {
 				return "", fmt.Errorf("failed to find task: %w", err)
 			}
 
-			result := fmt.Sprintf(`
+			o := DetailsOutput(t)
+			SeenFor(ctx, seen).Show(t, o.Data.(Data).Assignee)
+			return d.Display(ctx, o)
+		},
+	}
+}
+
+// DetailsOutput is the output for the details of a task. The Data is a
+// snapshot of the task (see Data), since the sinks encode it later.
+func DetailsOutput(t *Task) display.Output {
+	return display.Output{
+		Kind: "task-details",
+		Text: FormatDetails(t),
+		Data: t.Data(),
+	}
+}
+
+// FormatDetails formats the details of the task for the user.
+func FormatDetails(t *Task) string {
+	result := fmt.Sprintf(`
 Name: %s
 Description: %s
 `, t.Name(), t.Description())
 
-			if t.Completed() {
-				result = fmt.Sprintf("%s\nCompleted At: %s", result, t.CompletedAt())
-			}
-
-			if len(t.Notes()) > 0 {
-				var notes []string
-				for _, n := range t.Notes() {
-					notes = append(notes, fmt.Sprintf("* [%s] %s", n.Datetime(), n.Note()))
-				}
-				result = fmt.Sprintf("%s\nNotes:\n%s", result, strings.Join(notes, "\n"))
-			}
+	if !t.Due().IsZero() {
+		result = fmt.Sprintf("%sDue: %s\n", result, t.Due().Format(time.RFC1123))
+	}
+	if before, ok := t.Reminder(); ok {
+		result = fmt.Sprintf("%sReminder: %s before\n", result, FormatBefore(before))
+	}
+	if t.Assignee() != "" {
+		result = fmt.Sprintf("%sAssignee: %s\n", result, t.Assignee())
+	}
+	if len(t.Watchers()) > 0 {
+		result = fmt.Sprintf("%sWatchers: %s\n", result, strings.Join(t.Watchers(), ", "))
+	}
 
-			fmt.Println(result)
+	if t.Completed() {
+		result = fmt.Sprintf("%s\nCompleted At: %s", result, t.CompletedAt())
+	}
 
-			return fmt.Sprintf("Details of the task %s were shown to the user", input), nil
-		},
+	if len(t.Notes()) > 0 {
+		var notes []string
+		for _, n := range t.Notes() {
+			notes = append(notes, fmt.Sprintf("* [%s] %s", n.Datetime(), n.Note()))
+		}
+		result = fmt.Sprintf("%s\nNotes:\n%s", result, strings.Join(notes, "\n"))
 	}
+	return result
 }
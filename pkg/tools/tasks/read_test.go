@@ -0,0 +1,82 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		input  string
+		setup  func(f *fakeTaskFinder)
+		assert func(t *testing.T, val string, err error, outputs []display.Output)
+	}{
+		{
+			name:  "displays the task",
+			input: "some-task",
+			setup: func(f *fakeTaskFinder) {
+				f.Add("some-task", "some-description")
+			},
+			assert: func(t *testing.T, val string, err error, outputs []display.Output) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(outputs), 1; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := outputs[0].Kind, "task-details"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := outputs[0].Data.(tasks.Data).Name, "some-task"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+
+				// The agent should be able to answer questions about it.
+				if !strings.Contains(val, "Description: some-description") {
+					t.Fatalf("expected the summary to have the description, got %q", val)
+				}
+			},
+		},
+		{
+			name:  "finding the task fails",
+			input: "some-task",
+			setup: func(f *fakeTaskFinder) {
+				f.AddErr("some-task", errors.New("some-error"))
+			},
+			assert: func(t *testing.T, val string, err error, outputs []display.Output) {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if actual, expected := len(outputs), 0; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			f := injection.Resolve[tasks.TaskFinder](ctx).(*fakeTaskFinder)
+			buf := injection.Resolve[display.Sink](ctx).(*display.Buffer)
+			tc.setup(f)
+
+			result, err := tasks.Read(ctx).Run(context.Background(), tc.input)
+			tc.assert(t, result, err, buf.Outputs())
+		})
+	}
+}
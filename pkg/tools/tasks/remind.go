@@ -0,0 +1,169 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func init() {
+	injection.Register[injection.Group[modifyTaskTool]](func(ctx context.Context) injection.Group[modifyTaskTool] {
+		return injection.AddToGroup[modifyTaskTool](ctx, modifyTaskTool{
+			Tool: Remind(ctx),
+		})
+	})
+	injection.Register[injection.Group[modifyTaskTool]](func(ctx context.Context) injection.Group[modifyTaskTool] {
+		return injection.AddToGroup[modifyTaskTool](ctx, modifyTaskTool{
+			Tool: Snooze(ctx),
+		})
+	})
+	injection.Register[injection.Group[modifyTaskTool]](func(ctx context.Context) injection.Group[modifyTaskTool] {
+		return injection.AddToGroup[modifyTaskTool](ctx, modifyTaskTool{
+			Tool: Dismiss(ctx),
+		})
+	})
+}
+
+// ParseBefore parses a duration (e.g., "1h" or "30m"). Days (e.g., "2d") are
+// supported too.
+func ParseBefore(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q, expected something like 30m, 1h or 2d", s)
+	}
+	return d, nil
+}
+
+// FormatBefore formats the duration the way ParseBefore parses it.
+func FormatBefore(d time.Duration) string {
+	if d != 0 && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// Remind sets how long before the due date to remind the user about a task.
+func Remind(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	return tools.Tool{
+		Name:        "remind",
+		Description: "Set when to remind the user about a task. The first word is how long before the due date (e.g., 30m, 1h or 2d, or none to remove it), the rest is the task.",
+		Args: []string{
+			"before",
+			"task",
+		},
+		Examples: []string{
+			"1h the grocery store task",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			word, t, err := wordAndTask(ctx, f, input)
+			if err != nil {
+				return "", err
+			}
+
+			if strings.EqualFold(word, "none") {
+				if err := traceChange(ctx, "remove-reminder", t.Name(), t.RemoveReminder); err != nil {
+					return "", conflictError(err)
+				}
+				return fmt.Sprintf("Task %s doesn't have a reminder now", t.Name()), nil
+			}
+
+			before, err := ParseBefore(word)
+			if err != nil {
+				return "", err
+			}
+			// Don't change the store if the run was cancelled while finding the task.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			if err := traceChange(ctx, "set-reminder", t.Name(), func() error { return t.SetReminder(before) }); err != nil {
+				return "", conflictError(err)
+			}
+			result := fmt.Sprintf("The user will be reminded about task %s %s before it is due", t.Name(), FormatBefore(before))
+			if t.Due().IsZero() {
+				result += ". It doesn't have a due date yet"
+			}
+			return result, nil
+		},
+	}
+}
+
+// Snooze stops the reminders for a task for a while.
+func Snooze(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	return tools.Tool{
+		Name:        "snooze",
+		Description: "Stop reminding the user about a task for a while. The first word is for how long (e.g., 30m, 1h or 2d), the rest is the task.",
+		Args: []string{
+			"duration",
+			"task",
+		},
+		Examples: []string{
+			"1h the grocery store task",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			word, t, err := wordAndTask(ctx, f, input)
+			if err != nil {
+				return "", err
+			}
+			d, err := ParseBefore(word)
+			if err != nil {
+				return "", err
+			}
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+
+			until := time.Now().Add(d)
+			if err := traceChange(ctx, "snooze", t.Name(), func() error { return t.Snooze(until) }); err != nil {
+				return "", conflictError(err)
+			}
+			return fmt.Sprintf("Snoozed the reminders for task %s until %s", t.Name(), until.Format(time.RFC1123)), nil
+		},
+	}
+}
+
+// Dismiss stops the reminders for a task.
+func Dismiss(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	return tools.Tool{
+		Name:        "dismiss",
+		Description: "Stop reminding the user about a task until its due date changes. The input is the task.",
+		Args: []string{
+			"task",
+		},
+		Examples: []string{
+			"the grocery store task",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			t, err := f.FindTask(ctx, input)
+			if err != nil {
+				return "", fmt.Errorf("failed to find task: %w", err)
+			}
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			if err := traceChange(ctx, "dismiss", t.Name(), t.Dismiss); err != nil {
+				return "", conflictError(err)
+			}
+			return fmt.Sprintf("Dismissed the reminders for task %s", t.Name()), nil
+		},
+	}
+}
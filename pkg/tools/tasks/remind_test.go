@@ -0,0 +1,143 @@
+package tasks_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+func TestRemind(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		tool   func(context.Context) tools.Tool
+		input  string
+		setup  func(task *tasks.Task)
+		assert func(t *testing.T, val string, err error, task *tasks.Task)
+	}{
+		{
+			name:  "sets the reminder",
+			tool:  tasks.Remind,
+			input: "2d task 1",
+			assert: func(t *testing.T, val string, err error, task *tasks.Task) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if before, ok := task.Reminder(); !ok || before != 48*time.Hour {
+					t.Fatalf("expected a reminder 48h before, got %v", before)
+				}
+				if actual, expected := val, "The user will be reminded about task task 1 2d before it is due. It doesn't have a due date yet"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "removes the reminder",
+			tool:  tasks.Remind,
+			input: "none task 1",
+			setup: func(task *tasks.Task) {
+				task.SetReminder(time.Hour)
+			},
+			assert: func(t *testing.T, val string, err error, task *tasks.Task) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := task.Reminder(); ok {
+					t.Fatal("expected no reminder")
+				}
+			},
+		},
+		{
+			name:  "invalid duration",
+			tool:  tasks.Remind,
+			input: "soon task 1",
+			assert: func(t *testing.T, val string, err error, task *tasks.Task) {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+			},
+		},
+		{
+			name:  "snoozes the reminders",
+			tool:  tasks.Snooze,
+			input: "1h30m task 1",
+			assert: func(t *testing.T, val string, err error, task *tasks.Task) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if until := task.SnoozedUntil(); time.Until(until) < time.Hour || time.Until(until) > 90*time.Minute {
+					t.Fatalf("expected the reminders to be snoozed for 1h30m, got %v", until)
+				}
+			},
+		},
+		{
+			name:  "dismisses the reminders",
+			tool:  tasks.Dismiss,
+			input: "task 1",
+			assert: func(t *testing.T, val string, err error, task *tasks.Task) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !task.Dismissed() {
+					t.Fatal("expected the reminders to be dismissed")
+				}
+
+				// A new due date has new reminders.
+				task.SetDue(time.Now())
+				if task.Dismissed() {
+					t.Fatal("expected the reminders to not be dismissed")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			f := injection.Resolve[tasks.TaskFinder](ctx).(*fakeTaskFinder)
+			f.Add("task 1", "")
+			if tc.setup != nil {
+				tc.setup(f.GetTask("task 1"))
+			}
+			result, err := tc.tool(ctx).Run(context.Background(), tc.input)
+			tc.assert(t, result, err, f.GetTask("task 1"))
+		})
+	}
+}
+
+func TestParseBefore(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input    string
+		expected time.Duration
+		err      bool
+	}{
+		{input: "30m", expected: 30 * time.Minute},
+		{input: "1h", expected: time.Hour},
+		{input: "2d", expected: 48 * time.Hour},
+		{input: "-1h", err: true},
+		{input: "soon", err: true},
+	} {
+		actual, err := tasks.ParseBefore(tc.input)
+		if (err != nil) != tc.err {
+			t.Fatalf("%s: unexpected error %v", tc.input, err)
+		}
+		if actual != tc.expected {
+			t.Fatalf("%s: expected %v, got %v", tc.input, tc.expected, actual)
+		}
+		if !tc.err && tasks.FormatBefore(actual) != tc.input {
+			t.Fatalf("expected %q, got %q", tc.input, tasks.FormatBefore(actual))
+		}
+	}
+}
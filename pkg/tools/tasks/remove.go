@@ -40,7 +40,14 @@ func Remove(ctx context.Context) tools.Tool {
 			if err != nil {
 				return "", fmt.Errorf("failed to find task: %w", err)
 			}
-			s.Remove(t.Name())
+			// Don't change the store if the run was cancelled while finding the task.
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			traceChange(ctx, "remove", t.Name(), func() error {
+				StoreFor(ctx, s).Remove(t.Name())
+				return nil
+			})
 
 			return fmt.Sprintf("Removed task %s", t.Name()), nil
 		},
@@ -0,0 +1,272 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func init() {
+	injection.Register[injection.Group[taskTool]](func(ctx context.Context) injection.Group[taskTool] {
+		return injection.AddToGroup[taskTool](ctx, taskTool{
+			Tool: Review(ctx),
+		})
+	})
+	registerTextPredictor[retrospectiveParams]("RetrospectiveWriter", retrospectivePromptTempl)
+}
+
+const (
+	// DefaultReviewDays is how far back a review goes by default.
+	DefaultReviewDays = 7
+	// DefaultStaleDays is how long an open task can go without a note before
+	// it is stale.
+	DefaultStaleDays = 14
+)
+
+// Review shows the user what they got done recently, what is stale and how
+// they are keeping up, with a retrospective.
+func Review(ctx context.Context) tools.Tool {
+	s := injection.Resolve[Store](ctx)
+	d := injection.Resolve[*display.Displayer](ctx)
+	writer := injection.Resolve[predictors.Predictor[retrospectiveParams, string]](ctx)
+	return tools.Tool{
+		Name:        "review",
+		Description: fmt.Sprintf("Review the tasks completed in the last few days, the stale tasks and the trends, with a retrospective. The input is how many days to review (defaults to %d), optionally followed by how many days without a note make an open task stale (defaults to %d).", DefaultReviewDays, DefaultStaleDays),
+		Args: []string{
+			"days",
+			"stale days",
+		},
+		Examples: []string{
+			"7",
+			"30 14",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			// Only the numbers count, so "last 30 days" is 30 days.
+			var numbers []int
+			for _, f := range strings.Fields(input) {
+				if n, err := strconv.Atoi(f); err == nil && n > 0 {
+					numbers = append(numbers, n)
+				}
+			}
+			days, stale := DefaultReviewDays, DefaultStaleDays
+			if len(numbers) > 0 {
+				days = numbers[0]
+			}
+			if len(numbers) > 1 {
+				stale = numbers[1]
+			}
+
+			now := time.Now()
+			r := ReviewFor(StoreFor(ctx, s), now.AddDate(0, 0, -days), now, time.Duration(stale)*24*time.Hour)
+			retro, err := retrospectiveFor(ctx, writer, r)
+			if err != nil {
+				return "", err
+			}
+			r.Retrospective = retro
+			return d.Display(ctx, ReviewOutput(r))
+		},
+	}
+}
+
+// ReviewReport is what happened with the tasks during a period.
+type ReviewReport struct {
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Completed []ReviewItem  `json:"completed"`
+	Stale     []ReviewItem  `json:"stale"`
+	Trend     []TrendBucket `json:"trend"`
+	Open      int           `json:"open"`
+	Totals    TrendBucket   `json:"totals"`
+	// Retrospective is a summary of the period.
+	Retrospective string `json:"retrospective"`
+}
+
+// ReviewItem is a task in the ReviewReport.
+type ReviewItem struct {
+	Name string `json:"name"`
+	// Time is when the task was completed, or the last time it was touched
+	// for stale tasks.
+	Time time.Time `json:"time"`
+}
+
+// TrendBucket is how many tasks were created and completed on a day.
+type TrendBucket struct {
+	Day       string `json:"day"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// ReviewFor returns what happened with the tasks between from and to. Open
+// tasks without a note (or, if they don't have any notes, created) within
+// staleAfter of to are stale.
+func ReviewFor(s Store, from, to time.Time, staleAfter time.Duration) ReviewReport {
+	r := ReviewReport{
+		From:      from,
+		To:        to,
+		Completed: []ReviewItem{},
+		Stale:     []ReviewItem{},
+		Totals:    TrendBucket{Day: "total"},
+	}
+
+	// A bucket for each day in the period.
+	buckets := map[string]*TrendBucket{}
+	y, m, d := from.Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, from.Location()); day.Before(to); day = day.AddDate(0, 0, 1) {
+		r.Trend = append(r.Trend, TrendBucket{Day: day.Format("2006-01-02")})
+	}
+	for i := range r.Trend {
+		buckets[r.Trend[i].Day] = &r.Trend[i]
+	}
+	inPeriod := func(t time.Time) bool {
+		return !t.IsZero() && !t.Before(from) && t.Before(to)
+	}
+
+	for _, name := range s.TaskNames() {
+		t := s.GetTask(name)
+		if t == nil {
+			continue
+		}
+		data := t.Data()
+
+		if inPeriod(data.Created) {
+			r.Totals.Created++
+			if b := buckets[data.Created.In(from.Location()).Format("2006-01-02")]; b != nil {
+				b.Created++
+			}
+		}
+		if inPeriod(data.CompletedAt) {
+			r.Totals.Completed++
+			r.Completed = append(r.Completed, ReviewItem{Name: data.Name, Time: data.CompletedAt})
+			if b := buckets[data.CompletedAt.In(from.Location()).Format("2006-01-02")]; b != nil {
+				b.Completed++
+			}
+		}
+		if !data.CompletedAt.IsZero() {
+			continue
+		}
+
+		r.Open++
+		touched := data.Created
+		if len(data.Notes) > 0 {
+			touched = data.Notes[len(data.Notes)-1].Time
+		}
+		if to.Sub(touched) >= staleAfter {
+			r.Stale = append(r.Stale, ReviewItem{Name: data.Name, Time: touched})
+		}
+	}
+
+	sort.SliceStable(r.Completed, func(i, j int) bool { return r.Completed[i].Time.Before(r.Completed[j].Time) })
+	// The stalest first.
+	sort.SliceStable(r.Stale, func(i, j int) bool { return r.Stale[i].Time.Before(r.Stale[j].Time) })
+	return r
+}
+
+// FallbackRetrospective is the retrospective without the LLM.
+func FallbackRetrospective(r ReviewReport) string {
+	result := fmt.Sprintf("You completed %d %s and added %d.", r.Totals.Completed, plural(r.Totals.Completed, "task"), r.Totals.Created)
+	switch {
+	case r.Totals.Completed > r.Totals.Created:
+		result += " The list is getting shorter."
+	case r.Totals.Completed < r.Totals.Created:
+		result += " The list is getting longer."
+	}
+	if len(r.Stale) > 0 {
+		result += fmt.Sprintf(" %d %s haven't been touched in a while, consider finishing or removing them.", len(r.Stale), plural(len(r.Stale), "task"))
+	}
+	return result
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return s
+	}
+	return s + "s"
+}
+
+// retrospectiveFor uses the LLM to write the retrospective. See predictText.
+func retrospectiveFor(ctx context.Context, writer predictors.Predictor[retrospectiveParams, string], r ReviewReport) (string, error) {
+	return predictText(ctx, writer, retrospectiveParams{
+		Period:    formatPeriod(r),
+		Completed: formatReviewItems(r.Completed),
+		Stale:     formatReviewItems(r.Stale),
+		Trend:     formatTrend(r),
+	}, "write the retrospective", func() string { return FallbackRetrospective(r) })
+}
+
+// ReviewOutput is the output for the ReviewReport.
+func ReviewOutput(r ReviewReport) display.Output {
+	lines := []string{fmt.Sprintf("Review of %s", formatPeriod(r)), ""}
+
+	lines = append(lines, fmt.Sprintf("Completed (%d):", len(r.Completed)))
+	for _, item := range r.Completed {
+		lines = append(lines, fmt.Sprintf("* %s (%s)", item.Name, item.Time.Format("Mon 02 Jan")))
+	}
+	if len(r.Stale) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Stale (%d):", len(r.Stale)))
+		for _, item := range r.Stale {
+			lines = append(lines, fmt.Sprintf("* %s (last touched %s)", item.Name, item.Time.Format("02 Jan 2006")))
+		}
+	}
+	lines = append(lines, "", "Created vs. completed:", formatTrend(r))
+	lines = append(lines, "", r.Retrospective)
+
+	return display.Output{
+		Kind: "task-review",
+		Text: strings.Join(lines, "\n"),
+		Data: r,
+	}
+}
+
+func formatPeriod(r ReviewReport) string {
+	return fmt.Sprintf("%s to %s", r.From.Format("Mon 02 Jan"), r.To.Format("Mon 02 Jan"))
+}
+
+func formatReviewItems(items []ReviewItem) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	var lines []string
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("* %s (%s)", item.Name, item.Time.Format("02 Jan")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatTrend has a line per day, with a + per created task and a - per
+// completed task.
+func formatTrend(r ReviewReport) string {
+	var lines []string
+	for _, b := range append(append([]TrendBucket(nil), r.Trend...), r.Totals) {
+		lines = append(lines, fmt.Sprintf("%-10s %3d created %3d completed  %s%s",
+			b.Day, b.Created, b.Completed, strings.Repeat("+", min(b.Created, 20)), strings.Repeat("-", min(b.Completed, 20))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+const (
+	retrospectivePromptTempl = `Given what the user did with their tasks, write a short and encouraging retrospective (2 or 3 sentences). Point out what went well and what needs attention, such as the stale tasks or the list getting longer.
+
+Period: {{.Period}}
+Completed:
+{{.Completed}}
+Stale:
+{{.Stale}}
+Created vs. completed per day:
+{{.Trend}}
+Output: `
+)
+
+type retrospectiveParams struct {
+	Period    string
+	Completed string
+	Stale     string
+	Trend     string
+}
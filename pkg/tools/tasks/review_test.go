@@ -0,0 +1,155 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+func TestReviewFor(t *testing.T) {
+	t.Parallel()
+
+	s := tasks.NewStore("")
+	for _, name := range []string{"done", "also done", "open", "noted"} {
+		s.Add(name, "")
+	}
+	s.GetTask("done").Complete()
+	s.GetTask("also done").Complete()
+	s.GetTask("noted").AddNotes("called the plumber")
+
+	now := time.Now()
+	r := tasks.ReviewFor(s, now.AddDate(0, 0, -7), now.Add(time.Minute), 0)
+	var completed []string
+	for _, item := range r.Completed {
+		completed = append(completed, item.Name)
+	}
+	if actual, expected := strings.Join(completed, ","), "done,also done"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := r.Totals, (tasks.TrendBucket{Day: "total", Created: 4, Completed: 2}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+	if actual, expected := r.Trend[len(r.Trend)-1].Created, 4; actual != expected {
+		t.Fatalf("expected %d created today, got %d", expected, actual)
+	}
+	if len(r.Trend) < 7 || len(r.Trend) > 9 {
+		t.Fatalf("expected a bucket per day, got %d", len(r.Trend))
+	}
+	// The open task hasn't been touched since it was created, so it is the
+	// stalest.
+	if len(r.Stale) != 2 || r.Stale[0].Name != "open" || r.Stale[1].Name != "noted" {
+		t.Fatalf("unexpected stale tasks %+v", r.Stale)
+	}
+
+	if r := tasks.ReviewFor(s, now.AddDate(0, 0, -7), now, 24*time.Hour); len(r.Stale) != 0 {
+		t.Fatalf("expected no stale tasks, got %+v", r.Stale)
+	}
+	if r := tasks.ReviewFor(s, now.AddDate(0, 0, -14), now.AddDate(0, 0, -7), 0); len(r.Completed) != 0 || r.Totals.Created != 0 {
+		t.Fatalf("expected nothing in the period, got %+v", r)
+	}
+	if actual, expected := tasks.FallbackRetrospective(r), "You completed 2 tasks and added 4. The list is getting longer. 2 tasks haven't been touched in a while, consider finishing or removing them."; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestReview(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		input  string
+		setup  func(llm *llmstesting.Fake[vertex.Params], s tasks.Store)
+		assert func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params])
+	}{
+		{
+			name: "the LLM writes the retrospective",
+			setup: func(llm *llmstesting.Fake[vertex.Params], s tasks.Store) {
+				llm.AlwaysText = "Great week."
+				s.Add("call mom", "")
+				s.GetTask("call mom").Complete()
+			},
+			assert: func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if actual, expected := o.Data.(tasks.ReviewReport).Retrospective, "Great week."; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if !strings.Contains(llm.Prompts[0], "Completed:\n* call mom") {
+					t.Fatalf("expected the completed task in the prompt, got %q", llm.Prompts[0])
+				}
+				if !strings.Contains(o.Text, "Completed (1):\n* call mom") {
+					t.Fatalf("expected the completed task in %q", o.Text)
+				}
+			},
+		},
+		{
+			name: "falls back without the LLM",
+			setup: func(llm *llmstesting.Fake[vertex.Params], s tasks.Store) {
+				llm.Err = errors.New("unavailable")
+				s.Add("call mom", "")
+			},
+			assert: func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if actual, expected := o.Data.(tasks.ReviewReport).Retrospective, "You completed 0 tasks and added 1. The list is getting longer."; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name:  "uses the given period",
+			input: "30 1",
+			setup: func(llm *llmstesting.Fake[vertex.Params], s tasks.Store) {
+				llm.AlwaysText = "ok"
+			},
+			assert: func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				r := o.Data.(tasks.ReviewReport)
+				if actual := r.To.Sub(r.From); actual < 29*24*time.Hour || actual > 31*24*time.Hour {
+					t.Fatalf("expected 30 days, got %s", actual)
+				}
+			},
+		},
+		{
+			name:  "skips the words in the period",
+			input: "last 30 days",
+			setup: func(llm *llmstesting.Fake[vertex.Params], s tasks.Store) {
+				llm.AlwaysText = "ok"
+			},
+			assert: func(t *testing.T, o display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				r := o.Data.(tasks.ReviewReport)
+				if actual := r.To.Sub(r.From); actual < 29*24*time.Hour || actual > 31*24*time.Hour {
+					t.Fatalf("expected 30 days, got %s", actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			llm := injection.Resolve[llms.LLM[vertex.Params]](ctx).(*llmstesting.Fake[vertex.Params])
+			s := injection.Resolve[tasks.Store](ctx)
+			tc.setup(llm, s)
+
+			buf := &display.Buffer{}
+			if _, err := tasks.Review(ctx).Run(display.WithSink(context.Background(), buf), tc.input); err != nil {
+				t.Fatal(err)
+			}
+			outputs := buf.Outputs()
+			if len(outputs) != 1 || outputs[0].Kind != "task-review" {
+				t.Fatalf("expected the review, got %+v", outputs)
+			}
+			tc.assert(t, outputs[0], llm)
+		})
+	}
+}
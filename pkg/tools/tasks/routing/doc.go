@@ -0,0 +1,5 @@
+// Package routing has the unit tests for the task agents: which tools they
+// use for what, how they retry and how they handle errors. They use a
+// scripted LLM, and live outside of the tasks package because its tests
+// replace the TaskFinder with a fake.
+package routing
@@ -0,0 +1,347 @@
+package routing_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/poy/assistant/pkg/display"
+	assistanttesting "github.com/poy/assistant/pkg/testing"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/assistant/pkg/tools/userinput"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// setup returns a container that uses the LLM, with a couple of tasks.
+func setup(llm *assistanttesting.ScriptedLLM) (context.Context, tasks.Store) {
+	ctx := injection.WithInjection(assistanttesting.WithLLM(context.Background(), llm))
+	s := injection.Resolve[tasks.Store](ctx)
+	s.Add("hire nanny", "")
+	s.Add("buy groceries", "milk and bread")
+	return ctx, s
+}
+
+// agents returns which agent sent each prompt.
+func agents(llm *assistanttesting.ScriptedLLM) string {
+	var names []string
+	for _, p := range llm.Prompts() {
+		if p.Agent == "" {
+			names = append(names, "predictor")
+			continue
+		}
+		names = append(names, p.Agent)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestTaskFinder(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		llm    *assistanttesting.ScriptedLLM
+		asker  *userinput.Scripted
+		assert func(t *testing.T, task *tasks.Task, err error, llm *assistanttesting.ScriptedLLM, asker *userinput.Scripted)
+	}{
+		{
+			name: "finds the task",
+			llm:  assistanttesting.NewScriptedLLM(assistanttesting.Final("buy groceries")),
+			assert: func(t *testing.T, task *tasks.Task, err error, llm *assistanttesting.ScriptedLLM, asker *userinput.Scripted) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := task.Name(), "buy groceries"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				prompt := llm.PromptsFrom("TaskFinder")[0]
+				if !strings.Contains(prompt, "(hire nanny, buy groceries)") || !strings.Contains(prompt, "when they say: food shopping") {
+					t.Fatalf("expected the tasks and what the user said in the prompt, got %q", prompt)
+				}
+			},
+		},
+		{
+			name: "tries again if the task doesn't exist",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Final("buy milk"),
+				assistanttesting.Final("buy groceries"),
+			),
+			assert: func(t *testing.T, task *tasks.Task, err error, llm *assistanttesting.ScriptedLLM, asker *userinput.Scripted) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := task.Name(), "buy groceries"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := agents(llm), "TaskFinder,TaskFinder"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "gives up after 3 tries",
+			llm:  assistanttesting.NewScriptedLLM().On("TaskFinder", "", assistanttesting.Final("buy milk")),
+			assert: func(t *testing.T, task *tasks.Task, err error, llm *assistanttesting.ScriptedLLM, asker *userinput.Scripted) {
+				if err == nil || !strings.Contains(err.Error(), `could not find task "food shopping"`) {
+					t.Fatalf("expected the task to not be found, got %v", err)
+				}
+				if actual, expected := len(llm.Prompts()), 3; actual != expected {
+					t.Fatalf("expected %d prompts, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "asks the user",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Action("I should ask the user", "user-input", "Which task were you referring to?"),
+				assistanttesting.Final("hire nanny"),
+			),
+			asker: userinput.NewScripted("the nanny one"),
+			assert: func(t *testing.T, task *tasks.Task, err error, llm *assistanttesting.ScriptedLLM, asker *userinput.Scripted) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := task.Name(), "hire nanny"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := strings.Join(asker.Questions(), ","), "Which task were you referring to?"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				// The answer is given to the agent.
+				if prompt := llm.PromptsFrom("TaskFinder")[1]; !strings.Contains(prompt, "the nanny one") {
+					t.Fatalf("expected the answer in the prompt, got %q", prompt)
+				}
+			},
+		},
+		{
+			name: "the LLM fails",
+			llm:  assistanttesting.NewScriptedLLM().OnErr("", "", errors.New("some-error")),
+			assert: func(t *testing.T, task *tasks.Task, err error, llm *assistanttesting.ScriptedLLM, asker *userinput.Scripted) {
+				if err == nil || !strings.Contains(err.Error(), "some-error") {
+					t.Fatalf("expected some-error, got %v", err)
+				}
+			},
+		},
+		{
+			name: "retries invalid responses",
+			llm: assistanttesting.NewScriptedLLM(
+				"not json",
+				assistanttesting.Final("buy groceries"),
+			),
+			assert: func(t *testing.T, task *tasks.Task, err error, llm *assistanttesting.ScriptedLLM, asker *userinput.Scripted) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := task.Name(), "buy groceries"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, _ := setup(tc.llm)
+			if tc.asker == nil {
+				tc.asker = userinput.NewScripted()
+			}
+
+			task, err := injection.Resolve[tasks.TaskFinder](ctx).FindTask(userinput.WithAsker(ctx, tc.asker), "food shopping")
+			tc.assert(t, task, err, tc.llm, tc.asker)
+		})
+	}
+}
+
+func TestDisplayAgent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		llm    *assistanttesting.ScriptedLLM
+		assert func(t *testing.T, answer string, outputs []display.Output, llm *assistanttesting.ScriptedLLM)
+	}{
+		{
+			name: "lists the tasks",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Action("I should use the display tool", "display", "show the user the tasks"),
+				assistanttesting.Action("I should use the list tool", "list", ""),
+				assistanttesting.Final("Listed the tasks"),
+				assistanttesting.Final("Here are your tasks"),
+			),
+			assert: func(t *testing.T, answer string, outputs []display.Output, llm *assistanttesting.ScriptedLLM) {
+				if actual, expected := agents(llm), "Tasks,TaskDisplayer,TaskDisplayer,Tasks"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if len(outputs) != 1 || outputs[0].Kind != "task-list" {
+					t.Fatalf("expected the list, got %+v", outputs)
+				}
+				if actual, expected := answer, "Here are your tasks"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "reads a task",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Action("I should use the display tool", "display", "show the grocery task"),
+				assistanttesting.Action("I should use the read tool", "read", "the grocery task"),
+				assistanttesting.Final("Displayed the buy groceries task"),
+				assistanttesting.Final("Here is the task"),
+			).On("TaskFinder", "", assistanttesting.Final("buy groceries")),
+			assert: func(t *testing.T, answer string, outputs []display.Output, llm *assistanttesting.ScriptedLLM) {
+				if actual, expected := agents(llm), "Tasks,TaskDisplayer,TaskFinder,TaskDisplayer,Tasks"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if len(outputs) != 1 || outputs[0].Kind != "task-details" {
+					t.Fatalf("expected the details, got %+v", outputs)
+				}
+				// The displayer is told what was shown.
+				if prompt := llm.PromptsFrom("TaskDisplayer")[1]; !strings.Contains(prompt, "milk and bread") {
+					t.Fatalf("expected the task in the prompt, got %q", prompt)
+				}
+			},
+		},
+		{
+			name: "an unknown tool is reported to the agent",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Action("I should use the display tool", "display", "show the user the tasks"),
+				assistanttesting.Action("I should fly", "fly", ""),
+				assistanttesting.Final("I can't do that"),
+				assistanttesting.Final("I can't do that"),
+			),
+			assert: func(t *testing.T, answer string, outputs []display.Output, llm *assistanttesting.ScriptedLLM) {
+				if len(outputs) != 0 {
+					t.Fatalf("expected nothing to be displayed, got %+v", outputs)
+				}
+				prompts := llm.PromptsFrom("TaskDisplayer")
+				if len(prompts) != 2 || !strings.Contains(prompts[1], "ERROR:") {
+					t.Fatalf("expected the error in the prompt, got %q", prompts)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, _ := setup(tc.llm)
+
+			buf := &display.Buffer{}
+			answer, err := injection.Resolve[tasks.TaskAgent](ctx).Run(display.WithSink(ctx, buf), "show me my tasks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.assert(t, answer, buf.Outputs(), tc.llm)
+			if actual := tc.llm.Remaining(); actual != 0 {
+				t.Fatalf("expected the whole script to be used, %d left", actual)
+			}
+		})
+	}
+}
+
+func TestModifyAgent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		llm    *assistanttesting.ScriptedLLM
+		assert func(t *testing.T, s tasks.Store, llm *assistanttesting.ScriptedLLM)
+	}{
+		{
+			name: "completes the task",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Action("I should use the modify tool", "modify", "I hired the nanny"),
+				assistanttesting.Action("I should use the complete tool", "complete", "hire nanny"),
+				assistanttesting.Final("Completed hire nanny"),
+				assistanttesting.Final("Done"),
+			).On("TaskFinder", "", assistanttesting.Final("hire nanny")),
+			assert: func(t *testing.T, s tasks.Store, llm *assistanttesting.ScriptedLLM) {
+				if !s.GetTask("hire nanny").Completed() {
+					t.Fatal("expected the task to be completed")
+				}
+				if actual, expected := agents(llm), "Tasks,TaskFinder,TaskModifier,TaskFinder,TaskModifier,Tasks"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				// The modifier is told which task it is.
+				if prompt := llm.PromptsFrom("TaskModifier")[0]; !strings.Contains(prompt, "for the task hire nanny, do the following: I hired the nanny") {
+					t.Fatalf("expected the task in the prompt, got %q", prompt)
+				}
+			},
+		},
+		{
+			name: "adds a note",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Action("I should use the modify tool", "modify", "add a note to the grocery task to buy eggs"),
+				assistanttesting.Action("I should use the add-note tool", "add-note", "add a note to the grocery task to buy eggs"),
+				"buy eggs",
+				assistanttesting.Final("Added the note"),
+				assistanttesting.Final("Done"),
+			).On("TaskFinder", "", assistanttesting.Final("buy groceries")),
+			assert: func(t *testing.T, s tasks.Store, llm *assistanttesting.ScriptedLLM) {
+				notes := s.GetTask("buy groceries").Notes()
+				if len(notes) != 1 || notes[0].Note() != "buy eggs" {
+					t.Fatalf("expected the note, got %+v", notes)
+				}
+				if actual, expected := agents(llm), "Tasks,TaskFinder,TaskModifier,TaskFinder,predictor,TaskModifier,Tasks"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "a failing tool is reported to the agent",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Action("I should use the modify tool", "modify", "the nanny task is due someday"),
+				assistanttesting.Action("I should use the due tool", "due", "someday hire nanny"),
+				assistanttesting.Final("I couldn't tell when it is due"),
+				assistanttesting.Final("I couldn't tell when it is due"),
+			).On("TaskFinder", "", assistanttesting.Final("hire nanny")),
+			assert: func(t *testing.T, s tasks.Store, llm *assistanttesting.ScriptedLLM) {
+				if !s.GetTask("hire nanny").Due().IsZero() {
+					t.Fatal("expected the task to not have a due date")
+				}
+				prompts := llm.PromptsFrom("TaskModifier")
+				if len(prompts) != 2 || !strings.Contains(prompts[1], "ERROR:") {
+					t.Fatalf("expected the error in the prompt, got %q", prompts)
+				}
+			},
+		},
+		{
+			name: "the task can't be found",
+			llm: assistanttesting.NewScriptedLLM(
+				assistanttesting.Action("I should use the modify tool", "modify", "I walked the dog"),
+				assistanttesting.Final("I couldn't find the task"),
+			).On("TaskFinder", "", assistanttesting.Final("walk the dog")),
+			assert: func(t *testing.T, s tasks.Store, llm *assistanttesting.ScriptedLLM) {
+				if len(llm.PromptsFrom("TaskModifier")) != 0 {
+					t.Fatal("expected the modifier to not be used")
+				}
+				prompts := llm.PromptsFrom("Tasks")
+				if len(prompts) != 2 || !strings.Contains(prompts[1], `ERROR: finding task: could not find task`) {
+					t.Fatalf("expected the error in the prompt, got %q", prompts)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, s := setup(tc.llm)
+
+			if _, err := injection.Resolve[tasks.TaskAgent](ctx).Run(display.WithSink(ctx, &display.Buffer{}), "some-goal"); err != nil {
+				t.Fatal(err)
+			}
+			tc.assert(t, s, tc.llm)
+			if actual := tc.llm.Remaining(); actual != 0 {
+				t.Fatalf("expected the whole script to be used, %d left", actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package routing_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/poy/assistant/pkg/display"
+	assistanttesting "github.com/poy/assistant/pkg/testing"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/assistant/pkg/tracing"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanName is the span's name with its tool, task or agent.
+func spanName(s tracetest.SpanStub) string {
+	for _, kv := range s.Attributes {
+		if kv.Key == tracing.ToolKey || kv.Key == tracing.TaskKey {
+			return s.Name + "(" + kv.Value.AsString() + ")"
+		}
+	}
+	return s.Name + "(" + agentOf(s) + ")"
+}
+
+func agentOf(s tracetest.SpanStub) string {
+	for _, kv := range s.Attributes {
+		if kv.Key == tracing.AgentKey {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}
+
+// ancestry returns the names of the span and its parents, from the root.
+func ancestry(spans tracetest.SpanStubs, s tracetest.SpanStub) string {
+	byID := map[string]tracetest.SpanStub{}
+	for _, span := range spans {
+		byID[span.SpanContext.SpanID().String()] = span
+	}
+	names := []string{spanName(s)}
+	for s.Parent.IsValid() {
+		s = byID[s.Parent.SpanID().String()]
+		names = append([]string{spanName(s)}, names...)
+	}
+	return strings.Join(names, " > ")
+}
+
+func TestSpans(t *testing.T) {
+	t.Parallel()
+
+	llm := assistanttesting.NewScriptedLLM(
+		assistanttesting.Action("I should use the modify tool", "modify", "I hired the nanny"),
+		assistanttesting.Action("I should use the complete tool", "complete", "hire nanny"),
+		assistanttesting.Final("Completed hire nanny"),
+		assistanttesting.Final("Done"),
+	).On("TaskFinder", "", assistanttesting.Final("hire nanny"))
+	ctx, _ := setup(llm)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	runCtx := tracing.WithTracerProvider(display.WithSink(ctx, &display.Buffer{}), tp)
+	if _, err := injection.Resolve[tasks.TaskAgent](ctx).Run(runCtx, "I hired the nanny"); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	var (
+		paths []string
+		trace = spans[0].SpanContext.TraceID()
+	)
+	for _, s := range spans {
+		if s.SpanContext.TraceID() != trace {
+			t.Fatalf("expected a single trace, %s isn't in it", spanName(s))
+		}
+		if s.Name == "agent.iteration" && !strings.HasPrefix(ancestry(spans, s), "agent.run(") {
+			t.Fatalf("expected the iteration to be in an agent run, got %s", ancestry(spans, s))
+		}
+		if path := ancestry(spans, s); s.Name == "llm.generate" && !strings.Contains(path, "agent.iteration("+agentOf(s)+") > llm.generate") {
+			t.Fatalf("expected the LLM call to be in an iteration, got %s", path)
+		}
+		if s.Name == "store.complete" || s.Name == "agent.run" {
+			paths = append(paths, ancestry(spans, s))
+		}
+	}
+
+	// The spans are exported as they end, so the innermost are first. The
+	// iterations end once the agent decides which tool to use, so the tool
+	// calls are next to them rather than in them.
+	expected := []string{
+		"agent.run(Tasks) > tool.call(modify) > agent.run(TaskFinder)",
+		"agent.run(Tasks) > tool.call(modify) > agent.run(TaskModifier) > tool.call(complete) > agent.run(TaskFinder)",
+		"agent.run(Tasks) > tool.call(modify) > agent.run(TaskModifier) > tool.call(complete) > store.complete(hire nanny)",
+		"agent.run(Tasks) > tool.call(modify) > agent.run(TaskModifier)",
+		"agent.run(Tasks)",
+	}
+	if actual := strings.Join(paths, "\n"); actual != strings.Join(expected, "\n") {
+		t.Fatalf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), actual)
+	}
+}
@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// Seen remembers who the user was shown each task assigned to. The assign
+// and unassign tools only change the assignee if it is still the one the
+// user saw, so they don't overwrite an assignment someone else made since.
+type Seen struct {
+	mu        sync.Mutex
+	assignees map[*Task]string
+}
+
+// NewSeen returns a Seen for a conversation.
+func NewSeen() *Seen {
+	return &Seen{assignees: map[*Task]string{}}
+}
+
+func init() {
+	injection.Register[*Seen](func(ctx context.Context) *Seen {
+		return NewSeen()
+	})
+}
+
+type seenKey struct{}
+
+// WithSeen returns a context whose calls use the given Seen instead of the
+// injected one. The servers give each run its own, as the injected one is
+// shared by every request.
+func WithSeen(ctx context.Context, s *Seen) context.Context {
+	return context.WithValue(ctx, seenKey{}, s)
+}
+
+// SeenFor returns the Seen given to WithSeen for the call, falling back to
+// s.
+func SeenFor(ctx context.Context, s *Seen) *Seen {
+	if cs, ok := ctx.Value(seenKey{}).(*Seen); ok {
+		return cs
+	}
+	return s
+}
+
+// Show records that the user was shown the task assigned to the assignee.
+func (s *Seen) Show(t *Task, assignee string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assignees[t] = assignee
+}
+
+// Assignee returns who the user was shown the task assigned to. If the task
+// wasn't shown, it is who the task is assigned to now.
+func (s *Seen) Assignee(t *Task) string {
+	s.mu.Lock()
+	assignee, ok := s.assignees[t]
+	s.mu.Unlock()
+	if !ok {
+		return t.Assignee()
+	}
+	return assignee
+}
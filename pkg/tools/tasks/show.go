@@ -2,10 +2,9 @@ package tasks
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/display"
 	"github.com/poy/go-dependency-injection/pkg/injection"
 )
 
@@ -25,9 +24,10 @@ import (
 // Show returns a list of task names.
 func Show(ctx context.Context) tools.Tool {
 	s := injection.Resolve[Store](ctx)
+	d := injection.Resolve[*display.Displayer](ctx)
 	return tools.Tool{
 		Name:        "show",
-		Description: "Show/list/display tasks to the user. The response will not be availbe to the agent. The input is the instructions from the user.",
+		Description: "Show/list/display tasks to the user. A summary of what was shown is returned. The input is the instructions from the user.",
 		Args: []string{
 			"instructions",
 		},
@@ -35,13 +35,7 @@ func Show(ctx context.Context) tools.Tool {
 			"show me the task about buying groceries",
 		},
 		Run: func(ctx context.Context, input string) (string, error) {
-			var names []string
-			for _, name := range s.TaskNames() {
-				names = append(names, fmt.Sprintf("* %s", name))
-			}
-
-			fmt.Println(strings.Join(names, "\n"))
-			return "Displayed the current list of tasks to the user", nil
+			return d.Display(ctx, ListOutput(StoreFor(ctx, s).TaskNames()))
 		},
 	}
 }
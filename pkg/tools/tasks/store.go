@@ -3,11 +3,15 @@ package tasks
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/poy/assistant/pkg/jsonfile"
 	"github.com/poy/go-dependency-injection/pkg/injection"
 )
 
@@ -24,50 +28,81 @@ func ProvideStorePath(p string) {
 	)
 }
 
+// ErrExists is returned by Store.Create when there is already a task with
+// the name.
+var ErrExists = errors.New("the task already exists")
+
+// ErrConflict is returned when a change would overwrite a change someone
+// else made to a shared store.
+var ErrConflict = errors.New("the task was changed by someone else")
+
+type storeKey struct{}
+
+// WithStore returns a context whose injection containers use the given Store
+// instead of the one at the StorePath. The tools also use it for calls made
+// with the context, which allows each request to work on a different user's
+// tasks without a container per user.
+func WithStore(ctx context.Context, s Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, s)
+}
+
+// StoreFor returns the Store given to WithStore for the call, falling back
+// to s.
+func StoreFor(ctx context.Context, s Store) Store {
+	if cs, ok := ctx.Value(storeKey{}).(Store); ok {
+		return cs
+	}
+	return s
+}
+
 func init() {
 	injection.Register[Store](
 		func(ctx context.Context) Store {
-			storePath, ok := injection.TryResolve[StorePath](ctx)
-
-			s := &store{}
-			save := func() {}
-			if ok {
-				save = func() {
-					f, err := os.Create(string(storePath))
-					if err != nil {
-						log.Fatalf("failed to create file %s: %v", storePath, err)
-					}
-					defer f.Close()
-					if err := json.NewEncoder(f).Encode(s.tasks); err != nil {
-						log.Fatalf("failed to encode tasks: %v", err)
-					}
-				}
-
-				// Try loading the file. If it doesn't work, just move on.
-				f, err := os.Open(string(storePath))
-				if err != nil {
-					if !os.IsNotExist(err) {
-						log.Printf("failed to open store file: %s", err)
-					}
-				} else if err := json.NewDecoder(f).Decode(&s.tasks); err != nil {
-					log.Printf("failed to decode store file: %s", err)
-				} else {
-					for i := range s.tasks {
-						s.tasks[i].save = save
-					}
-				}
+			if s, ok := ctx.Value(storeKey{}).(Store); ok {
+				return s
+			}
 
+			storePath, _ := injection.TryResolve[StorePath](ctx)
+			logger, ok := injection.TryResolve[*slog.Logger](ctx)
+			if !ok {
+				logger = slog.Default()
 			}
-			s.save = save
-			return s
+			return newStore(string(storePath), logger)
 		},
 	)
 }
 
-// Store is a store of Tasks.
+// NewStore returns a Store that is saved to the given path. If the path is
+// empty, the store will just be in-memory.
+//
+// Several assistants can share the same path (e.g., a team's list). Each
+// change is made on top of what is in the file at the time, so changes from
+// the others aren't lost, and changes that would overwrite someone else's
+// (e.g., assigning a task that was just assigned) fail with ErrConflict.
+func NewStore(p string) Store {
+	return newStore(p, slog.Default())
+}
+
+func newStore(p string, logger *slog.Logger) *store {
+	s := &store{mu: &sync.Mutex{}, path: p, logger: logger}
+	if p == "" {
+		return s
+	}
+
+	// Try loading the file. If it doesn't work, just move on.
+	if err := s.reload(); err != nil {
+		s.logger.Warn("failed to load the store file", "path", p, "error", err)
+	}
+	return s
+}
+
+// Store is a store of Tasks. It is safe to use from multiple goroutines.
 type Store interface {
 	// Add adds a new Task to the store.
 	Add(name, description string)
+	// Create adds a new Task to the store and returns it. It fails with
+	// ErrExists if there is already a task with the name.
+	Create(name, description string) (*Task, error)
 	// Remove removes a Task from the store.
 	Remove(name string)
 	// TaskNames returns the tasks in the store.
@@ -76,10 +111,18 @@ type Store interface {
 	GetTask(name string) *Task
 }
 
-// store keeps track of the tasks.
+// store keeps track of the tasks. The mutex is shared with the tasks, and is
+// held while saving.
 type store struct {
-	tasks []*Task
-	save  func()
+	mu     *sync.Mutex
+	path   string
+	tasks  []*Task
+	logger *slog.Logger
+
+	// modTime and size are of the file when it was last read or written.
+	// They are used to tell if someone else changed it.
+	modTime time.Time
+	size    int64
 }
 
 // Task represents a task.
@@ -88,51 +131,139 @@ type Task struct {
 	datetime    int64
 	description string
 	completed   *int64
+	due         *int64
+	remind      *int64
+	snoozed     *int64
+	dismissed   bool
 	notes       []Note
-	save        func()
+	assignee    string
+	watchers    []string
+	summary     *noteSummary
+
+	// s is the store the task belongs to. It is nil for tasks that were
+	// decoded on their own.
+	s *store
+}
+
+// lock locks the store the task belongs to. It returns the unlock function.
+func (t *Task) lock() func() {
+	if t.s == nil {
+		return func() {}
+	}
+	t.s.mu.Lock()
+	return t.s.mu.Unlock
 }
 
+// update makes the change to the task and saves it. See store.update.
+func (t *Task) update(change func(t *Task) error) error {
+	if t.s == nil {
+		return change(t)
+	}
+	return t.s.update(t, change)
+}
+
+// secondsCutoff tells the times saved in seconds apart from the ones saved in
+// nanoseconds. In nanoseconds, it is only a few minutes after 1970.
+const secondsCutoff = 1e12
+
+// taskJSON is how a Task is saved.
+type taskJSON struct {
+	Name        string       `json:"name"`
+	Datetime    int64        `json:"datetime"`
+	Description string       `json:"description"`
+	Completed   *int64       `json:"completed"`
+	Due         *int64       `json:"due,omitempty"`
+	Remind      *int64       `json:"remind,omitempty"`
+	Snoozed     *int64       `json:"snoozed,omitempty"`
+	Dismissed   bool         `json:"dismissed,omitempty"`
+	Notes       []Note       `json:"notes"`
+	Assignee    string       `json:"assignee,omitempty"`
+	Watchers    []string     `json:"watchers,omitempty"`
+	Summary     *noteSummary `json:"summary,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. It is the format of the store file,
+// and only the store uses it. It doesn't lock the task because the store
+// encodes the tasks while it is locked. Use Data for anything else.
 func (t *Task) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]any{
-		"name":        t.name,
-		"datetime":    t.datetime,
-		"description": t.description,
-		"completed":   t.completed,
-		"notes":       t.notes,
+	return json.Marshal(taskJSON{
+		Name:        t.name,
+		Datetime:    t.datetime,
+		Description: t.description,
+		Completed:   t.completed,
+		Due:         t.due,
+		Remind:      t.remind,
+		Snoozed:     t.snoozed,
+		Dismissed:   t.dismissed,
+		Notes:       t.notes,
+		Assignee:    t.assignee,
+		Watchers:    t.watchers,
+		Summary:     t.summary,
 	})
 }
 
+// UnmarshalJSON implements json.Unmarshaler.
 func (t *Task) UnmarshalJSON(b []byte) error {
-	var m map[string]any
-	if err := json.Unmarshal(b, &m); err != nil {
+	var v taskJSON
+	if err := json.Unmarshal(b, &v); err != nil {
 		return err
 	}
 
-	t.name, _ = m["name"].(string)
-	t.datetime = int64(m["datetime"].(float64))
-	t.description, _ = m["description"].(string)
-	t.completed, _ = m["completed"].(*int64)
-	t.notes, _ = m["notes"].([]Note)
+	t.name = v.Name
+	t.datetime = v.Datetime
+	t.description = v.Description
+	t.completed = v.Completed
+	if t.completed != nil && *t.completed < secondsCutoff {
+		// Older versions saved the completion time in seconds.
+		ns := time.Unix(*t.completed, 0).UnixNano()
+		t.completed = &ns
+	}
+	t.due = v.Due
+	t.remind = v.Remind
+	t.snoozed = v.Snoozed
+	t.dismissed = v.Dismissed
+	t.notes = v.Notes
+	t.assignee = v.Assignee
+	t.watchers = v.Watchers
+	t.summary = v.Summary
 	return nil
 }
 
 // Name returns the name of the Task.
 func (t *Task) Name() string {
+	defer t.lock()()
 	return t.name
 }
 
+// Rename renames the task, keeping everything else about it. It fails with
+// ErrExists if a different task already has the name.
+func (t *Task) Rename(name string) error {
+	return t.update(func(t *Task) error {
+		if t.s != nil {
+			if other := t.s.getTask(name); other != nil && other != t {
+				return fmt.Errorf("%w: %q", ErrExists, name)
+			}
+		}
+		t.name = name
+		return nil
+	})
+}
+
 // Description returns the description of the Task.
 func (t *Task) Description() string {
+	defer t.lock()()
 	return t.description
 }
 
 // Completed returns if the task has been comleted.
 func (t *Task) Completed() bool {
+	defer t.lock()()
 	return t.completed != nil
 }
 
 // CompletedAt returns the time the task was completed.
 func (t *Task) CompletedAt() time.Time {
+	defer t.lock()()
 	if t.completed == nil {
 		return time.Time{}
 	}
@@ -141,31 +272,234 @@ func (t *Task) CompletedAt() time.Time {
 
 // Complete marks the task as completed.
 func (t *Task) Complete() {
-	now := time.Now().Unix()
-	t.completed = &now
-	t.save()
+	t.logUpdate(func(t *Task) error {
+		if t.completed != nil {
+			// Someone else already completed it.
+			return nil
+		}
+		now := time.Now().UnixNano()
+		t.completed = &now
+		return nil
+	})
 }
 
 // AddNotes adds notes to the task.
 func (t *Task) AddNotes(notes ...string) {
-	for _, note := range notes {
-		t.notes = append(t.notes, Note{
-			datetime: time.Now().UnixNano(),
-			note:     note,
-		})
+	t.logUpdate(func(t *Task) error {
+		for _, note := range notes {
+			t.notes = append(t.notes, Note{
+				datetime: time.Now().UnixNano(),
+				note:     note,
+			})
+		}
+		return nil
+	})
+}
+
+// logUpdate is for changes that can't fail unless the task was removed by
+// someone else.
+func (t *Task) logUpdate(change func(t *Task) error) {
+	if err := t.update(change); err != nil {
+		logger := slog.Default()
+		if t.s != nil {
+			logger = t.s.logger
+		}
+		logger.Error("failed to update the task", "task", t.name, "error", err)
 	}
-	t.save()
 }
 
 // Notes returns the notes for the task.
 func (t *Task) Notes() []Note {
-	return t.notes
+	defer t.lock()()
+	return append([]Note(nil), t.notes...)
+}
+
+// Due returns when the task is due. It is zero if the task doesn't have a due
+// date.
+func (t *Task) Due() time.Time {
+	defer t.lock()()
+	if t.due == nil {
+		return time.Time{}
+	}
+	return time.Unix(0, *t.due)
+}
+
+// SetDue sets when the task is due. A zero time removes the due date. The
+// reminders for the old due date are no longer snoozed or dismissed.
+func (t *Task) SetDue(due time.Time) error {
+	return t.update(func(t *Task) error {
+		t.due = unixNano(due)
+		t.snoozed = nil
+		t.dismissed = false
+		return nil
+	})
+}
+
+// Reminder returns how long before the due date to remind about the task. It
+// returns false if there isn't a reminder, in which case the reminder is when
+// the task is due.
+func (t *Task) Reminder() (time.Duration, bool) {
+	defer t.lock()()
+	if t.remind == nil {
+		return 0, false
+	}
+	return time.Duration(*t.remind), true
+}
+
+// SetReminder sets how long before the due date to remind about the task.
+func (t *Task) SetReminder(before time.Duration) error {
+	if before < 0 {
+		return fmt.Errorf("the reminder must be before the due date, got %s", before)
+	}
+	return t.update(func(t *Task) error {
+		b := int64(before)
+		t.remind = &b
+		t.snoozed = nil
+		t.dismissed = false
+		return nil
+	})
+}
+
+// RemoveReminder removes the reminder.
+func (t *Task) RemoveReminder() error {
+	return t.update(func(t *Task) error {
+		t.remind = nil
+		return nil
+	})
+}
+
+// SnoozedUntil returns when the reminders are snoozed until. It is zero if
+// they aren't snoozed.
+func (t *Task) SnoozedUntil() time.Time {
+	defer t.lock()()
+	if t.snoozed == nil {
+		return time.Time{}
+	}
+	return time.Unix(0, *t.snoozed)
+}
+
+// Snooze stops the reminders until the given time.
+func (t *Task) Snooze(until time.Time) error {
+	return t.update(func(t *Task) error {
+		t.snoozed = unixNano(until)
+		return nil
+	})
+}
+
+// Dismissed returns true if the reminders were dismissed.
+func (t *Task) Dismissed() bool {
+	defer t.lock()()
+	return t.dismissed
+}
+
+// Dismiss stops the reminders until the due date changes.
+func (t *Task) Dismiss() error {
+	return t.update(func(t *Task) error {
+		t.dismissed = true
+		return nil
+	})
+}
+
+// Assignee returns who the task is assigned to. It is empty if the task isn't
+// assigned.
+func (t *Task) Assignee() string {
+	defer t.lock()()
+	return t.assignee
+}
+
+// Watchers returns who is following the task.
+func (t *Task) Watchers() []string {
+	defer t.lock()()
+	return append([]string(nil), t.watchers...)
+}
+
+// Assign assigns the task to the person if it is still assigned to expected
+// (i.e., whatever Assignee returned when the caller looked at the task). It
+// fails with ErrConflict if someone else changed the assignee since then.
+func (t *Task) Assign(expected, person string) error {
+	return t.update(func(t *Task) error {
+		if t.assignee != expected {
+			return fmt.Errorf("%w: %q was assigned to %q", ErrConflict, t.name, t.assignee)
+		}
+		t.assignee = person
+		return nil
+	})
+}
+
+// Unassign removes the assignee if it is still expected. See Assign.
+func (t *Task) Unassign(expected string) error {
+	return t.Assign(expected, "")
+}
+
+// Watch adds the person to the watchers.
+func (t *Task) Watch(person string) error {
+	return t.update(func(t *Task) error {
+		for _, w := range t.watchers {
+			if strings.EqualFold(w, person) {
+				return nil
+			}
+		}
+		t.watchers = append(t.watchers, person)
+		return nil
+	})
+}
+
+// Unwatch removes the person from the watchers.
+func (t *Task) Unwatch(person string) error {
+	return t.update(func(t *Task) error {
+		var watchers []string
+		for _, w := range t.watchers {
+			if !strings.EqualFold(w, person) {
+				watchers = append(watchers, w)
+			}
+		}
+		t.watchers = watchers
+		return nil
+	})
+}
+
+// noteSummary is a summary of the task's notes. It is only used while the
+// notes are the ones it was made from.
+type noteSummary struct {
+	Text string `json:"text"`
+	// Notes and Latest are how many notes there were and when the latest one
+	// was added.
+	Notes  int   `json:"notes"`
+	Latest int64 `json:"latest"`
+}
+
+func summaryOf(text string, notes []Note) *noteSummary {
+	s := &noteSummary{Text: text, Notes: len(notes)}
+	if len(notes) > 0 {
+		s.Latest = notes[len(notes)-1].datetime
+	}
+	return s
+}
+
+// Summary returns the summary of the notes. It returns false if the notes
+// haven't been summarized or have changed since.
+func (t *Task) Summary() (string, bool) {
+	defer t.lock()()
+	if t.summary == nil || *t.summary != *summaryOf(t.summary.Text, t.notes) {
+		return "", false
+	}
+	return t.summary.Text, true
+}
+
+// SetSummary saves the summary of the given notes. The notes are the ones
+// that were summarized, so a note added in the meantime still invalidates
+// the summary.
+func (t *Task) SetSummary(summary string, notes []Note) error {
+	return t.update(func(t *Task) error {
+		t.summary = summaryOf(summary, notes)
+		return nil
+	})
 }
 
 // Note is a note about a task.
 type Note struct {
-	datetime int64  `json:"datetime"`
-	note     string `json:"note"`
+	datetime int64
+	note     string
 }
 
 func (n *Note) MarshalJSON() ([]byte, error) {
@@ -176,13 +510,18 @@ func (n *Note) MarshalJSON() ([]byte, error) {
 }
 
 func (n *Note) UnmarshalJSON(b []byte) error {
-	var m map[string]any
-	if err := json.Unmarshal(b, &m); err != nil {
+	// The datetime is decoded as an int64, as a float64 would round the
+	// nanoseconds.
+	var v struct {
+		Datetime int64  `json:"datetime"`
+		Note     string `json:"note"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
 		return err
 	}
 
-	n.datetime = int64(m["datetime"].(float64))
-	n.note, _ = m["note"].(string)
+	n.datetime = v.Datetime
+	n.note = v.Note
 	return nil
 }
 
@@ -198,33 +537,56 @@ func (n *Note) Note() string {
 
 // Add adds a new Task to the store.
 func (s *store) Add(name, description string) {
-	if s.GetTask(name) != nil {
-		return
+	_, err := s.Create(name, description)
+	if err != nil && !errors.Is(err, ErrExists) {
+		s.logger.Error("failed to add the task", "task", name, "error", err)
 	}
+}
 
-	s.tasks = append(s.tasks, &Task{
-		name:        name,
-		datetime:    time.Now().UnixNano(),
-		description: description,
-		save:        s.save,
+// Create adds a new Task to the store and returns it.
+func (s *store) Create(name, description string) (*Task, error) {
+	var t *Task
+	err := s.change(func() error {
+		if s.getTask(name) != nil {
+			return fmt.Errorf("%w: %q", ErrExists, name)
+		}
+		t = &Task{
+			name:        name,
+			datetime:    time.Now().UnixNano(),
+			description: description,
+			s:           s,
+		}
+		s.tasks = append(s.tasks, t)
+		return nil
 	})
-	s.save()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
 // Remove removes a Task from the store.
 func (s *store) Remove(name string) {
-	name = strings.ToLower(name)
-	for i, t := range s.tasks {
-		if strings.ToLower(t.name) == name {
-			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
-			s.save()
-			return
+	err := s.change(func() error {
+		name = strings.ToLower(name)
+		for i, t := range s.tasks {
+			if strings.ToLower(t.name) == name {
+				s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+				return nil
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to remove the task", "task", name, "error", err)
 	}
 }
 
 // TaskNames returns the tasks in the store.
 func (s *store) TaskNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
 	var names []string
 	for _, t := range s.tasks {
 		names = append(names, t.name)
@@ -234,6 +596,13 @@ func (s *store) TaskNames() []string {
 
 // GetTask returns the Task with the given name.
 func (s *store) GetTask(name string) *Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	return s.getTask(name)
+}
+
+func (s *store) getTask(name string) *Task {
 	name = strings.ToLower(name)
 	for _, t := range s.tasks {
 		if strings.ToLower(t.name) == name {
@@ -242,3 +611,123 @@ func (s *store) GetTask(name string) *Task {
 	}
 	return nil
 }
+
+// update makes the change to the task and saves it. The task is reloaded
+// first, so the change is made on top of what others have saved.
+func (s *store) update(t *Task, change func(t *Task) error) error {
+	return s.change(func() error {
+		current := s.getTask(t.name)
+		if current != t {
+			return fmt.Errorf("%w: task %q was removed", ErrConflict, t.name)
+		}
+		return change(t)
+	})
+}
+
+// change reloads the store, makes the change and then saves it. Nothing is
+// saved if the change fails, and the change is undone if it can't be saved.
+func (s *store) change(f func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" {
+		return f()
+	}
+
+	unlock, err := jsonfile.Lock(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.reload(); err != nil {
+		return err
+	}
+	if err := f(); err != nil {
+		return err
+	}
+	if err := s.save(); err != nil {
+		// Go back to what is in the file, otherwise the tasks would have a
+		// change that refresh never notices isn't saved.
+		if rerr := s.reload(); rerr != nil {
+			s.logger.Error("failed to reload the store file", "path", s.path, "error", rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the tasks to the file.
+func (s *store) save() error {
+	if err := jsonfile.Write(s.path, s.tasks); err != nil {
+		return fmt.Errorf("failed to save tasks: %w", err)
+	}
+	s.stat()
+	return nil
+}
+
+// refresh reloads the store if someone else changed the file.
+func (s *store) refresh() {
+	if s.path == "" {
+		return
+	}
+	info, err := os.Stat(s.path)
+	if err != nil || (info.ModTime().Equal(s.modTime) && info.Size() == s.size) {
+		return
+	}
+	if err := s.reload(); err != nil {
+		s.logger.Warn("failed to reload the store file", "path", s.path, "error", err)
+	}
+}
+
+// reload reads the tasks from the file. The tasks that are still there keep
+// the same *Task so that they can still be used by whoever has them.
+func (s *store) reload() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		// Nothing has been saved (yet).
+		s.tasks = nil
+		s.modTime, s.size = time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read store file: %w", err)
+	}
+
+	var loaded []*Task
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to decode store file: %w", err)
+	}
+
+	tasks := make([]*Task, 0, len(loaded))
+	for _, l := range loaded {
+		t := s.getTask(l.name)
+		if t == nil {
+			t = &Task{}
+		}
+		t.name = l.name
+		t.datetime = l.datetime
+		t.description = l.description
+		t.completed = l.completed
+		t.due = l.due
+		t.remind = l.remind
+		t.snoozed = l.snoozed
+		t.dismissed = l.dismissed
+		t.notes = l.notes
+		t.assignee = l.assignee
+		t.watchers = l.watchers
+		t.summary = l.summary
+		t.s = s
+		tasks = append(tasks, t)
+	}
+	s.tasks = tasks
+	s.stat()
+	return nil
+}
+
+// stat records the file's state so refresh can tell if it changes.
+func (s *store) stat() {
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+		s.size = info.Size()
+	}
+}
@@ -1,7 +1,12 @@
 package tasks_test
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/poy/assistant/pkg/tools/tasks"
 	"github.com/poy/go-dependency-injection/pkg/injection"
@@ -71,8 +76,8 @@ func TestStore(t *testing.T) {
 				s.GetTask("some-task").Complete()
 			},
 			assert: func(t *testing.T, s tasks.Store) {
-				if actual, expected := s.GetTask("some-task").CompletedAt().IsZero(), false; actual != expected {
-					t.Errorf("expected %v, got %v", expected, actual)
+				if actual := s.GetTask("some-task").CompletedAt(); time.Since(actual) > time.Minute || time.Since(actual) < 0 {
+					t.Errorf("expected the task to be completed just now, got %v", actual)
 				}
 				if actual, expected := s.GetTask("some-other-task").CompletedAt().IsZero(), true; actual != expected {
 					t.Errorf("expected %v, got %v", expected, actual)
@@ -117,3 +122,244 @@ func TestStore(t *testing.T) {
 		})
 	}
 }
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	s := tasks.NewStore("")
+	task, err := s.Create("some-task", "some-description")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := task.Description(), "some-description"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if _, err := s.Create("Some-Task", ""); !errors.Is(err, tasks.ErrExists) {
+		t.Fatalf("expected %v, got %v", tasks.ErrExists, err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "tasks.json")
+	s := tasks.NewStore(p)
+	s.Add("some-task", "some-description")
+	s.Add("other-task", "")
+	task := s.GetTask("some-task")
+	if err := task.SetReminder(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	created := task.Data().Created
+
+	if err := task.Rename("other-task"); !errors.Is(err, tasks.ErrExists) {
+		t.Fatalf("expected %v, got %v", tasks.ErrExists, err)
+	}
+	if err := task.Rename("Some-Task"); err != nil {
+		t.Fatal(err)
+	}
+	if err := task.Rename("renamed-task"); err != nil {
+		t.Fatal(err)
+	}
+
+	renamed := tasks.NewStore(p).GetTask("renamed-task")
+	if renamed == nil {
+		t.Fatal("expected the renamed task")
+	}
+	if actual, expected := renamed.Data().Created, created; !actual.Equal(expected) {
+		t.Fatalf("expected the creation time %v, got %v", expected, actual)
+	}
+	if _, ok := renamed.Reminder(); !ok {
+		t.Fatal("expected the reminder to be kept")
+	}
+	if s.GetTask("some-task") != nil {
+		t.Fatal("expected the old name to be gone")
+	}
+}
+
+func TestUnsavedChange(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "tasks")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	s := tasks.NewStore(filepath.Join(dir, "tasks.json"))
+	s.Add("some-task", "some-description")
+
+	// Without the directory, nothing can be saved.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	s.Add("other-task", "")
+	if s.GetTask("other-task") != nil {
+		t.Fatal("expected the change that wasn't saved to be undone")
+	}
+}
+
+func TestSetData(t *testing.T) {
+	t.Parallel()
+
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name              string
+		due               time.Time
+		expectedDismissed bool
+	}{
+		{name: "keeps the reminder dismissed for the same due date", due: due, expectedDismissed: true},
+		{name: "resets the reminder when the due date changes", due: due.Add(24 * time.Hour)},
+		{name: "resets the reminder when the due date is removed"},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := tasks.NewStore("")
+			s.Add("some-task", "some-description")
+			task := s.GetTask("some-task")
+			if err := task.SetDue(due); err != nil {
+				t.Fatal(err)
+			}
+			if err := task.Snooze(due.Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			if err := task.Dismiss(); err != nil {
+				t.Fatal(err)
+			}
+
+			// E.g., the due date was changed on the calendar.
+			d := task.Data()
+			d.Due = tc.due
+			if err := task.SetData(d); err != nil {
+				t.Fatal(err)
+			}
+
+			if actual, expected := task.Dismissed(), tc.expectedDismissed; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+			if actual, expected := task.SnoozedUntil().IsZero(), !tc.expectedDismissed; actual != expected {
+				t.Fatalf("expected the snooze to be reset to be %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestAssignConflict(t *testing.T) {
+	t.Parallel()
+
+	// Two users share the same store in the process (e.g., a team's list
+	// served to both of them).
+	s := tasks.NewStore("")
+	s.Add("some-task", "some-description")
+	a, b := s.GetTask("some-task"), s.GetTask("some-task")
+	aSeen, bSeen := a.Assignee(), b.Assignee()
+
+	if err := a.Assign(aSeen, "alex"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Assign(bSeen, "sam"); !errors.Is(err, tasks.ErrConflict) {
+		t.Fatalf("expected %v, got %v", tasks.ErrConflict, err)
+	}
+	if actual, expected := s.GetTask("some-task").Assignee(), "alex"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if err := b.Unassign(bSeen); !errors.Is(err, tasks.ErrConflict) {
+		t.Fatalf("expected %v, got %v", tasks.ErrConflict, err)
+	}
+}
+
+func TestSharedStore(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		assert func(t *testing.T, a, b tasks.Store)
+	}{
+		{
+			name: "sees the other's tasks",
+			assert: func(t *testing.T, a, b tasks.Store) {
+				a.Add("some-task", "some-description")
+				if b.GetTask("some-task") == nil {
+					t.Fatal("expected the task")
+				}
+			},
+		},
+		{
+			name: "keeps both notes",
+			assert: func(t *testing.T, a, b tasks.Store) {
+				a.Add("some-task", "some-description")
+				aTask, bTask := a.GetTask("some-task"), b.GetTask("some-task")
+
+				aTask.AddNotes("some-note")
+				bTask.AddNotes("some-other-note")
+
+				if actual, expected := len(a.GetTask("some-task").Notes()), 2; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "doesn't overwrite the other's assignment",
+			assert: func(t *testing.T, a, b tasks.Store) {
+				a.Add("some-task", "some-description")
+				aTask, bTask := a.GetTask("some-task"), b.GetTask("some-task")
+
+				if err := aTask.Assign(aTask.Assignee(), "alex"); err != nil {
+					t.Fatal(err)
+				}
+				if err := bTask.Assign("", "sam"); !errors.Is(err, tasks.ErrConflict) {
+					t.Fatalf("expected %v, got %v", tasks.ErrConflict, err)
+				}
+				if actual, expected := a.GetTask("some-task").Assignee(), "alex"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+
+				// Now that b has seen the assignment, it can change it.
+				if err := bTask.Assign(bTask.Assignee(), "sam"); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "can't change a removed task",
+			assert: func(t *testing.T, a, b tasks.Store) {
+				a.Add("some-task", "some-description")
+				bTask := b.GetTask("some-task")
+
+				a.Remove("some-task")
+				if err := bTask.Assign("", "sam"); !errors.Is(err, tasks.ErrConflict) {
+					t.Fatalf("expected %v, got %v", tasks.ErrConflict, err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := filepath.Join(t.TempDir(), "tasks.json")
+			tc.assert(t, tasks.NewStore(p), tasks.NewStore(p))
+		})
+	}
+}
+
+func TestStoreLoadsCompletionSeconds(t *testing.T) {
+	t.Parallel()
+
+	// Older versions saved the completion time in seconds.
+	completed := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	p := filepath.Join(t.TempDir(), "tasks.json")
+	data := fmt.Sprintf(`[{"name":"some-task","datetime":1,"description":"","completed":%d,"notes":null}]`, completed.Unix())
+	if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := tasks.NewStore(p).GetTask("some-task").CompletedAt(); !actual.Equal(completed) {
+		t.Fatalf("expected %v, got %v", completed, actual)
+	}
+}
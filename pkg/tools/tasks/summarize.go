@@ -0,0 +1,131 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+func init() {
+	injection.Register[injection.Group[displayTaskTool]](func(ctx context.Context) injection.Group[displayTaskTool] {
+		return injection.AddToGroup[displayTaskTool](ctx, displayTaskTool{
+			Tool: Summarize(ctx),
+		})
+	})
+	registerTextPredictor[summarizeNotesParams]("NotesSummarizer", summarizeNotesPromptTempl)
+}
+
+// Summarize condenses a task's notes into its current status, the decisions
+// made and the open questions. The summary is saved on the task and reused
+// until the notes change.
+func Summarize(ctx context.Context) tools.Tool {
+	f := injection.Resolve[TaskFinder](ctx)
+	d := injection.Resolve[*display.Displayer](ctx)
+	summarizer := injection.Resolve[predictors.Predictor[summarizeNotesParams, string]](ctx)
+	return tools.Tool{
+		Name:        "summarize",
+		Description: "Summarize the notes of a task into its current status, the decisions made and the open questions. Use it for tasks with a lot of notes.",
+		Args: []string{
+			"name",
+		},
+		Examples: []string{
+			"renovate kitchen",
+		},
+		Run: func(ctx context.Context, input string) (string, error) {
+			t, err := f.FindTask(ctx, input)
+			if err != nil {
+				return "", fmt.Errorf("failed to find task: %w", err)
+			}
+			if t == nil {
+				return "", fmt.Errorf("task %q not found. Try listing the tasks to find the right one.", input)
+			}
+
+			notes := t.Notes()
+			if len(notes) == 0 {
+				return fmt.Sprintf("%s doesn't have any notes to summarize", t.Name()), nil
+			}
+
+			summary, ok := t.Summary()
+			if !ok {
+				summary, err = summarizer.Predict(ctx, summarizeNotesParams{
+					Name:        t.Name(),
+					Description: t.Description(),
+					Notes:       formatNotes(notes),
+				})
+				if err != nil {
+					return "", fmt.Errorf("failed to summarize the notes: %w", err)
+				}
+
+				// Don't change the store if the run was cancelled while predicting.
+				if err := ctx.Err(); err != nil {
+					return "", err
+				}
+				summary = strings.TrimSpace(summary)
+				if err := traceChange(ctx, "set-summary", t.Name(), func() error { return t.SetSummary(summary, notes) }); err != nil {
+					return "", fmt.Errorf("failed to save the summary: %w", err)
+				}
+			}
+
+			return d.Display(ctx, SummaryOutput(t.Name(), summary, len(notes)))
+		},
+	}
+}
+
+// NotesSummary is the summary of a task's notes.
+type NotesSummary struct {
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+	// Notes is how many notes were summarized.
+	Notes int `json:"notes"`
+}
+
+// SummaryOutput is the output for the summary of a task's notes.
+func SummaryOutput(name, summary string, notes int) display.Output {
+	return display.Output{
+		Kind: "task-summary",
+		Text: fmt.Sprintf("Summary of %s (%d %s):\n%s", name, notes, plural(notes, "note"), summary),
+		Data: NotesSummary{Name: name, Summary: summary, Notes: notes},
+	}
+}
+
+func formatNotes(notes []Note) string {
+	var lines []string
+	for _, n := range notes {
+		lines = append(lines, fmt.Sprintf("* [%s] %s", n.Datetime().Format("2006-01-02"), n.Note()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+const (
+	summarizeNotesPromptTempl = `Given the notes of a task (the oldest first), summarize them for the user. Write the current status in a sentence or two, then the decisions that were made and then the questions that are still open. Later notes replace earlier ones. Only use what is in the notes and write none if there aren't any decisions or open questions.
+
+Task: paint the fence
+Description: the back fence
+Notes:
+* [2023-05-01] the fence needs two coats
+* [2023-05-02] picked white paint
+* [2023-05-04] first coat done, is it dry enough for the second?
+Output: Status: The first coat is done.
+Decisions:
+* The fence needs two coats.
+* The paint is white.
+Open questions:
+* Is the first coat dry enough for the second?
+
+Task: {{.Name}}
+Description: {{.Description}}
+Notes:
+{{.Notes}}
+Output: `
+)
+
+type summarizeNotesParams struct {
+	Name        string
+	Description string
+	Notes       string
+}
@@ -0,0 +1,181 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		setup  func(f *fakeTaskFinder, llm *llmstesting.Fake[vertex.Params])
+		run    func(run func() (string, error), f *fakeTaskFinder) (string, error)
+		assert func(t *testing.T, val string, err error, outputs []display.Output, llm *llmstesting.Fake[vertex.Params])
+	}{
+		{
+			name: "summarizes the notes",
+			setup: func(f *fakeTaskFinder, llm *llmstesting.Fake[vertex.Params]) {
+				llm.AlwaysText = " Status: the cabinets are ordered. "
+				f.Add("renovate kitchen", "")
+				f.GetTask("renovate kitchen").AddNotes("picked oak cabinets", "ordered the cabinets")
+			},
+			assert: func(t *testing.T, val string, err error, outputs []display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(outputs) != 1 || outputs[0].Kind != "task-summary" {
+					t.Fatalf("expected the summary, got %+v", outputs)
+				}
+				if actual, expected := outputs[0].Data.(tasks.NotesSummary), (tasks.NotesSummary{Name: "renovate kitchen", Summary: "Status: the cabinets are ordered.", Notes: 2}); actual != expected {
+					t.Fatalf("expected %+v, got %+v", expected, actual)
+				}
+				if !strings.Contains(llm.Prompts[0], "] picked oak cabinets\n* [") {
+					t.Fatalf("expected the notes in the prompt, got %q", llm.Prompts[0])
+				}
+			},
+		},
+		{
+			name: "uses the saved summary",
+			setup: func(f *fakeTaskFinder, llm *llmstesting.Fake[vertex.Params]) {
+				llm.AlwaysText = "Status: the cabinets are ordered."
+				f.Add("renovate kitchen", "")
+				f.GetTask("renovate kitchen").AddNotes("ordered the cabinets")
+			},
+			run: func(run func() (string, error), f *fakeTaskFinder) (string, error) {
+				if _, err := run(); err != nil {
+					return "", err
+				}
+				return run()
+			},
+			assert: func(t *testing.T, val string, err error, outputs []display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(llm.Prompts), 1; actual != expected {
+					t.Fatalf("expected %d prompts, got %d", expected, actual)
+				}
+				if actual, expected := outputs[1].Data.(tasks.NotesSummary).Summary, "Status: the cabinets are ordered."; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "summarizes again when the notes change",
+			setup: func(f *fakeTaskFinder, llm *llmstesting.Fake[vertex.Params]) {
+				llm.AlwaysText = "Status: the cabinets are ordered."
+				f.Add("renovate kitchen", "")
+				f.GetTask("renovate kitchen").AddNotes("ordered the cabinets")
+			},
+			run: func(run func() (string, error), f *fakeTaskFinder) (string, error) {
+				if _, err := run(); err != nil {
+					return "", err
+				}
+				f.GetTask("renovate kitchen").AddNotes("the cabinets arrived")
+				return run()
+			},
+			assert: func(t *testing.T, val string, err error, outputs []display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(llm.Prompts), 2; actual != expected {
+					t.Fatalf("expected %d prompts, got %d", expected, actual)
+				}
+				if !strings.Contains(llm.Prompts[1], "the cabinets arrived") {
+					t.Fatalf("expected the new note in the prompt, got %q", llm.Prompts[1])
+				}
+			},
+		},
+		{
+			name: "no notes",
+			setup: func(f *fakeTaskFinder, llm *llmstesting.Fake[vertex.Params]) {
+				f.Add("renovate kitchen", "")
+			},
+			assert: func(t *testing.T, val string, err error, outputs []display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(outputs) != 0 || len(llm.Prompts) != 0 {
+					t.Fatalf("expected nothing to be summarized, got %+v", outputs)
+				}
+				if actual, expected := val, "renovate kitchen doesn't have any notes to summarize"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "the LLM fails",
+			setup: func(f *fakeTaskFinder, llm *llmstesting.Fake[vertex.Params]) {
+				llm.Err = errors.New("some-error")
+				f.Add("renovate kitchen", "")
+				f.GetTask("renovate kitchen").AddNotes("ordered the cabinets")
+			},
+			assert: func(t *testing.T, val string, err error, outputs []display.Output, llm *llmstesting.Fake[vertex.Params]) {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if len(outputs) != 0 {
+					t.Fatalf("expected nothing to be displayed, got %+v", outputs)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			llm := injection.Resolve[llms.LLM[vertex.Params]](ctx).(*llmstesting.Fake[vertex.Params])
+			f := injection.Resolve[tasks.TaskFinder](ctx).(*fakeTaskFinder)
+			buf := injection.Resolve[display.Sink](ctx).(*display.Buffer)
+			tc.setup(f, llm)
+
+			tool := tasks.Summarize(ctx)
+			run := func() (string, error) {
+				return tool.Run(context.Background(), "renovate kitchen")
+			}
+			if tc.run == nil {
+				tc.run = func(run func() (string, error), f *fakeTaskFinder) (string, error) { return run() }
+			}
+			result, err := tc.run(run, f)
+			tc.assert(t, result, err, buf.Outputs(), llm)
+		})
+	}
+}
+
+func TestSummaryIsSaved(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "tasks.json")
+	s := tasks.NewStore(p)
+	s.Add("renovate kitchen", "")
+	task := s.GetTask("renovate kitchen")
+	task.AddNotes("ordered the cabinets")
+	if err := task.SetSummary("the cabinets are ordered", task.Notes()); err != nil {
+		t.Fatal(err)
+	}
+
+	task = tasks.NewStore(p).GetTask("renovate kitchen")
+	if summary, ok := task.Summary(); !ok || summary != "the cabinets are ordered" {
+		t.Fatalf("expected the saved summary, got %q (%v)", summary, ok)
+	}
+	task.AddNotes("the cabinets arrived")
+	if _, ok := task.Summary(); ok {
+		t.Fatal("expected the summary to be invalidated by the new note")
+	}
+}
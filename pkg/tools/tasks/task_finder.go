@@ -30,7 +30,7 @@ type TaskFinder interface {
 }
 
 type taskFinder struct {
-	agent agents.Agent[string]
+	agent assistanttools.Agent[string]
 	s     Store
 }
 
@@ -56,12 +56,14 @@ func newTaskFinder(ctx context.Context) TaskFinder {
 
 // FindTask finds a task by name using the LLM.
 func (t *taskFinder) FindTask(ctx context.Context, taskName string) (*Task, error) {
+	// Only look at the tasks of whoever is making the call.
+	s := StoreFor(ctx, t.s)
 	for i := 0; i < 3; i++ {
 		name, err := t.agent.Run(
 			ctx,
 			fmt.Sprintf(
 				"Which of the tasks (%s) do you think the user is looking for when they say: %s ",
-				strings.Join(t.s.TaskNames(), ", "),
+				strings.Join(s.TaskNames(), ", "),
 				taskName,
 			),
 		)
@@ -70,7 +72,7 @@ func (t *taskFinder) FindTask(ctx context.Context, taskName string) (*Task, erro
 			return nil, fmt.Errorf("failed to find task: %w", err)
 		}
 
-		task := t.s.GetTask(name)
+		task := s.GetTask(name)
 		if task == nil {
 			continue
 		}
@@ -0,0 +1,128 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotMember is returned when a user asks for a team's list but isn't on
+// the team.
+var ErrNotMember = errors.New("not a member of the team")
+
+// Tenants keeps a separate Store for each user, along with the shared team
+// lists. Use WithStore to give a request the Store for its user.
+type Tenants struct {
+	dir   string
+	teams map[string][]string
+
+	mu     sync.Mutex
+	stores map[string]Store
+}
+
+// NewTenants returns Tenants that saves each user's tasks to
+// dir/users/<user>/tasks.json and each team's tasks to
+// dir/teams/<team>/tasks.json. If dir is empty, the tasks are only kept in
+// memory. The teams are the members of each team.
+func NewTenants(dir string, teams map[string][]string) *Tenants {
+	return &Tenants{
+		dir:    dir,
+		teams:  teams,
+		stores: map[string]Store{},
+	}
+}
+
+// UserStore returns the user's own tasks.
+func (t *Tenants) UserStore(user string) (Store, error) {
+	return t.store("users", user)
+}
+
+// TeamStore returns the team's shared tasks. The user has to be a member of
+// the team.
+func (t *Tenants) TeamStore(user, team string) (Store, error) {
+	if !t.IsMember(user, team) {
+		return nil, fmt.Errorf("%s: %w %s", user, ErrNotMember, team)
+	}
+	return t.store("teams", team)
+}
+
+// IsMember returns true if the user is a member of the team.
+func (t *Tenants) IsMember(user, team string) bool {
+	for _, m := range t.teams[team] {
+		if m == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Teams returns the teams the user is a member of.
+func (t *Tenants) Teams(user string) []string {
+	var result []string
+	for team := range t.teams {
+		if t.IsMember(user, team) {
+			result = append(result, team)
+		}
+	}
+	return result
+}
+
+// WithUser returns a context whose calls use the user's tasks, or the team's
+// if team isn't empty.
+func (t *Tenants) WithUser(ctx context.Context, user, team string) (context.Context, error) {
+	var (
+		s   Store
+		err error
+	)
+	if team == "" {
+		s, err = t.UserStore(user)
+	} else {
+		s, err = t.TeamStore(user, team)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return WithStore(ctx, s), nil
+}
+
+// SafeName escapes the name (e.g., a user ID from a request) so it can be
+// used as a file or directory name that can't escape its directory.
+func SafeName(name string) (string, error) {
+	escaped := url.PathEscape(name)
+	if escaped == "" || escaped == "." || escaped == ".." {
+		return "", fmt.Errorf("invalid name %q", name)
+	}
+	return escaped, nil
+}
+
+func (t *Tenants) store(kind, name string) (Store, error) {
+	// The name comes from the request.
+	escaped, err := SafeName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := kind + "/" + escaped
+	if s, ok := t.stores[key]; ok {
+		return s, nil
+	}
+
+	p := ""
+	if t.dir != "" {
+		dir := filepath.Join(t.dir, kind, escaped)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", name, err)
+		}
+		p = filepath.Join(dir, "tasks.json")
+	}
+
+	s := NewStore(p)
+	t.stores[key] = s
+	return s, nil
+}
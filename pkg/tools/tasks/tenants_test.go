@@ -0,0 +1,182 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/poy/assistant/pkg/display"
+	"github.com/poy/assistant/pkg/tools/tasks"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+)
+
+func TestTenants(t *testing.T) {
+	t.Parallel()
+
+	teams := map[string][]string{
+		"platform": {"alice", "bob"},
+	}
+
+	testCases := []struct {
+		name   string
+		assert func(t *testing.T, tenants *tasks.Tenants, dir string)
+	}{
+		{
+			name: "each user has their own tasks",
+			assert: func(t *testing.T, tenants *tasks.Tenants, dir string) {
+				alice, err := tenants.UserStore("alice")
+				if err != nil {
+					t.Fatal(err)
+				}
+				bob, err := tenants.UserStore("bob")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				alice.Add("some-task", "some-description")
+				if bob.GetTask("some-task") != nil {
+					t.Fatal("expected bob to not see alice's task")
+				}
+				if _, err := os.Stat(filepath.Join(dir, "users", "alice", "tasks.json")); err != nil {
+					t.Fatalf("expected the tasks to be saved: %v", err)
+				}
+			},
+		},
+		{
+			name: "returns the same store for the user",
+			assert: func(t *testing.T, tenants *tasks.Tenants, dir string) {
+				first, _ := tenants.UserStore("alice")
+				first.Add("some-task", "some-description")
+
+				second, _ := tenants.UserStore("alice")
+				if second.GetTask("some-task") == nil {
+					t.Fatal("expected the task")
+				}
+			},
+		},
+		{
+			name: "members share the team list",
+			assert: func(t *testing.T, tenants *tasks.Tenants, dir string) {
+				alice, err := tenants.TeamStore("alice", "platform")
+				if err != nil {
+					t.Fatal(err)
+				}
+				bob, err := tenants.TeamStore("bob", "platform")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				alice.Add("some-task", "some-description")
+				if bob.GetTask("some-task") == nil {
+					t.Fatal("expected bob to see the team's task")
+				}
+			},
+		},
+		{
+			name: "only members can use the team list",
+			assert: func(t *testing.T, tenants *tasks.Tenants, dir string) {
+				_, err := tenants.TeamStore("mallory", "platform")
+				if !errors.Is(err, tasks.ErrNotMember) {
+					t.Fatalf("expected %v, got %v", tasks.ErrNotMember, err)
+				}
+			},
+		},
+		{
+			name: "names can't escape the directory",
+			assert: func(t *testing.T, tenants *tasks.Tenants, dir string) {
+				for _, name := range []string{"", ".", ".."} {
+					if _, err := tenants.UserStore(name); err == nil {
+						t.Fatalf("expected an error for %q", name)
+					}
+				}
+
+				if _, err := tenants.UserStore("../alice"); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := os.Stat(filepath.Join(dir, "users", "..%2Falice")); err != nil {
+					t.Fatalf("expected the name to be escaped: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tc.assert(t, tasks.NewTenants(dir, teams), dir)
+		})
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		err      bool
+	}{
+		{name: "plain", input: "alice", expected: "alice"},
+		{name: "escapes separators", input: "../alice", expected: "..%2Falice"},
+		{name: "empty", input: "", err: true},
+		{name: "dot", input: ".", err: true},
+		{name: "parent", input: "..", err: true},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := tasks.SafeName(tc.input)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestToolsUseTheCallersStore(t *testing.T) {
+	t.Parallel()
+	ctx := injectiontesting.WithTesting(t)
+
+	tenants := tasks.NewTenants("", nil)
+	alice, _ := tenants.UserStore("alice")
+	alice.Add("some-task", "some-description")
+
+	list := tasks.List(ctx)
+	for _, tc := range []struct {
+		user     string
+		expected string
+	}{
+		{user: "alice", expected: "* some-task"},
+		{user: "bob", expected: "You don't have any tasks yet..."},
+	} {
+		buf := &display.Buffer{}
+		callCtx, err := tenants.WithUser(display.WithSink(context.Background(), buf), tc.user, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := list.Run(callCtx, ""); err != nil {
+			t.Fatal(err)
+		}
+		if actual, expected := buf.Outputs()[0].Text, tc.expected; actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+	}
+}
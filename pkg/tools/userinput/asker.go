@@ -0,0 +1,178 @@
+package userinput
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/poy/go-dependency-injection/pkg/injection"
+)
+
+// Asker asks the user a question and waits for the answer.
+type Asker interface {
+	// Ask shows the question to the user and returns their answer. It
+	// returns the context's error if it is done before the user answers.
+	Ask(ctx context.Context, question string) (string, error)
+}
+
+// ProvideAsker asks the user questions with the given Asker.
+func ProvideAsker(a Asker) {
+	injection.Register[Asker](
+		func(ctx context.Context) Asker {
+			return a
+		},
+	)
+}
+
+func init() {
+	ProvideAsker(NewTerminal(os.Stdout))
+}
+
+type askerKey struct{}
+
+// WithAsker returns a context that asks the questions with the given Asker.
+func WithAsker(ctx context.Context, a Asker) context.Context {
+	return context.WithValue(ctx, askerKey{}, a)
+}
+
+// Terminal asks the question on the terminal and reads the answer with
+// ReadLine.
+type Terminal struct {
+	out io.Writer
+}
+
+// NewTerminal returns an Asker that writes the questions to out.
+func NewTerminal(out io.Writer) *Terminal {
+	return &Terminal{out: out}
+}
+
+// Ask implements Asker.
+func (t *Terminal) Ask(ctx context.Context, question string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(t.out, "AI: %s\n", question)
+
+	line, err := ReadLine("You: ")
+	if errors.Is(err, ErrInterrupted) {
+		// While reading a line the terminal is in raw mode, which means
+		// Ctrl-C doesn't send SIGINT. So cancel the run ourselves.
+		if cancel, ok := ctx.Value(interruptKey{}).(context.CancelCauseFunc); ok {
+			cancel(ErrInterrupted)
+		}
+		return "", ErrInterrupted
+	}
+	return line, err
+}
+
+type interruptKey struct{}
+
+// WithInterrupt returns a context that is cancelled (with ErrInterrupted as
+// the cause) when the user presses Ctrl-C while answering a question on the
+// Terminal. Without it, the question just fails with ErrInterrupted.
+func WithInterrupt(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx = context.WithValue(ctx, interruptKey{}, cancel)
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// ErrNoMoreAnswers is returned by Scripted when it runs out of answers.
+var ErrNoMoreAnswers = errors.New("no more answers")
+
+// Scripted answers questions from a script. It is useful for tests. The zero
+// value has no answers.
+type Scripted struct {
+	mu        sync.Mutex
+	answers   []string
+	questions []string
+}
+
+// NewScripted returns an Asker that gives the answers in order.
+func NewScripted(answers ...string) *Scripted {
+	return &Scripted{answers: answers}
+}
+
+// AddAnswers adds answers to the end of the script.
+func (s *Scripted) AddAnswers(answers ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.answers = append(s.answers, answers...)
+}
+
+// Questions returns the questions that were asked.
+func (s *Scripted) Questions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.questions...)
+}
+
+// Ask implements Asker.
+func (s *Scripted) Ask(ctx context.Context, question string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.questions = append(s.questions, question)
+	if len(s.answers) == 0 {
+		return "", fmt.Errorf("failed to answer %q: %w", question, ErrNoMoreAnswers)
+	}
+	answer := s.answers[0]
+	s.answers = s.answers[1:]
+	return answer, nil
+}
+
+// Question is a question waiting for an answer.
+type Question struct {
+	// Text is the question for the user.
+	Text string
+
+	answers chan<- string
+}
+
+// Answer answers the question. Only the first answer is used.
+func (q Question) Answer(answer string) {
+	select {
+	case q.answers <- answer:
+	default:
+	}
+}
+
+// Channel hands the questions to another goroutine (e.g., an HTTP handler)
+// to get answered.
+type Channel struct {
+	questions chan Question
+}
+
+// NewChannel returns an Asker that sends the questions to Questions.
+func NewChannel() *Channel {
+	return &Channel{questions: make(chan Question)}
+}
+
+// Questions returns the questions as they are asked. Each question has to be
+// answered before Ask returns.
+func (c *Channel) Questions() <-chan Question {
+	return c.questions
+}
+
+// Ask implements Asker.
+func (c *Channel) Ask(ctx context.Context, question string) (string, error) {
+	answers := make(chan string, 1)
+	select {
+	case c.questions <- Question{Text: question, answers: answers}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	select {
+	case answer := <-answers:
+		return answer, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
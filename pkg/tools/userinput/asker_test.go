@@ -0,0 +1,110 @@
+package userinput_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poy/assistant/pkg/tools/userinput"
+	"github.com/poy/go-dependency-injection/pkg/injection"
+	injectiontesting "github.com/poy/go-dependency-injection/pkg/injection/testing"
+
+	// Register the scripted Asker.
+	_ "github.com/poy/assistant/pkg/testing"
+)
+
+func TestUserInputTool(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		setup  func(ctx context.Context) context.Context
+		assert func(t *testing.T, val string, err error, s *userinput.Scripted)
+	}{
+		{
+			name: "returns the answer",
+			setup: func(ctx context.Context) context.Context {
+				return ctx
+			},
+			assert: func(t *testing.T, val string, err error, s *userinput.Scripted) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := val, "some-answer"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := s.Questions(), []string{"some-question"}; len(actual) != 1 || actual[0] != expected[0] {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name: "context is cancelled",
+			setup: func(ctx context.Context) context.Context {
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+				return ctx
+			},
+			assert: func(t *testing.T, val string, err error, s *userinput.Scripted) {
+				if actual, expected := errors.Is(err, context.Canceled), true; actual != expected {
+					t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+				}
+				if actual, expected := len(s.Questions()), 0; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := injectiontesting.WithTesting(t)
+
+			s := injection.Resolve[userinput.Asker](ctx).(*userinput.Scripted)
+			s.AddAnswers("some-answer")
+
+			result, err := userinput.UserInputTool(ctx).Run(tc.setup(context.Background()), "some-question")
+			tc.assert(t, result, err, s)
+		})
+	}
+}
+
+func TestScripted(t *testing.T) {
+	t.Parallel()
+
+	s := userinput.NewScripted("first")
+	if actual, err := s.Ask(context.Background(), "one?"); err != nil || actual != "first" {
+		t.Fatalf("expected %q, got %q (%v)", "first", actual, err)
+	}
+	if _, err := s.Ask(context.Background(), "two?"); !errors.Is(err, userinput.ErrNoMoreAnswers) {
+		t.Fatalf("expected %v, got %v", userinput.ErrNoMoreAnswers, err)
+	}
+}
+
+func TestChannel(t *testing.T) {
+	t.Parallel()
+
+	c := userinput.NewChannel()
+	go func() {
+		q := <-c.Questions()
+		q.Answer("answer to " + q.Text)
+	}()
+
+	actual, err := c.Ask(context.Background(), "some-question")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "answer to some-question"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	// Nobody is answering, so only the context can stop it.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Ask(ctx, "some-question"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,554 @@
+package userinput
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned when the user presses Ctrl-C while typing.
+var ErrInterrupted = errors.New("interrupted")
+
+const (
+	// maxHistory is the number of lines kept in the history.
+	maxHistory = 1000
+
+	// continuationPrompt is shown at the start of each extra line of a
+	// multi-line input.
+	continuationPrompt = "... "
+
+	bracketedPasteOn  = "\x1b[?2004h"
+	bracketedPasteOff = "\x1b[?2004l"
+)
+
+// Editor reads lines from the user. When reading from a terminal, it supports
+// editing, history, multi-line pastes and tab completion. Otherwise it simply
+// reads lines. A single Editor should be used for the life of the process so
+// buffered input is never dropped.
+type Editor struct {
+	r           *bufio.Reader
+	out         io.Writer
+	fd          int
+	interactive bool
+
+	history     []string
+	historyPath string
+	completer   func() []string
+
+	// lastRune is the last key of the previous line. It is used to ignore
+	// the \n of a \r\n.
+	lastRune rune
+}
+
+// EditorOption configures an Editor.
+type EditorOption func(*Editor)
+
+// WithHistoryFile loads and saves the history to the given file.
+func WithHistoryFile(p string) EditorOption {
+	return func(e *Editor) {
+		e.historyPath = p
+	}
+}
+
+// WithCompleter sets the function that returns the candidates for tab
+// completion.
+func WithCompleter(f func() []string) EditorOption {
+	return func(e *Editor) {
+		e.completer = f
+	}
+}
+
+// WithInteractive forces the editing features on or off instead of detecting
+// whether the input is a terminal.
+func WithInteractive(interactive bool) EditorOption {
+	return func(e *Editor) {
+		e.interactive = interactive
+	}
+}
+
+// NewEditor returns an Editor that reads from in and writes to out.
+func NewEditor(in io.Reader, out io.Writer, opts ...EditorOption) *Editor {
+	e := &Editor{
+		r:   bufio.NewReader(in),
+		out: out,
+		fd:  -1,
+	}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		e.fd = int(f.Fd())
+		e.interactive = true
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	e.loadHistory()
+	return e
+}
+
+// ReadLine shows the prompt and returns the line the user entered. It returns
+// io.EOF when there is no more input and ErrInterrupted if the user pressed
+// Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if !e.interactive {
+		return e.readPlainLine(prompt)
+	}
+
+	if e.fd >= 0 {
+		state, err := term.MakeRaw(e.fd)
+		if err != nil {
+			return "", fmt.Errorf("failed to put terminal in raw mode: %w", err)
+		}
+		defer term.Restore(e.fd, state)
+	}
+	fmt.Fprint(e.out, bracketedPasteOn)
+	defer fmt.Fprint(e.out, bracketedPasteOff)
+
+	s := &lineState{
+		prompt:     prompt,
+		historyIdx: len(e.history),
+		lastRune:   e.lastRune,
+	}
+	e.refresh(s)
+
+	for {
+		r, _, err := e.r.ReadRune()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(s.buf) > 0 {
+				break
+			}
+			return "", err
+		}
+
+		switch {
+		case r == '\x1b':
+			if err := e.handleEscape(s); err != nil {
+				return "", err
+			}
+		case r == '\r' || r == '\n':
+			if s.pasting {
+				// Terminals send \r\n or \r for newlines in pastes.
+				if r == '\n' && s.lastRune == '\r' {
+					break
+				}
+				s.insert('\n')
+				break
+			}
+			if r == '\n' && s.lastRune == '\r' {
+				break
+			}
+			// A trailing backslash continues the input on the next line.
+			if s.pos == len(s.buf) && s.pos > 0 && s.buf[s.pos-1] == '\\' {
+				s.buf[s.pos-1] = '\n'
+				break
+			}
+			s.lastRune = r
+			return e.finish(s), nil
+		case r == 1: // Ctrl-A
+			s.pos = s.lineStart()
+		case r == 2: // Ctrl-B
+			s.left()
+		case r == 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case r == 4: // Ctrl-D
+			if len(s.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			s.delete()
+		case r == 5: // Ctrl-E
+			s.pos = s.lineEnd()
+		case r == 6: // Ctrl-F
+			s.right()
+		case r == 8 || r == 127: // Backspace
+			s.backspace()
+		case r == '\t':
+			if s.pasting {
+				s.insert(r)
+				break
+			}
+			e.complete(s)
+		case r == 11: // Ctrl-K
+			s.buf = append(s.buf[:s.pos], s.buf[s.lineEnd():]...)
+		case r == 12: // Ctrl-L
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+			s.cursorRow = 0
+		case r == 14: // Ctrl-N
+			e.historyNext(s)
+		case r == 16: // Ctrl-P
+			e.historyPrev(s)
+		case r == 21: // Ctrl-U
+			start := s.lineStart()
+			s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+			s.pos = start
+		case r == 23: // Ctrl-W
+			s.deleteWord()
+		case unicode.IsPrint(r):
+			s.insert(r)
+		}
+		s.lastRune = r
+		e.refresh(s)
+	}
+
+	return e.finish(s), nil
+}
+
+func (e *Editor) readPlainLine(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (e *Editor) finish(s *lineState) string {
+	e.lastRune = s.lastRune
+	s.pos = len(s.buf)
+	e.refresh(s)
+	fmt.Fprint(e.out, "\r\n")
+
+	line := string(s.buf)
+	e.addHistory(line)
+	return line
+}
+
+func (e *Editor) handleEscape(s *lineState) error {
+	r, _, err := e.r.ReadRune()
+	if err != nil {
+		return err
+	}
+	if r != '[' && r != 'O' {
+		// Alt+key, just ignore it.
+		return nil
+	}
+
+	var params strings.Builder
+	for {
+		r, _, err = e.r.ReadRune()
+		if err != nil {
+			return err
+		}
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+		params.WriteRune(r)
+	}
+
+	switch params.String() + string(r) {
+	case "A":
+		e.historyPrev(s)
+	case "B":
+		e.historyNext(s)
+	case "C":
+		s.right()
+	case "D":
+		s.left()
+	case "H", "1~", "7~":
+		s.pos = s.lineStart()
+	case "F", "4~", "8~":
+		s.pos = s.lineEnd()
+	case "3~":
+		s.delete()
+	case "200~":
+		s.pasting = true
+	case "201~":
+		s.pasting = false
+	}
+	return nil
+}
+
+// complete completes the task name that is being typed. Task names can have
+// spaces, so the longest match that starts at a word boundary is used.
+func (e *Editor) complete(s *lineState) {
+	if e.completer == nil {
+		return
+	}
+	candidates := e.completer()
+	before := s.buf[:s.pos]
+
+	for start := 0; start < len(before); start++ {
+		if start > 0 && !unicode.IsSpace(before[start-1]) {
+			continue
+		}
+		typed := strings.ToLower(string(before[start:]))
+
+		var matches []string
+		for _, c := range candidates {
+			if strings.HasPrefix(strings.ToLower(c), typed) {
+				matches = append(matches, c)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		completion := []rune(commonPrefix(matches))
+		if len(completion) > len(before)-start {
+			rest := append([]rune{}, s.buf[s.pos:]...)
+			s.buf = append(append(s.buf[:start], completion...), rest...)
+			s.pos = start + len(completion)
+			return
+		}
+
+		if len(matches) > 1 {
+			// Nothing more to complete, show the options.
+			s.pos = len(s.buf)
+			e.refresh(s)
+			fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(matches, "  "))
+			s.cursorRow = 0
+		}
+		return
+	}
+}
+
+func commonPrefix(vals []string) string {
+	prefix := []rune(vals[0])
+	for _, v := range vals[1:] {
+		rs := []rune(v)
+		i := 0
+		for i < len(prefix) && i < len(rs) && unicode.ToLower(prefix[i]) == unicode.ToLower(rs[i]) {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	return string(prefix)
+}
+
+func (e *Editor) historyPrev(s *lineState) {
+	if s.historyIdx == 0 {
+		return
+	}
+	if s.historyIdx == len(e.history) {
+		s.saved = string(s.buf)
+	}
+	s.historyIdx--
+	s.buf = []rune(e.history[s.historyIdx])
+	s.pos = len(s.buf)
+}
+
+func (e *Editor) historyNext(s *lineState) {
+	if s.historyIdx >= len(e.history) {
+		return
+	}
+	s.historyIdx++
+	if s.historyIdx == len(e.history) {
+		s.buf = []rune(s.saved)
+	} else {
+		s.buf = []rune(e.history[s.historyIdx])
+	}
+	s.pos = len(s.buf)
+}
+
+func (e *Editor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+
+	if e.historyPath == "" {
+		return
+	}
+	f, err := os.OpenFile(e.historyPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		slog.Warn("failed to open the history file", "error", err)
+		return
+	}
+	defer f.Close()
+
+	// Each entry is JSON encoded so that multi-line entries take up a single
+	// line in the file.
+	if err := json.NewEncoder(f).Encode(line); err != nil {
+		slog.Warn("failed to save the history", "error", err)
+	}
+}
+
+func (e *Editor) loadHistory() {
+	if e.historyPath == "" {
+		return
+	}
+	f, err := os.Open(e.historyPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("failed to open the history file", "error", err)
+		}
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var line string
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		e.history = append(e.history, line)
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// refresh redraws the prompt and the buffer and puts the cursor in the right
+// spot. Long and multi-line inputs span several rows, so it keeps track of
+// the row the cursor is on to know where to start redrawing from.
+func (e *Editor) refresh(s *lineState) {
+	width := 80
+	if e.fd >= 0 {
+		if w, _, err := term.GetSize(e.fd); err == nil && w > 0 {
+			width = w
+		}
+	}
+
+	var sb strings.Builder
+	if s.cursorRow > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dA", s.cursorRow)
+	}
+	sb.WriteString("\r\x1b[J")
+
+	var row, col, curRow, curCol int
+	wrapped := false
+	advance := func(r rune) {
+		sb.WriteRune(r)
+		col++
+		wrapped = false
+		if col == width {
+			row++
+			col = 0
+			wrapped = true
+		}
+	}
+
+	for _, r := range s.prompt {
+		advance(r)
+	}
+	for i, r := range s.buf {
+		if i == s.pos {
+			curRow, curCol = row, col
+		}
+		if r == '\n' {
+			sb.WriteString("\r\n")
+			row++
+			col = 0
+			for _, r := range continuationPrompt {
+				advance(r)
+			}
+			continue
+		}
+		advance(r)
+	}
+	if s.pos == len(s.buf) {
+		curRow, curCol = row, col
+	}
+	if wrapped {
+		// The terminal holds the cursor at the end of the row until something
+		// else is written.
+		sb.WriteString("\r\n")
+	}
+
+	if up := row - curRow; up > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dA", up)
+	}
+	sb.WriteString("\r")
+	if curCol > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dC", curCol)
+	}
+	s.cursorRow = curRow
+
+	fmt.Fprint(e.out, sb.String())
+}
+
+// lineState is the state of the line being edited.
+type lineState struct {
+	prompt string
+	buf    []rune
+	pos    int
+
+	// cursorRow is the row (relative to the prompt) the cursor is on.
+	cursorRow int
+
+	historyIdx int
+	// saved is what was typed before browsing the history.
+	saved string
+
+	pasting  bool
+	lastRune rune
+}
+
+func (s *lineState) insert(r rune) {
+	s.buf = append(s.buf, 0)
+	copy(s.buf[s.pos+1:], s.buf[s.pos:])
+	s.buf[s.pos] = r
+	s.pos++
+}
+
+func (s *lineState) backspace() {
+	if s.pos == 0 {
+		return
+	}
+	s.buf = append(s.buf[:s.pos-1], s.buf[s.pos:]...)
+	s.pos--
+}
+
+func (s *lineState) delete() {
+	if s.pos == len(s.buf) {
+		return
+	}
+	s.buf = append(s.buf[:s.pos], s.buf[s.pos+1:]...)
+}
+
+func (s *lineState) deleteWord() {
+	start := s.pos
+	for start > 0 && unicode.IsSpace(s.buf[start-1]) {
+		start--
+	}
+	for start > 0 && !unicode.IsSpace(s.buf[start-1]) {
+		start--
+	}
+	s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+	s.pos = start
+}
+
+func (s *lineState) left() {
+	if s.pos > 0 {
+		s.pos--
+	}
+}
+
+func (s *lineState) right() {
+	if s.pos < len(s.buf) {
+		s.pos++
+	}
+}
+
+// lineStart returns the start of the current line of a multi-line input.
+func (s *lineState) lineStart() int {
+	i := s.pos
+	for i > 0 && s.buf[i-1] != '\n' {
+		i--
+	}
+	return i
+}
+
+// lineEnd returns the end of the current line of a multi-line input.
+func (s *lineState) lineEnd() int {
+	i := s.pos
+	for i < len(s.buf) && s.buf[i] != '\n' {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,151 @@
+package userinput_test
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/poy/assistant/pkg/tools/userinput"
+)
+
+func TestEditor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		interactive bool
+		completions []string
+		expected    []string
+		expectedErr error
+	}{
+		{
+			name:     "plain lines share the buffer",
+			input:    "first\nsecond\r\nthird",
+			expected: []string{"first", "second", "third"},
+		},
+		{
+			name:        "edits the line",
+			input:       "helo\x1b[Dl\x1b[C!\x7f\r",
+			interactive: true,
+			expected:    []string{"hello"},
+		},
+		{
+			name:        "ctrl-u and ctrl-w",
+			input:       "some words\x17other\x15again\r",
+			interactive: true,
+			expected:    []string{"again"},
+		},
+		{
+			name:        "recalls history",
+			input:       "first\rsecond\r\x1b[A\x1b[A\x1b[B\r",
+			interactive: true,
+			expected:    []string{"first", "second", "second"},
+		},
+		{
+			name:        "multi-line paste",
+			input:       "\x1b[200~line one\r\nline two\x1b[201~\r",
+			interactive: true,
+			expected:    []string{"line one\nline two"},
+		},
+		{
+			name:        "backslash continues the line",
+			input:       "line one\\\rline two\r",
+			interactive: true,
+			expected:    []string{"line one\nline two"},
+		},
+		{
+			name:        "completes task names",
+			input:       "read the groc\t\r",
+			interactive: true,
+			completions: []string{"groceries for the week", "Grocery run", "clean the garage"},
+			expected:    []string{"read the grocer"},
+		},
+		{
+			name:        "completes task names with spaces",
+			input:       "show clean the g\t\r",
+			interactive: true,
+			completions: []string{"buy groceries", "clean the garage"},
+			expected:    []string{"show clean the garage"},
+		},
+		{
+			name:        "ctrl-c interrupts",
+			input:       "some goal\x03",
+			interactive: true,
+			expectedErr: userinput.ErrInterrupted,
+		},
+		{
+			name:        "ctrl-d on an empty line",
+			input:       "\x04",
+			interactive: true,
+			expectedErr: io.EOF,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := userinput.NewEditor(
+				strings.NewReader(tc.input),
+				io.Discard,
+				userinput.WithInteractive(tc.interactive),
+				userinput.WithCompleter(func() []string { return tc.completions }),
+			)
+
+			for _, expected := range tc.expected {
+				actual, err := e.ReadLine("You: ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			}
+
+			if tc.expectedErr != nil {
+				if _, err := e.ReadLine("You: "); !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestEditorHistoryFile(t *testing.T) {
+	t.Parallel()
+	p := filepath.Join(t.TempDir(), "history")
+
+	e := userinput.NewEditor(
+		strings.NewReader("first\rline one\\\rline two\r"),
+		io.Discard,
+		userinput.WithInteractive(true),
+		userinput.WithHistoryFile(p),
+	)
+	for i := 0; i < 2; i++ {
+		if _, err := e.ReadLine("You: "); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A new editor should be able to recall the previous lines.
+	e = userinput.NewEditor(
+		strings.NewReader("\x1b[A\r\x1b[A\x1b[A\r"),
+		io.Discard,
+		userinput.WithInteractive(true),
+		userinput.WithHistoryFile(p),
+	)
+	for _, expected := range []string{"line one\nline two", "first"} {
+		actual, err := e.ReadLine("You: ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+	}
+}
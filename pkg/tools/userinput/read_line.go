@@ -1,21 +1,31 @@
 package userinput
 
 import (
-	"bufio"
-	"errors"
 	"os"
+	"sync"
 )
 
-// ReadLine returns a line from the user.
-func ReadLine() (string, error) {
-	scanner := bufio.NewScanner(os.Stdin)
+var (
+	defaultEditorMu sync.Mutex
+	defaultEditor   *Editor
+)
 
-	for scanner.Scan() {
-		return scanner.Text(), nil
-	}
+// SetDefaultEditor sets the Editor that ReadLine uses.
+func SetDefaultEditor(e *Editor) {
+	defaultEditorMu.Lock()
+	defer defaultEditorMu.Unlock()
+	defaultEditor = e
+}
 
-	if err := scanner.Err(); err != nil {
-		return "", scanner.Err()
+// ReadLine shows the prompt and returns a line from the user. Every call
+// shares the same Editor so buffered input isn't lost between calls.
+func ReadLine(prompt string) (string, error) {
+	defaultEditorMu.Lock()
+	if defaultEditor == nil {
+		defaultEditor = NewEditor(os.Stdin, os.Stdout)
 	}
-	return "", errors.New("unable to get input from user")
+	e := defaultEditor
+	defaultEditorMu.Unlock()
+
+	return e.ReadLine(prompt)
 }
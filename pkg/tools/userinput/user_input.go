@@ -2,7 +2,6 @@ package userinput
 
 import (
 	"context"
-	"fmt"
 	"reflect"
 
 	"github.com/google/go-react/pkg/tools"
@@ -18,25 +17,25 @@ func Register[TToolGroup any]() {
 
 		// This panicking probably implies that the underlying type needs to embed
 		// tools.Tool.
-		u := reflect.ValueOf(UserInputTool())
+		u := reflect.ValueOf(UserInputTool(ctx))
 		toolVal.Set(u)
 
 		return injection.AddToGroup[TToolGroup](ctx, t)
 	})
 }
 
-// UserInputTool asks the user for input.
-func UserInputTool() tools.Tool {
+// UserInputTool asks the user for input with the Asker.
+func UserInputTool(ctx context.Context) tools.Tool {
+	a := injection.Resolve[Asker](ctx)
 	return tools.Tool{
 		Name:        "user-input",
 		Description: "Ask the user a question. The input is what is displayed to the user.",
 		Examples:    []string{"some question to the user"},
 		Run: func(ctx context.Context, input string) (string, error) {
-			fmt.Printf("AI: %s\n", input)
-
-			// Read a line from the user.
-			fmt.Print("You: ")
-			return ReadLine()
+			if ca, ok := ctx.Value(askerKey{}).(Asker); ok {
+				return ca.Ask(ctx, input)
+			}
+			return a.Ask(ctx, input)
 		},
 	}
 }
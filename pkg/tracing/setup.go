@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Setup sets the global TracerProvider to export the spans with the
+// exporter (e.g., stdouttrace or otlptracegrpc). The returned function
+// flushes the spans that haven't been exported yet and has to be called
+// before exiting.
+func Setup(exporter sdktrace.SpanExporter) func(context.Context) error {
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName("assistant"))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown
+}
@@ -0,0 +1,81 @@
+// Package tracing records OpenTelemetry spans for the agent runs, reasoning
+// iterations, LLM calls, tool calls and store changes, so a goal that goes
+// through nested agents (e.g., Tasks → modify → TaskFinder → user-input) can
+// be followed as a single trace.
+//
+// The spans go to the global TracerProvider (see Setup), or the one given to
+// WithTracerProvider.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer.
+const instrumentationName = "github.com/poy/assistant"
+
+// The attributes on the spans.
+const (
+	// AgentKey is the name of the agent (or predictor).
+	AgentKey = attribute.Key("assistant.agent")
+	// ToolKey is the name of the tool.
+	ToolKey = attribute.Key("assistant.tool")
+	// TaskKey is the name of the task that was changed.
+	TaskKey = attribute.Key("assistant.task")
+	// IterationKey is which reasoning iteration of the agent it is, starting
+	// at 1.
+	IterationKey = attribute.Key("assistant.iteration")
+	// ActionKey is the tool the agent decided to use. It is empty if the
+	// agent came up with the final answer.
+	ActionKey = attribute.Key("assistant.action")
+	// InputSizeKey is how long the input (e.g., the goal, prompt or tool
+	// input) is.
+	InputSizeKey = attribute.Key("assistant.input.size")
+	// OutputSizeKey is how long the output (e.g., the final answer, response
+	// or observation) is.
+	OutputSizeKey = attribute.Key("assistant.output.size")
+)
+
+type providerKey struct{}
+
+// WithTracerProvider returns a context whose spans go to the given
+// TracerProvider instead of the global one (e.g., for a test).
+func WithTracerProvider(ctx context.Context, tp trace.TracerProvider) context.Context {
+	return context.WithValue(ctx, providerKey{}, tp)
+}
+
+// providerFor returns the TracerProvider given to WithTracerProvider,
+// falling back to the global one.
+func providerFor(ctx context.Context) trace.TracerProvider {
+	if tp, ok := ctx.Value(providerKey{}).(trace.TracerProvider); ok {
+		return tp
+	}
+	return otel.GetTracerProvider()
+}
+
+// Start starts a span. It has to be ended with End.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return providerFor(ctx).Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if there is one, and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Run runs f in a span.
+func Run(ctx context.Context, name string, f func(ctx context.Context) error, attrs ...attribute.KeyValue) error {
+	ctx, span := Start(ctx, name, attrs...)
+	err := f(ctx)
+	End(span, err)
+	return err
+}
@@ -0,0 +1,125 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-react/pkg/agents"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type fakePredictor struct {
+	resp agents.Reasoning[string]
+	err  error
+}
+
+func (f fakePredictor) Predict(ctx context.Context, req agents.PromptData[string]) (agents.Reasoning[string], error) {
+	return f.resp, f.err
+}
+
+func attrs(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range s.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		run    func(ctx context.Context)
+		assert func(t *testing.T, spans tracetest.SpanStubs)
+	}{
+		{
+			name: "llm call",
+			run: func(ctx context.Context) {
+				llm := &llmstesting.Fake[vertex.Params]{AlwaysText: "some-response"}
+				tracing.NewLLM[vertex.Params](llm, "TaskFinder").Generate(ctx, "some-prompt", vertex.Params{})
+			},
+			assert: func(t *testing.T, spans tracetest.SpanStubs) {
+				a := attrs(spans[0])
+				if spans[0].Name != "llm.generate" || a[tracing.AgentKey].AsString() != "TaskFinder" || a[tracing.InputSizeKey].AsInt64() != 11 || a[tracing.OutputSizeKey].AsInt64() != 13 {
+					t.Fatalf("unexpected span %s %v", spans[0].Name, a)
+				}
+			},
+		},
+		{
+			name: "reasoning iteration",
+			run: func(ctx context.Context) {
+				p := tracing.NewPredictor[string](fakePredictor{resp: agents.Reasoning[string]{Action: "add-note"}}, "TaskModifier")
+				p.Predict(ctx, agents.PromptData[string]{Chains: make([]agents.ThoughtIteration[string], 2)})
+			},
+			assert: func(t *testing.T, spans tracetest.SpanStubs) {
+				a := attrs(spans[0])
+				if spans[0].Name != "agent.iteration" || a[tracing.IterationKey].AsInt64() != 3 || a[tracing.ActionKey].AsString() != "add-note" {
+					t.Fatalf("unexpected span %s %v", spans[0].Name, a)
+				}
+			},
+		},
+		{
+			name: "tool call",
+			run: func(ctx context.Context) {
+				tool := tracing.Tool(tools.Tool{
+					Name: "complete",
+					Run: func(ctx context.Context, input string) (string, error) {
+						return "", errors.New("some-error")
+					},
+				}, "TaskModifier")
+				tool.Run(ctx, "hire nanny")
+			},
+			assert: func(t *testing.T, spans tracetest.SpanStubs) {
+				a := attrs(spans[0])
+				if spans[0].Name != "tool.call" || a[tracing.ToolKey].AsString() != "complete" || a[tracing.InputSizeKey].AsInt64() != 10 {
+					t.Fatalf("unexpected span %s %v", spans[0].Name, a)
+				}
+				if spans[0].Status.Code != codes.Error || spans[0].Status.Description != "some-error" || len(spans[0].Events) != 1 {
+					t.Fatalf("expected the error to be recorded, got %+v", spans[0].Status)
+				}
+			},
+		},
+		{
+			name: "nested spans",
+			run: func(ctx context.Context) {
+				tracing.Run(ctx, "parent", func(ctx context.Context) error {
+					return tracing.Run(ctx, "child", func(ctx context.Context) error { return nil })
+				})
+			},
+			assert: func(t *testing.T, spans tracetest.SpanStubs) {
+				// Spans are exported as they end.
+				child, parent := spans[0], spans[1]
+				if child.Parent.SpanID() != parent.SpanContext.SpanID() || child.SpanContext.TraceID() != parent.SpanContext.TraceID() {
+					t.Fatal("expected the child to be in the parent")
+				}
+				if parent.Status.Code == codes.Error {
+					t.Fatal("expected the span to be ok")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // Avoid issues with closure.
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			tc.run(tracing.WithTracerProvider(context.Background(), tp))
+			spans := exporter.GetSpans()
+			if len(spans) == 0 {
+				t.Fatal("expected spans")
+			}
+			tc.assert(t, spans)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/tools"
+)
+
+type tracedLLM[TParams any] struct {
+	llm   llms.LLM[TParams]
+	agent string
+}
+
+// NewLLM wraps the LLM so that each call is a span.
+func NewLLM[TParams any](llm llms.LLM[TParams], agent string) llms.LLM[TParams] {
+	return tracedLLM[TParams]{llm: llm, agent: agent}
+}
+
+// Generate implements llms.LLM.
+func (l tracedLLM[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	ctx, span := Start(ctx, "llm.generate", AgentKey.String(l.agent), InputSizeKey.Int(len(prompt)))
+	resp, err := l.llm.Generate(ctx, prompt, params)
+	span.SetAttributes(OutputSizeKey.Int(len(resp)))
+	End(span, err)
+	return resp, err
+}
+
+type tracedPredictor[TOut any] struct {
+	p     predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]]
+	agent string
+}
+
+// NewPredictor wraps the agent's Predictor so that each reasoning iteration
+// is a span.
+func NewPredictor[TOut any](
+	p predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]],
+	agent string,
+) predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]] {
+	return tracedPredictor[TOut]{p: p, agent: agent}
+}
+
+// Predict implements Predictor.
+func (t tracedPredictor[TOut]) Predict(
+	ctx context.Context,
+	req agents.PromptData[TOut],
+) (agents.Reasoning[TOut], error) {
+	ctx, span := Start(ctx, "agent.iteration", AgentKey.String(t.agent), IterationKey.Int(len(req.Chains)+1))
+	resp, err := t.p.Predict(ctx, req)
+	if err == nil {
+		span.SetAttributes(ActionKey.String(resp.Action))
+	}
+	End(span, err)
+	return resp, err
+}
+
+// Tool wraps the tool so that each call is a span.
+func Tool(t tools.Tool, agent string) tools.Tool {
+	run := t.Run
+	t.Run = func(ctx context.Context, input string) (string, error) {
+		ctx, span := Start(ctx, "tool.call",
+			AgentKey.String(agent),
+			ToolKey.String(t.Name),
+			InputSizeKey.Int(len(input)),
+		)
+		observation, err := run(ctx, input)
+		span.SetAttributes(OutputSizeKey.Int(len(observation)))
+		End(span, err)
+		return observation, err
+	}
+	return t
+}
@@ -0,0 +1,111 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/poy/assistant/pkg/jsonfile"
+)
+
+// Stats is the usage over every goal.
+type Stats struct {
+	Goals   int       `json:"goals"`
+	Since   time.Time `json:"since"`
+	Entries []Entry   `json:"entries"`
+}
+
+// LoadStats reads the stats from the file. A missing file is not an error.
+func LoadStats(p string) (Stats, error) {
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Stats{}, nil
+	}
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to read usage stats: %w", err)
+	}
+	var s Stats
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Stats{}, fmt.Errorf("failed to decode usage stats: %w", err)
+	}
+	return s, nil
+}
+
+// Save writes the stats to the file. The file is replaced all at once, so
+// it is never left half written.
+func (s Stats) Save(p string) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := jsonfile.Write(p, s); err != nil {
+		return fmt.Errorf("failed to write usage stats: %w", err)
+	}
+	return nil
+}
+
+// Add adds a goal's usage to the stats.
+func (s *Stats) Add(t *Tracker) {
+	if s.Since.IsZero() {
+		s.Since = time.Now()
+	}
+	s.Goals++
+
+	totals := map[key]*Usage{}
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		totals[key{agent: e.Agent, tool: e.Tool}] = &e.Usage
+	}
+	for _, e := range t.Entries() {
+		if u, ok := totals[key{agent: e.Agent, tool: e.Tool}]; ok {
+			u.add(e.Usage)
+			continue
+		}
+		s.Entries = append(s.Entries, e)
+	}
+	sortEntries(s.Entries)
+}
+
+// RecordGoal adds the goal's usage to the stats in the file. The file is
+// locked while it is updated, so the goals of other processes (e.g., another
+// REPL) aren't lost.
+func RecordGoal(p string, t *Tracker) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	unlock, err := jsonfile.Lock(p)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s, err := LoadStats(p)
+	if err != nil {
+		return err
+	}
+	s.Add(t)
+	return s.Save(p)
+}
+
+// WriteSummary writes a table of the usage of each agent and tool, followed
+// by the total.
+func WriteSummary(w io.Writer, entries []Entry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "AGENT\tTOOL\tCALLS\tPROMPT TOKENS\tCOMPLETION TOKENS\tLATENCY\n")
+	var total Usage
+	for _, e := range entries {
+		total.add(e.Usage)
+		tool := e.Tool
+		if tool == "" {
+			tool = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t~%d\t~%d\t%s\n", e.Agent, tool, e.Calls, e.PromptTokens, e.CompletionTokens, e.Latency.Round(time.Millisecond))
+	}
+	fmt.Fprintf(tw, "total\t\t%d\t~%d\t~%d\t%s\n", total.Calls, total.PromptTokens, total.CompletionTokens, total.Latency.Round(time.Millisecond))
+	return tw.Flush()
+}
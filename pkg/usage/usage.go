@@ -0,0 +1,209 @@
+// Package usage accounts for how much each agent and tool uses the LLM, so
+// the cost of a goal can be seen and a runaway agent can be stopped.
+//
+// The LLM doesn't report how many tokens it used, so they are estimated from
+// the length of the prompts and responses (see EstimateTokens).
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/tools"
+)
+
+// ErrBudgetExceeded is returned once a goal has used all of its tokens.
+var ErrBudgetExceeded = errors.New("exceeded the token budget")
+
+// Usage is how much the LLM was used.
+type Usage struct {
+	Calls            int           `json:"calls"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	Latency          time.Duration `json:"latency"`
+}
+
+// Tokens is the prompt and completion tokens.
+func (u Usage) Tokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+func (u *Usage) add(o Usage) {
+	u.Calls += o.Calls
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.Latency += o.Latency
+}
+
+// Entry is the usage of an agent (or predictor) while a tool was running.
+// The tool is empty if the agent wasn't called by a tool (e.g., the root
+// agent).
+type Entry struct {
+	Agent string `json:"agent"`
+	Tool  string `json:"tool,omitempty"`
+	Usage
+}
+
+// Tracker adds up the usage for a goal. It is safe for concurrent use.
+type Tracker struct {
+	budget int
+
+	mu      sync.Mutex
+	entries map[key]*Usage
+}
+
+type key struct {
+	agent, tool string
+}
+
+// NewTracker returns a Tracker that stops the LLM from being used once the
+// budget (in tokens) is used up. Zero means there isn't a budget.
+func NewTracker(budget int) *Tracker {
+	return &Tracker{
+		budget:  budget,
+		entries: map[key]*Usage{},
+	}
+}
+
+// Add records the usage for the agent and tool.
+func (t *Tracker) Add(agent, tool string, u Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	k := key{agent: agent, tool: tool}
+	e, ok := t.entries[k]
+	if !ok {
+		e = &Usage{}
+		t.entries[k] = e
+	}
+	e.add(u)
+}
+
+// Entries returns the usage sorted by agent and tool.
+func (t *Tracker) Entries() []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := []Entry{}
+	for k, u := range t.entries {
+		result = append(result, Entry{Agent: k.agent, Tool: k.tool, Usage: *u})
+	}
+	sortEntries(result)
+	return result
+}
+
+// Total returns the usage of every agent and tool.
+func (t *Tracker) Total() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var total Usage
+	for _, u := range t.entries {
+		total.add(*u)
+	}
+	return total
+}
+
+// CheckBudget returns ErrBudgetExceeded if the budget is used up.
+func (t *Tracker) CheckBudget() error {
+	if t.budget <= 0 {
+		return nil
+	}
+	if used := t.Total().Tokens(); used >= t.budget {
+		return fmt.Errorf("%w (used ~%d of %d tokens)", ErrBudgetExceeded, used, t.budget)
+	}
+	return nil
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Agent != entries[j].Agent {
+			return entries[i].Agent < entries[j].Agent
+		}
+		return entries[i].Tool < entries[j].Tool
+	})
+}
+
+type trackerKey struct{}
+
+// WithTracker returns a context whose LLM usage is recorded with the Tracker.
+// Without a Tracker, the usage isn't recorded.
+func WithTracker(ctx context.Context, t *Tracker) context.Context {
+	return context.WithValue(ctx, trackerKey{}, t)
+}
+
+// TrackerFor returns the Tracker given to WithTracker, or nil if there isn't
+// one.
+func TrackerFor(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(trackerKey{}).(*Tracker)
+	return t
+}
+
+// CheckBudget returns ErrBudgetExceeded if the context's Tracker has used up
+// its budget.
+func CheckBudget(ctx context.Context) error {
+	if t := TrackerFor(ctx); t != nil {
+		return t.CheckBudget()
+	}
+	return nil
+}
+
+type toolKey struct{}
+
+// ToolFor returns the name of the tool that is running (see TrackTool). It
+// is empty if a tool isn't running.
+func ToolFor(ctx context.Context) string {
+	name, _ := ctx.Value(toolKey{}).(string)
+	return name
+}
+
+// TrackTool wraps the tool so that the LLM usage while it runs is recorded
+// against it.
+func TrackTool(t tools.Tool) tools.Tool {
+	run := t.Run
+	t.Run = func(ctx context.Context, input string) (string, error) {
+		return run(context.WithValue(ctx, toolKey{}, t.Name), input)
+	}
+	return t
+}
+
+type trackedLLM[TParams any] struct {
+	llm   llms.LLM[TParams]
+	agent string
+}
+
+// NewLLM wraps the LLM so that each call is recorded against the agent (or
+// predictor) and the tool that is running. Once the budget is used up, the
+// LLM isn't called and ErrBudgetExceeded is returned.
+func NewLLM[TParams any](llm llms.LLM[TParams], agent string) llms.LLM[TParams] {
+	return trackedLLM[TParams]{llm: llm, agent: agent}
+}
+
+// Generate implements llms.LLM.
+func (l trackedLLM[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	t := TrackerFor(ctx)
+	if t == nil {
+		return l.llm.Generate(ctx, prompt, params)
+	}
+	if err := t.CheckBudget(); err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	resp, err := l.llm.Generate(ctx, prompt, params)
+	t.Add(l.agent, ToolFor(ctx), Usage{
+		Calls:            1,
+		PromptTokens:     EstimateTokens(prompt),
+		CompletionTokens: EstimateTokens(resp),
+		Latency:          time.Since(start),
+	})
+	return resp, err
+}
+
+// EstimateTokens guesses how many tokens the text is, at about 4 characters
+// per token.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
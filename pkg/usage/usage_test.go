@@ -0,0 +1,183 @@
+package usage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/google/go-react/pkg/tools"
+	"github.com/poy/assistant/pkg/usage"
+)
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		budget int
+		run    func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) error
+		assert func(t *testing.T, tracker *usage.Tracker, llm *llmstesting.Fake[vertex.Params], err error)
+	}{
+		{
+			name: "records each call against the agent",
+			run: func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) error {
+				llm.AlwaysText = "12345678"
+				for i := 0; i < 2; i++ {
+					if _, err := usage.NewLLM[vertex.Params](llm, "Tasks").Generate(ctx, "1234", vertex.Params{}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			assert: func(t *testing.T, tracker *usage.Tracker, llm *llmstesting.Fake[vertex.Params], err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries := tracker.Entries()
+				if len(entries) != 1 {
+					t.Fatalf("expected 1 entry, got %+v", entries)
+				}
+				e := entries[0]
+				if e.Agent != "Tasks" || e.Tool != "" || e.Calls != 2 || e.PromptTokens != 2 || e.CompletionTokens != 4 {
+					t.Fatalf("unexpected usage %+v", e)
+				}
+				if actual, expected := tracker.Total().Tokens(), 6; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "records the calls in a tool against it",
+			run: func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) error {
+				tool := usage.TrackTool(tools.Tool{
+					Name: "add-note",
+					Run: func(ctx context.Context, input string) (string, error) {
+						return usage.NewLLM[vertex.Params](llm, "TaskRewriter").Generate(ctx, input, vertex.Params{})
+					},
+				})
+				_, err := tool.Run(ctx, "buy eggs")
+				return err
+			},
+			assert: func(t *testing.T, tracker *usage.Tracker, llm *llmstesting.Fake[vertex.Params], err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries := tracker.Entries()
+				if len(entries) != 1 || entries[0].Agent != "TaskRewriter" || entries[0].Tool != "add-note" {
+					t.Fatalf("unexpected usage %+v", entries)
+				}
+			},
+		},
+		{
+			name:   "stops once the budget is used up",
+			budget: 3,
+			run: func(ctx context.Context, llm *llmstesting.Fake[vertex.Params]) error {
+				llm.AlwaysText = "1234"
+				for {
+					if _, err := usage.NewLLM[vertex.Params](llm, "Tasks").Generate(ctx, "1234", vertex.Params{}); err != nil {
+						return err
+					}
+				}
+			},
+			assert: func(t *testing.T, tracker *usage.Tracker, llm *llmstesting.Fake[vertex.Params], err error) {
+				if !errors.Is(err, usage.ErrBudgetExceeded) {
+					t.Fatalf("expected %v, got %v", usage.ErrBudgetExceeded, err)
+				}
+				if actual, expected := len(llm.Prompts), 2; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // Avoid issues with closure.
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tracker := usage.NewTracker(tc.budget)
+			llm := &llmstesting.Fake[vertex.Params]{}
+			err := tc.run(usage.WithTracker(context.Background(), tracker), llm)
+			tc.assert(t, tracker, llm, err)
+		})
+	}
+}
+
+func TestWithoutTracker(t *testing.T) {
+	t.Parallel()
+
+	llm := &llmstesting.Fake[vertex.Params]{AlwaysText: "some-text"}
+	resp, err := usage.NewLLM[vertex.Params](llm, "Tasks").Generate(context.Background(), "some-prompt", vertex.Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "some-text"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "usage.json")
+	for _, agent := range []string{"Tasks", "Tasks", "TaskFinder"} {
+		tracker := usage.NewTracker(0)
+		tracker.Add(agent, "", usage.Usage{Calls: 1, PromptTokens: 10, CompletionTokens: 2})
+		if err := usage.RecordGoal(p, tracker); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := usage.LoadStats(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := s.Goals, 3; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if len(s.Entries) != 2 || s.Entries[0].Agent != "TaskFinder" || s.Entries[1].Calls != 2 || s.Entries[1].PromptTokens != 20 {
+		t.Fatalf("unexpected entries %+v", s.Entries)
+	}
+
+	var buf bytes.Buffer
+	if err := usage.WriteSummary(&buf, s.Entries); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "total             3      ~30") {
+		t.Fatalf("expected the total in:\n%s", buf.String())
+	}
+}
+
+func TestRecordGoalConcurrently(t *testing.T) {
+	t.Parallel()
+
+	// The file is shared, e.g., by several REPLs.
+	p := filepath.Join(t.TempDir(), "usage.json")
+	const goals = 10
+	var wg sync.WaitGroup
+	for i := 0; i < goals; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker := usage.NewTracker(0)
+			tracker.Add("Tasks", "", usage.Usage{Calls: 1})
+			if err := usage.RecordGoal(p, tracker); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s, err := usage.LoadStats(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := s.Goals, goals; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}